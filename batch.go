@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+	"wallpaper/entities/command"
+	"wallpaper/entities/jobs"
+	"wallpaper/entities/render"
+)
+
+// batchWallpapers renders every config file through a job queue, options.batchJobs at a time,
+//   and prints each job's state as it changes. A config that fails does not stop the others.
+//   Cancelling ctx cancels the running and waiting jobs.
+func batchWallpapers(ctx context.Context, options *commandLineOptions, progress *progressLine) error {
+	queue := jobs.NewQueue(ctx, options.batchJobs, func(status jobs.Status) {
+		if status.State == jobs.Running && status.Progress.RowsDone > 0 {
+			return
+		}
+		progress.end()
+		switch status.State {
+		case jobs.Failed:
+			fmt.Fprintf(os.Stderr, "job %d %s: %s: %s\n", status.ID, status.Name, status.State, status.Error)
+		case jobs.Done:
+			fmt.Printf("job %d %s: %s in %v\n", status.ID, status.Name, status.State, status.Finished.Sub(status.Started).Round(time.Millisecond))
+		default:
+			fmt.Printf("job %d %s: %s\n", status.ID, status.Name, status.State)
+		}
+	})
+	for _, configFilename := range options.batchFilenames {
+		queue.Submit(configFilename, batchJob(configFilename, options, progress))
+	}
+	queue.Wait()
+
+	failed := 0
+	for _, status := range queue.List() {
+		if status.State != jobs.Done {
+			failed++
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d configs failed", failed, len(options.batchFilenames))
+	}
+	return nil
+}
+
+// batchJob renders one config file the way the render subcommand would, reporting its progress to the queue
+//   and to the progress line.
+func batchJob(configFilename string, options *commandLineOptions, progress *progressLine) jobs.RunFunc {
+	return func(ctx context.Context, reportProgress func(render.Progress)) error {
+		configYAML, err := ioutil.ReadFile(configFilename)
+		if err != nil {
+			return fileError(err)
+		}
+		wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML(configYAML)
+		if err != nil {
+			return invalidConfigError(fmt.Errorf("cannot parse %s: %v", configFilename, err))
+		}
+		wallpaperCommand.ApplyOverrides(options.overrides)
+
+		jobOptions := *options
+		jobOptions.configFilename = configFilename
+		showProgress := progress.reporter(configFilename)
+		jobOptions.progress = func(rendered render.Progress) {
+			reportProgress(rendered)
+			if showProgress != nil {
+				showProgress(rendered)
+			}
+		}
+		return renderWallpaper(ctx, wallpaperCommand, &jobOptions)
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"wallpaper/entities/command"
+	"wallpaper/entities/formula/catalog"
+	"wallpaper/entities/preview"
+	"wallpaper/entities/render"
+)
+
+// Exit codes returned by the program.
+const (
+	exitCodeSuccess       = 0
+	exitCodeFailure       = 1
+	exitCodeUsage         = 2
+	exitCodeInvalidConfig = 3
+	exitCodeFileError     = 4
+)
+
+const defaultConfigFilename = "data/formula.yml"
+
+// Subcommands the program understands.
+const (
+	subcommandRender    = "render"
+	subcommandAnalyze   = "analyze"
+	subcommandPreview   = "preview"
+	subcommandColorize  = "colorize"
+	subcommandReproduce = "reproduce"
+	subcommandWatch     = "watch"
+	subcommandServe     = "serve"
+	subcommandBatch     = "batch"
+	subcommandGroups    = "groups"
+)
+
+var subcommandDescriptions = []struct {
+	name        string
+	description string
+}{
+	{name: subcommandRender, description: "render the wallpaper to the output file"},
+	{name: subcommandAnalyze, description: "report symmetries and value ranges without writing an image"},
+	{name: subcommandPreview, description: "render a small preview stamp of the wallpaper, keeping the last few"},
+	{name: subcommandColorize, description: "color a saved field file without calculating the formula"},
+	{name: subcommandReproduce, description: "render an image again from the recipe stored in it, optionally at a different size"},
+	{name: subcommandWatch, description: "render a preview and then the full image every time the config file or source image changes"},
+	{name: subcommandServe, description: "serve an HTTP API and a web editor on localhost that render posted configs"},
+	{name: subcommandBatch, description: "render several config files, given as arguments, through a job queue"},
+	{name: subcommandGroups, description: "list the rosette, frieze and wallpaper symmetry groups, or describe the groups given as arguments"},
+}
+
+// commandLineOptions holds everything parsed from the command line.
+type commandLineOptions struct {
+	subcommand     string
+	configFilename string
+	overrides      command.Overrides
+	workers        int
+	// memoryBudget is the most memory a streaming render may hold, in bytes. 0 renders the whole image in memory.
+	memoryBudget int64
+	// recipeFilename is the image whose recipe the reproduce subcommand renders.
+	recipeFilename string
+	// previewHistory is how many previews the preview subcommand keeps.
+	previewHistory int
+	// previewIndex writes an HTML page showing the kept previews.
+	previewIndex bool
+	// progress is told how far each render has got. It is nil when nobody is watching.
+	progress func(render.Progress)
+	// listenAddress is where serve listens for HTTP requests.
+	listenAddress string
+	// uploadDirectory is where serve saves uploaded source images. Empty uses a new temporary directory.
+	uploadDirectory string
+	// batchFilenames are the config files the batch subcommand renders.
+	batchFilenames []string
+	// batchJobs is how many configs the batch subcommand renders at once.
+	batchJobs int
+	// groupNames are the symmetry groups the groups subcommand describes. Empty lists every group.
+	groupNames []string
+}
+
+// errUsage is returned when the user asked for help.
+var errUsage = errors.New("usage requested")
+
+// sampleSpaceFlag parses a sample space in the form minx,miny,maxx,maxy.
+type sampleSpaceFlag struct {
+	corners *command.ComplexNumberCorners
+}
+
+func (f *sampleSpaceFlag) String() string {
+	if f.corners == nil {
+		return ""
+	}
+	return fmt.Sprintf("%g,%g,%g,%g", f.corners.MinX, f.corners.MinY, f.corners.MaxX, f.corners.MaxY)
+}
+
+func (f *sampleSpaceFlag) Set(value string) error {
+	fields := strings.Split(value, ",")
+	if len(fields) != 4 {
+		return fmt.Errorf("sample space needs 4 comma separated numbers (minx,miny,maxx,maxy), got %q", value)
+	}
+
+	numbers := []float64{}
+	for _, field := range fields {
+		number, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return fmt.Errorf("sample space value %q is not a number", field)
+		}
+		numbers = append(numbers, number)
+	}
+
+	f.corners = &command.ComplexNumberCorners{
+		MinX: numbers[0],
+		MinY: numbers[1],
+		MaxX: numbers[2],
+		MaxY: numbers[3],
+	}
+	return nil
+}
+
+// parseCommandLine reads the subcommand and its flags.
+func parseCommandLine(args []string, output io.Writer) (*commandLineOptions, error) {
+	if len(args) < 1 {
+		printUsage(output)
+		return nil, errors.New("no subcommand given")
+	}
+
+	subcommand := args[0]
+	if subcommand == "-h" || subcommand == "-help" || subcommand == "--help" || subcommand == "help" {
+		printUsage(output)
+		return nil, errUsage
+	}
+	if !isKnownSubcommand(subcommand) {
+		printUsage(output)
+		return nil, fmt.Errorf("unknown subcommand %q", subcommand)
+	}
+
+	options := &commandLineOptions{subcommand: subcommand}
+
+	flags := flag.NewFlagSet(subcommand, flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.StringVar(&options.configFilename, "config", defaultConfigFilename, "YAML file describing the wallpaper")
+	outputFilename := flags.String("output", "", "output image filename (overrides output_filename)")
+	outputWidth := flags.Int("width", 0, "output width in pixels (overrides output_size.width)")
+	outputHeight := flags.Int("height", 0, "output height in pixels (overrides output_size.height)")
+	flags.IntVar(&options.workers, "workers", 0, "number of goroutines to render with (0 uses every CPU)")
+	memoryBudgetMegabytes := flags.Int64("memory-budget", 0, "stream the PNG in strips using at most this many MiB (0 keeps the whole image in memory)")
+	fieldFilename := flags.String("field", "", "file that caches the formula's values (overrides field_filename)")
+	supersample := flags.Int("supersample", 0, "take NxN samples per pixel and average them (overrides supersample.size)")
+	sampleSpace := &sampleSpaceFlag{}
+	flags.Var(sampleSpace, "sample-space", "sample space as minx,miny,maxx,maxy (overrides sample_space)")
+	if subcommand == subcommandReproduce {
+		flags.StringVar(&options.recipeFilename, "from", "", "PNG rendered by this program whose recipe is rendered again (-config is ignored)")
+	}
+	if subcommand == subcommandServe {
+		flags.StringVar(&options.listenAddress, "listen", defaultListenAddress, "localhost address and port to serve on")
+		flags.StringVar(&options.uploadDirectory, "uploads", "", "directory for uploaded source images (default: a new temporary directory)")
+	}
+	if subcommand == subcommandPreview || subcommand == subcommandWatch {
+		flags.IntVar(&options.previewHistory, "history", preview.DefaultDepth, "number of previews to keep as <output>.preview.<N>.png, 1 is the newest")
+		flags.BoolVar(&options.previewIndex, "index", false, "also write <output>.previews.html showing the kept previews")
+	}
+	if subcommand == subcommandBatch {
+		flags.IntVar(&options.batchJobs, "jobs", 1, "number of configs to render at once, each still uses -workers goroutines")
+	}
+
+	err := flags.Parse(args[1:])
+	if err == flag.ErrHelp {
+		return nil, errUsage
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subcommand == subcommandBatch {
+		options.batchFilenames = flags.Args()
+		if len(options.batchFilenames) == 0 {
+			return nil, errors.New("batch needs at least 1 config file")
+		}
+		if options.batchJobs < 1 {
+			return nil, fmt.Errorf("jobs must be positive, got %d", options.batchJobs)
+		}
+		if *outputFilename != "" {
+			return nil, errors.New("batch cannot use -output, each config names its own output file")
+		}
+	} else if subcommand == subcommandGroups {
+		options.groupNames = flags.Args()
+		for _, name := range options.groupNames {
+			_, err := catalog.Find(name)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+	if *memoryBudgetMegabytes < 0 {
+		return nil, fmt.Errorf("memory budget cannot be negative, got %d", *memoryBudgetMegabytes)
+	}
+	options.memoryBudget = *memoryBudgetMegabytes << 20
+	if subcommand == subcommandReproduce && options.recipeFilename == "" {
+		return nil, errors.New("reproduce needs an image, use -from")
+	}
+	if (subcommand == subcommandPreview || subcommand == subcommandWatch) && options.previewHistory < 1 {
+		return nil, fmt.Errorf("history must keep at least 1 preview, got %d", options.previewHistory)
+	}
+
+	var sizeError error
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "output":
+			options.overrides.OutputFilename = outputFilename
+		case "width":
+			if *outputWidth < 1 {
+				sizeError = fmt.Errorf("width must be positive, got %d", *outputWidth)
+			}
+			options.overrides.OutputWidth = outputWidth
+		case "height":
+			if *outputHeight < 1 {
+				sizeError = fmt.Errorf("height must be positive, got %d", *outputHeight)
+			}
+			options.overrides.OutputHeight = outputHeight
+		case "sample-space":
+			options.overrides.SampleSpace = sampleSpace.corners
+		case "field":
+			options.overrides.FieldFilename = fieldFilename
+		case "supersample":
+			if *supersample < 1 {
+				sizeError = fmt.Errorf("supersample must be positive, got %d", *supersample)
+			}
+			options.overrides.Supersample = supersample
+		}
+	})
+	if sizeError != nil {
+		return nil, sizeError
+	}
+
+	return options, nil
+}
+
+func isKnownSubcommand(subcommand string) bool {
+	for _, known := range subcommandDescriptions {
+		if known.name == subcommand {
+			return true
+		}
+	}
+	return false
+}
+
+func printUsage(output io.Writer) {
+	fmt.Fprintln(output, "Usage: wallpaper <subcommand> [flags]")
+	fmt.Fprintln(output, "")
+	fmt.Fprintln(output, "Subcommands:")
+	for _, subcommand := range subcommandDescriptions {
+		fmt.Fprintf(output, "  %-10s %s\n", subcommand.name, subcommand.description)
+	}
+	fmt.Fprintln(output, "")
+	fmt.Fprintln(output, "Run 'wallpaper <subcommand> -h' to see the flags for a subcommand.")
+}
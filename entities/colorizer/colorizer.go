@@ -0,0 +1,141 @@
+package colorizer
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"wallpaper/entities/mathutility"
+)
+
+// Colorizer picks the color for a value transformed by a formula.
+type Colorizer interface {
+	ColorAt(transformedValue complex128) color.NRGBA64
+}
+
+// SourceImage colors values by sampling a source image.
+//   Values are moved by the Mapping, if any, then values inside the ValueSpace are scaled
+//   to a point on the Source and sampled with the Filter.
+//   Values outside of the ValueSpace are colored according to the OutOfRange mode.
+type SourceImage struct {
+	Source image.Image
+	// Mapping moves values before they are compared with the value space. nil leaves them alone.
+	Mapping       ValueMapping
+	ValueSpaceMin complex128
+	ValueSpaceMax complex128
+	// Filter samples the Source. nil uses NearestFilter.
+	Filter Filter
+	// OutOfRange colors values outside of the value space. Empty is the same as OutOfRangeTransparent.
+	OutOfRange OutOfRangeMode
+	// Background is used by OutOfRangeBackground and OutOfRangeFade. nil is transparent black.
+	Background color.Color
+	// FadeDistance is how far past the value space OutOfRangeFade reaches the Background,
+	//   as a fraction of the value space's size. 0 or less fades over the full size.
+	FadeDistance float64
+}
+
+// ColorAt returns the Source color that transformedValue maps to.
+func (sourceImage *SourceImage) ColorAt(transformedValue complex128) color.NRGBA64 {
+	if sourceImage.Mapping != nil {
+		transformedValue = sourceImage.Mapping.Map(transformedValue)
+	}
+	if sourceImage.OutOfRange != "" && sourceImage.OutOfRange != OutOfRangeTransparent {
+		return sourceImage.colorWithOutOfRangeMode(transformedValue)
+	}
+
+	if !sourceImage.inValueSpace(transformedValue) {
+		return color.NRGBA64{R: 0, G: 0, B: 0, A: 0}
+	}
+
+	sourceImageBounds := sourceImage.Source.Bounds()
+	sourceImageX := mathutility.ScaleValueBetweenTwoRanges(
+		real(transformedValue),
+		real(sourceImage.ValueSpaceMin),
+		real(sourceImage.ValueSpaceMax),
+		float64(sourceImageBounds.Min.X),
+		float64(sourceImageBounds.Max.X),
+	)
+	sourceImageY := mathutility.ScaleValueBetweenTwoRanges(
+		imag(transformedValue),
+		imag(sourceImage.ValueSpaceMin),
+		imag(sourceImage.ValueSpaceMax),
+		float64(sourceImageBounds.Min.Y),
+		float64(sourceImageBounds.Max.Y),
+	)
+
+	filter := sourceImage.Filter
+	if filter == nil {
+		filter = NearestFilter
+	}
+	return filter.Sample(sourceImage.Source, sourceImageX, sourceImageY)
+}
+
+func (sourceImage *SourceImage) inValueSpace(transformedValue complex128) bool {
+	return real(transformedValue) >= real(sourceImage.ValueSpaceMin) &&
+		imag(transformedValue) >= imag(sourceImage.ValueSpaceMin) &&
+		real(transformedValue) <= real(sourceImage.ValueSpaceMax) &&
+		imag(transformedValue) <= imag(sourceImage.ValueSpaceMax)
+}
+
+// colorWithOutOfRangeMode measures how far across the value space transformedValue is,
+//   moves it back into the value space if the OutOfRange mode says to, and samples the Source there.
+func (sourceImage *SourceImage) colorWithOutOfRangeMode(transformedValue complex128) color.NRGBA64 {
+	horizontal := valueSpaceFraction(real(transformedValue), real(sourceImage.ValueSpaceMin), real(sourceImage.ValueSpaceMax))
+	vertical := valueSpaceFraction(imag(transformedValue), imag(sourceImage.ValueSpaceMin), imag(sourceImage.ValueSpaceMax))
+
+	switch sourceImage.OutOfRange {
+	case OutOfRangeBackground:
+		if !sourceImage.inValueSpace(transformedValue) {
+			return sourceImage.backgroundColor()
+		}
+		return sourceImage.sampleFraction(horizontal, vertical)
+	case OutOfRangeTile:
+		return sourceImage.sampleFraction(tileFraction(horizontal), tileFraction(vertical))
+	case OutOfRangeMirror:
+		return sourceImage.sampleFraction(mirrorFraction(horizontal), mirrorFraction(vertical))
+	case OutOfRangeFade:
+		edgeColor := sourceImage.sampleFraction(clampFraction(horizontal), clampFraction(vertical))
+		fadeDistance := sourceImage.FadeDistance
+		if fadeDistance <= 0 {
+			fadeDistance = 1
+		}
+		distance := math.Max(distanceOutside(horizontal), distanceOutside(vertical))
+		return blendColors(edgeColor, sourceImage.backgroundColor(), math.Min(1, distance/fadeDistance))
+	}
+	return sourceImage.sampleFraction(clampFraction(horizontal), clampFraction(vertical))
+}
+
+// sampleFraction samples the Source at a point measured as a fraction of its width and height.
+func (sourceImage *SourceImage) sampleFraction(horizontal, vertical float64) color.NRGBA64 {
+	bounds := sourceImage.Source.Bounds()
+	sourceImageX := fractionToPixel(horizontal, bounds.Min.X, bounds.Max.X)
+	sourceImageY := fractionToPixel(vertical, bounds.Min.Y, bounds.Max.Y)
+
+	filter := sourceImage.Filter
+	if filter == nil {
+		filter = NearestFilter
+	}
+	return filter.Sample(sourceImage.Source, sourceImageX, sourceImageY)
+}
+
+func (sourceImage *SourceImage) backgroundColor() color.NRGBA64 {
+	if sourceImage.Background == nil {
+		return color.NRGBA64{}
+	}
+	red, green, blue, alpha := sourceImage.Background.RGBA()
+	return color.NRGBA64{R: uint16(red), G: uint16(green), B: uint16(blue), A: uint16(alpha)}
+}
+
+// valueSpaceFraction measures how far value is from minimum to maximum, 0 at minimum and 1 at maximum.
+func valueSpaceFraction(value, minimum, maximum float64) float64 {
+	if maximum == minimum {
+		return 0
+	}
+	return (value - minimum) / (maximum - minimum)
+}
+
+// fractionToPixel scales a fraction from 0 to 1 into a coordinate inside the pixels from minimum to maximum.
+//   A fraction of 1 lands just inside the last pixel instead of past it.
+func fractionToPixel(fraction float64, minimum, maximum int) float64 {
+	coordinate := float64(minimum) + fraction*float64(maximum-minimum)
+	return math.Max(float64(minimum), math.Min(coordinate, math.Nextafter(float64(maximum), math.Inf(-1))))
+}
@@ -0,0 +1,48 @@
+package colorizer_test
+
+import (
+	. "gopkg.in/check.v1"
+	"image"
+	"image/color"
+	"testing"
+	"wallpaper/entities/colorizer"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type SourceImageSuite struct {
+	sourceColorizer *colorizer.SourceImage
+}
+
+var _ = Suite(&SourceImageSuite{})
+
+func (suite *SourceImageSuite) SetUpTest(checker *C) {
+	source := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	source.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	source.Set(1, 0, color.NRGBA{G: 255, A: 255})
+	source.Set(0, 1, color.NRGBA{B: 255, A: 255})
+	source.Set(1, 1, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+
+	suite.sourceColorizer = &colorizer.SourceImage{
+		Source:        source,
+		ValueSpaceMin: complex(-1, -1),
+		ValueSpaceMax: complex(1, 1),
+	}
+}
+
+func (suite *SourceImageSuite) TestValuesMapToSourcePixels(checker *C) {
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(-0.5, -0.5)), Equals, color.NRGBA64{R: 0xffff, A: 0xffff})
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(0.5, -0.5)), Equals, color.NRGBA64{G: 0xffff, A: 0xffff})
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(-0.5, 0.5)), Equals, color.NRGBA64{B: 0xffff, A: 0xffff})
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(0.5, 0.5)), Equals, color.NRGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff})
+}
+
+func (suite *SourceImageSuite) TestValuesOutsideValueSpaceAreTransparent(checker *C) {
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(-2, 0)), Equals, color.NRGBA64{})
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(0, 1.5)), Equals, color.NRGBA64{})
+}
+
+func (suite *SourceImageSuite) TestFilterSamplesBetweenPixels(checker *C) {
+	suite.sourceColorizer.Filter = colorizer.BilinearFilter
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(0, -0.5)), Equals, color.NRGBA64{R: 0x8000, G: 0x8000, A: 0xffff})
+}
@@ -0,0 +1,103 @@
+package colorizer
+
+import (
+	"image/color"
+	"math"
+	"math/cmplx"
+)
+
+// DomainColoring colors values without a source image.
+//   The argument of the value picks the hue: red at 0, then yellow, green, cyan, blue and magenta going counterclockwise.
+//   The modulus picks the lightness: black at 0, fully saturated at ModulusScale and white at infinity.
+type DomainColoring struct {
+	// ModulusScale is the modulus that gets fully saturated colors. 0 or less uses 1.
+	ModulusScale float64 `json:"modulus_scale" yaml:"modulus_scale"`
+	// ModulusContours shades a band between each power of 2 of the modulus.
+	ModulusContours bool `json:"modulus_contours" yaml:"modulus_contours"`
+	// PhaseBands shades this many bands around the circle of arguments. 0 has no bands.
+	PhaseBands int `json:"phase_bands" yaml:"phase_bands"`
+	// ContourStrength is how dark the contours get, from 0 to 1. 0 or less uses DefaultContourStrength.
+	ContourStrength float64 `json:"contour_strength" yaml:"contour_strength"`
+}
+
+// DefaultContourStrength is how dark DomainColoring contours get if the strength is not set.
+const DefaultContourStrength = 0.25
+
+// ColorAt returns the color for transformedValue's argument and modulus.
+//   Infinite values are white and NaN values are transparent.
+func (domainColoring *DomainColoring) ColorAt(transformedValue complex128) color.NRGBA64 {
+	if cmplx.IsInf(transformedValue) {
+		return color.NRGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff}
+	}
+	if cmplx.IsNaN(transformedValue) {
+		return color.NRGBA64{}
+	}
+
+	modulusScale := domainColoring.ModulusScale
+	if modulusScale <= 0 {
+		modulusScale = 1
+	}
+	modulus := cmplx.Abs(transformedValue)
+	hue := cmplx.Phase(transformedValue) / (2 * math.Pi)
+	if hue < 0 {
+		hue++
+	}
+	lightness := 2 / math.Pi * math.Atan(modulus/modulusScale)
+	red, green, blue := hslToRGB(hue, 1, lightness)
+
+	shade := 1.0
+	contourStrength := domainColoring.ContourStrength
+	if contourStrength <= 0 {
+		contourStrength = DefaultContourStrength
+	}
+	if domainColoring.ModulusContours && modulus > 0 {
+		logModulus := math.Log2(modulus / modulusScale)
+		shade *= 1 - contourStrength + contourStrength*(logModulus-math.Floor(logModulus))
+	}
+	if domainColoring.PhaseBands > 0 {
+		bandPosition := hue * float64(domainColoring.PhaseBands)
+		shade *= 1 - contourStrength + contourStrength*(bandPosition-math.Floor(bandPosition))
+	}
+
+	return color.NRGBA64{
+		R: unitToChannel(red * shade),
+		G: unitToChannel(green * shade),
+		B: unitToChannel(blue * shade),
+		A: 0xffff,
+	}
+}
+
+// hslToRGB converts a hue, saturation and lightness, each from 0 to 1, into red, green and blue from 0 to 1.
+func hslToRGB(hue, saturation, lightness float64) (float64, float64, float64) {
+	chroma := (1 - math.Abs(2*lightness-1)) * saturation
+	huePrime := hue * 6
+	second := chroma * (1 - math.Abs(math.Mod(huePrime, 2)-1))
+	var red, green, blue float64
+	switch {
+	case huePrime < 1:
+		red, green, blue = chroma, second, 0
+	case huePrime < 2:
+		red, green, blue = second, chroma, 0
+	case huePrime < 3:
+		red, green, blue = 0, chroma, second
+	case huePrime < 4:
+		red, green, blue = 0, second, chroma
+	case huePrime < 5:
+		red, green, blue = second, 0, chroma
+	default:
+		red, green, blue = chroma, 0, second
+	}
+	lightnessOffset := lightness - chroma/2
+	return red + lightnessOffset, green + lightnessOffset, blue + lightnessOffset
+}
+
+// unitToChannel rounds a value from 0 to 1 into a 16 bit channel.
+func unitToChannel(value float64) uint16 {
+	if value <= 0 {
+		return 0
+	}
+	if value >= 1 {
+		return 0xffff
+	}
+	return uint16(value*0xffff + 0.5)
+}
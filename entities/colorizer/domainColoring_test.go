@@ -0,0 +1,64 @@
+package colorizer_test
+
+import (
+	. "gopkg.in/check.v1"
+	"image/color"
+	"math"
+	"math/cmplx"
+	"wallpaper/entities/colorizer"
+)
+
+type DomainColoringSuite struct {
+	domainColoring *colorizer.DomainColoring
+}
+
+var _ = Suite(&DomainColoringSuite{})
+
+func (suite *DomainColoringSuite) SetUpTest(checker *C) {
+	suite.domainColoring = &colorizer.DomainColoring{}
+}
+
+func (suite *DomainColoringSuite) TestArgumentPicksTheHue(checker *C) {
+	checker.Assert(suite.domainColoring.ColorAt(complex(1, 0)), Equals, color.NRGBA64{R: 0xffff, A: 0xffff})
+	checker.Assert(suite.domainColoring.ColorAt(cmplx.Rect(1, 2*math.Pi/3)), Equals, color.NRGBA64{G: 0xffff, A: 0xffff})
+	checker.Assert(suite.domainColoring.ColorAt(cmplx.Rect(1, -2*math.Pi/3)), Equals, color.NRGBA64{B: 0xffff, A: 0xffff})
+	checker.Assert(suite.domainColoring.ColorAt(complex(-1, 0)), Equals, color.NRGBA64{G: 0xffff, B: 0xffff, A: 0xffff})
+}
+
+func (suite *DomainColoringSuite) TestModulusPicksTheLightness(checker *C) {
+	checker.Assert(suite.domainColoring.ColorAt(0), Equals, color.NRGBA64{A: 0xffff})
+	checker.Assert(suite.domainColoring.ColorAt(cmplx.Inf()), Equals, color.NRGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff})
+
+	dark := suite.domainColoring.ColorAt(complex(0.5, 0))
+	light := suite.domainColoring.ColorAt(complex(4, 0))
+	checker.Assert(dark.R < 0xffff && dark.G == 0, Equals, true)
+	checker.Assert(light.R == 0xffff && light.G > 0, Equals, true)
+}
+
+func (suite *DomainColoringSuite) TestModulusScaleMovesFullSaturation(checker *C) {
+	suite.domainColoring.ModulusScale = 10
+	checker.Assert(suite.domainColoring.ColorAt(complex(10, 0)), Equals, color.NRGBA64{R: 0xffff, A: 0xffff})
+}
+
+func (suite *DomainColoringSuite) TestNaNIsTransparent(checker *C) {
+	checker.Assert(suite.domainColoring.ColorAt(cmplx.NaN()), Equals, color.NRGBA64{})
+}
+
+func (suite *DomainColoringSuite) TestModulusContoursDarkenTheStartOfEachBand(checker *C) {
+	suite.domainColoring.ModulusContours = true
+	suite.domainColoring.ContourStrength = 0.5
+
+	startOfBand := suite.domainColoring.ColorAt(complex(1, 0))
+	checker.Assert(startOfBand, Equals, color.NRGBA64{R: 0x8000, A: 0xffff})
+	endOfBand := suite.domainColoring.ColorAt(complex(1.99, 0))
+	checker.Assert(endOfBand.R > 0xf000, Equals, true)
+}
+
+func (suite *DomainColoringSuite) TestPhaseBandsDarkenTheStartOfEachBand(checker *C) {
+	suite.domainColoring.PhaseBands = 4
+
+	startOfBand := suite.domainColoring.ColorAt(cmplx.Rect(1, math.Pi/2+0.001))
+	endOfBand := suite.domainColoring.ColorAt(cmplx.Rect(1, math.Pi/2-0.001))
+	checker.Assert(startOfBand.G < 0xc100, Equals, true)
+	checker.Assert(endOfBand.G > 0xff00, Equals, true)
+}
@@ -0,0 +1,171 @@
+package colorizer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Filter reads the color of an image at a point that may fall between pixels.
+type Filter interface {
+	// Sample returns the color at (x, y), in the image's pixel coordinates.
+	//   Pixel (i, j) covers the square from (i, j) to (i+1, j+1).
+	Sample(source image.Image, x, y float64) color.NRGBA64
+}
+
+// Sampling filter names used in the config.
+const (
+	FilterNameNearest  = "nearest"
+	FilterNameBilinear = "bilinear"
+	FilterNameBicubic  = "bicubic"
+	FilterNameLanczos  = "lanczos"
+)
+
+// NearestFilter uses the color of the pixel the point lands in.
+var NearestFilter Filter = nearestFilter{}
+
+// BilinearFilter blends the 4 closest pixels.
+var BilinearFilter Filter = &kernelFilter{support: 1, kernel: triangleKernel}
+
+// BicubicFilter blends the 16 closest pixels with a Catmull-Rom spline.
+var BicubicFilter Filter = &kernelFilter{support: 2, kernel: catmullRomKernel}
+
+// LanczosFilter blends the 36 closest pixels with a 3 lobed Lanczos kernel.
+var LanczosFilter Filter = &kernelFilter{support: 3, kernel: lanczos3Kernel}
+
+// FilterByName returns the Filter with the given name. An empty name returns NearestFilter.
+func FilterByName(name string) (Filter, error) {
+	switch name {
+	case "", FilterNameNearest:
+		return NearestFilter, nil
+	case FilterNameBilinear:
+		return BilinearFilter, nil
+	case FilterNameBicubic:
+		return BicubicFilter, nil
+	case FilterNameLanczos:
+		return LanczosFilter, nil
+	}
+	return nil, fmt.Errorf("unknown sampling filter %q, expected one of %s, %s, %s or %s",
+		name, FilterNameNearest, FilterNameBilinear, FilterNameBicubic, FilterNameLanczos)
+}
+
+type nearestFilter struct{}
+
+// Sample truncates the point to a pixel. Points outside of the image are transparent.
+func (filter nearestFilter) Sample(source image.Image, x, y float64) color.NRGBA64 {
+	sourceColorR, sourceColorG, sourceColorB, sourceColorA := source.At(int(x), int(y)).RGBA()
+	return color.NRGBA64{
+		R: uint16(sourceColorR),
+		G: uint16(sourceColorG),
+		B: uint16(sourceColorB),
+		A: uint16(sourceColorA),
+	}
+}
+
+// kernelFilter weighs the pixels around the point with a separable kernel.
+//   Pixels past the edge of the image repeat the edge pixels.
+type kernelFilter struct {
+	// support is the distance from the point where the kernel drops to 0.
+	support float64
+	kernel  func(distance float64) float64
+}
+
+// Sample blends the pixels whose centers are within the kernel's support.
+//   Like image.Image.RGBA, the channels are premultiplied by alpha.
+func (filter *kernelFilter) Sample(source image.Image, x, y float64) color.NRGBA64 {
+	bounds := source.Bounds()
+	centerX := x - 0.5
+	centerY := y - 0.5
+	firstX := int(math.Floor(centerX-filter.support)) + 1
+	lastX := int(math.Floor(centerX + filter.support))
+	firstY := int(math.Floor(centerY-filter.support)) + 1
+	lastY := int(math.Floor(centerY + filter.support))
+
+	var red, green, blue, alpha, totalWeight float64
+	for pixelY := firstY; pixelY <= lastY; pixelY++ {
+		weightY := filter.kernel(float64(pixelY) - centerY)
+		if weightY == 0 {
+			continue
+		}
+		for pixelX := firstX; pixelX <= lastX; pixelX++ {
+			weight := weightY * filter.kernel(float64(pixelX)-centerX)
+			if weight == 0 {
+				continue
+			}
+			sourceColorR, sourceColorG, sourceColorB, sourceColorA := source.At(
+				clampInt(pixelX, bounds.Min.X, bounds.Max.X-1),
+				clampInt(pixelY, bounds.Min.Y, bounds.Max.Y-1),
+			).RGBA()
+			red += weight * float64(sourceColorR)
+			green += weight * float64(sourceColorG)
+			blue += weight * float64(sourceColorB)
+			alpha += weight * float64(sourceColorA)
+			totalWeight += weight
+		}
+	}
+	if totalWeight == 0 {
+		return color.NRGBA64{}
+	}
+
+	blendedAlpha := clampChannel(alpha/totalWeight, 0xffff)
+	return color.NRGBA64{
+		R: clampChannel(red/totalWeight, float64(blendedAlpha)),
+		G: clampChannel(green/totalWeight, float64(blendedAlpha)),
+		B: clampChannel(blue/totalWeight, float64(blendedAlpha)),
+		A: blendedAlpha,
+	}
+}
+
+func triangleKernel(distance float64) float64 {
+	distance = math.Abs(distance)
+	if distance >= 1 {
+		return 0
+	}
+	return 1 - distance
+}
+
+func catmullRomKernel(distance float64) float64 {
+	distance = math.Abs(distance)
+	if distance < 1 {
+		return (1.5*distance-2.5)*distance*distance + 1
+	}
+	if distance < 2 {
+		return ((-0.5*distance+2.5)*distance-4)*distance + 2
+	}
+	return 0
+}
+
+func lanczos3Kernel(distance float64) float64 {
+	distance = math.Abs(distance)
+	if distance == 0 {
+		return 1
+	}
+	if distance >= 3 {
+		return 0
+	}
+	piDistance := math.Pi * distance
+	return 3 * math.Sin(piDistance) * math.Sin(piDistance/3) / (piDistance * piDistance)
+}
+
+func clampInt(value, minimum, maximum int) int {
+	if value < minimum {
+		return minimum
+	}
+	if value > maximum {
+		return maximum
+	}
+	return value
+}
+
+// clampChannel rounds value to the nearest channel value between 0 and maximum.
+//   Kernels with negative lobes can overshoot, and premultiplied colors cannot be brighter than their alpha.
+func clampChannel(value, maximum float64) uint16 {
+	if value <= 0 {
+		return 0
+	}
+	if value >= maximum {
+		return uint16(maximum)
+	}
+	return uint16(value + 0.5)
+}
@@ -0,0 +1,78 @@
+package colorizer_test
+
+import (
+	. "gopkg.in/check.v1"
+	"image"
+	"image/color"
+	"wallpaper/entities/colorizer"
+)
+
+type FilterSuite struct {
+	stripes *image.NRGBA
+	flat    *image.NRGBA
+}
+
+var _ = Suite(&FilterSuite{})
+
+func (suite *FilterSuite) SetUpTest(checker *C) {
+	suite.stripes = image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	suite.flat = image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			suite.stripes.Set(x, y, color.NRGBA{R: uint8(255 * (x % 2)), A: 255})
+			suite.flat.Set(x, y, color.NRGBA{R: 10, G: 100, B: 200, A: 255})
+		}
+	}
+}
+
+func (suite *FilterSuite) TestFilterByName(checker *C) {
+	for name, expected := range map[string]colorizer.Filter{
+		"":         colorizer.NearestFilter,
+		"nearest":  colorizer.NearestFilter,
+		"bilinear": colorizer.BilinearFilter,
+		"bicubic":  colorizer.BicubicFilter,
+		"lanczos":  colorizer.LanczosFilter,
+	} {
+		filter, err := colorizer.FilterByName(name)
+		checker.Assert(err, IsNil)
+		checker.Assert(filter, Equals, expected)
+	}
+
+	_, err := colorizer.FilterByName("blurry")
+	checker.Assert(err, ErrorMatches, `unknown sampling filter "blurry".*`)
+}
+
+func (suite *FilterSuite) TestNearestTruncatesToAPixel(checker *C) {
+	checker.Assert(colorizer.NearestFilter.Sample(suite.stripes, 1.9, 0.2), Equals, color.NRGBA64{R: 0xffff, A: 0xffff})
+	checker.Assert(colorizer.NearestFilter.Sample(suite.stripes, 2.1, 0.2), Equals, color.NRGBA64{A: 0xffff})
+}
+
+func (suite *FilterSuite) TestEveryFilterReturnsPixelCentersExactly(checker *C) {
+	for _, filter := range []colorizer.Filter{colorizer.BilinearFilter, colorizer.BicubicFilter, colorizer.LanczosFilter} {
+		checker.Assert(filter.Sample(suite.stripes, 1.5, 1.5), Equals, color.NRGBA64{R: 0xffff, A: 0xffff})
+		checker.Assert(filter.Sample(suite.stripes, 2.5, 1.5), Equals, color.NRGBA64{A: 0xffff})
+	}
+}
+
+func (suite *FilterSuite) TestEveryFilterKeepsFlatColors(checker *C) {
+	expected := color.NRGBA64{R: 10 * 0x101, G: 100 * 0x101, B: 200 * 0x101, A: 0xffff}
+	for _, filter := range []colorizer.Filter{colorizer.BilinearFilter, colorizer.BicubicFilter, colorizer.LanczosFilter} {
+		checker.Assert(filter.Sample(suite.flat, 0.1, 3.9), Equals, expected)
+		checker.Assert(filter.Sample(suite.flat, 2.3, 1.7), Equals, expected)
+	}
+}
+
+func (suite *FilterSuite) TestBilinearBlendsNeighbors(checker *C) {
+	checker.Assert(colorizer.BilinearFilter.Sample(suite.stripes, 2, 1.5), Equals, color.NRGBA64{R: 0x8000, A: 0xffff})
+	checker.Assert(colorizer.BilinearFilter.Sample(suite.stripes, 1.75, 1.5), Equals, color.NRGBA64{R: 0xbfff, A: 0xffff})
+}
+
+func (suite *FilterSuite) TestSharpFiltersStayInRange(checker *C) {
+	for _, filter := range []colorizer.Filter{colorizer.BicubicFilter, colorizer.LanczosFilter} {
+		for x := 0.0; x < 4; x += 0.1 {
+			sampled := filter.Sample(suite.stripes, x, 2)
+			checker.Assert(sampled.A, Equals, uint16(0xffff))
+			checker.Assert(sampled.G, Equals, uint16(0))
+		}
+	}
+}
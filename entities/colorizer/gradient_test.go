@@ -0,0 +1,103 @@
+package colorizer_test
+
+import (
+	. "gopkg.in/check.v1"
+	"image/color"
+	"math"
+	"math/cmplx"
+	"wallpaper/entities/colorizer"
+	"wallpaper/entities/utility"
+)
+
+type GradientSuite struct {
+	blackToWhite []colorizer.GradientStop
+}
+
+var _ = Suite(&GradientSuite{})
+
+func (suite *GradientSuite) SetUpTest(checker *C) {
+	suite.blackToWhite = []colorizer.GradientStop{
+		{Position: 0, Color: color.NRGBA{A: 0xff}},
+		{Position: 1, Color: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+	}
+}
+
+func (suite *GradientSuite) TestEndsUseTheStopColors(checker *C) {
+	gradient, err := colorizer.NewGradient(suite.blackToWhite, colorizer.GradientScalarModulus, 0, 1)
+	checker.Assert(err, IsNil)
+	checker.Assert(gradient.ColorAt(0), Equals, color.NRGBA64{A: 0xffff})
+	checker.Assert(gradient.ColorAt(1), Equals, color.NRGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff})
+}
+
+func (suite *GradientSuite) TestValuesPastTheEndsAreClamped(checker *C) {
+	gradient, _ := colorizer.NewGradient(suite.blackToWhite, colorizer.GradientScalarReal, 0, 1)
+	checker.Assert(gradient.ColorAt(-5), Equals, color.NRGBA64{A: 0xffff})
+	checker.Assert(gradient.ColorAt(5), Equals, color.NRGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff})
+}
+
+func (suite *GradientSuite) TestBlendsInOKLab(checker *C) {
+	gradient, _ := colorizer.NewGradient(suite.blackToWhite, colorizer.GradientScalarModulus, 0, 1)
+	middle := gradient.ColorAt(0.5)
+
+	// OKLab lightness 0.5 is about 39% sRGB, darker than the 50% a plain sRGB blend gives.
+	checker.Assert(middle.R, Equals, middle.G)
+	checker.Assert(middle.G, Equals, middle.B)
+	checker.Assert(float64(middle.R)/0xffff, utility.NumericallyCloseEnough{}, 0.389, 0.01)
+}
+
+func (suite *GradientSuite) TestScalarPicksThePartOfTheValue(checker *C) {
+	white := color.NRGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff}
+
+	argument, _ := colorizer.NewGradient(suite.blackToWhite, colorizer.GradientScalarArgument, -math.Pi, math.Pi)
+	checker.Assert(argument.ColorAt(complex(-1, 0)), Equals, white)
+
+	imaginary, _ := colorizer.NewGradient(suite.blackToWhite, colorizer.GradientScalarImaginary, 0, 1)
+	checker.Assert(imaginary.ColorAt(complex(1, 0)), Equals, color.NRGBA64{A: 0xffff})
+	checker.Assert(imaginary.ColorAt(complex(0, 1)), Equals, white)
+
+	modulus, _ := colorizer.NewGradient(suite.blackToWhite, colorizer.GradientScalarModulus, 0, 5)
+	checker.Assert(modulus.ColorAt(complex(3, 4)), Equals, white)
+}
+
+func (suite *GradientSuite) TestStopsDoNotNeedToBeSorted(checker *C) {
+	reversed := []colorizer.GradientStop{suite.blackToWhite[1], suite.blackToWhite[0]}
+	gradient, err := colorizer.NewGradient(reversed, colorizer.GradientScalarModulus, 0, 1)
+	checker.Assert(err, IsNil)
+	checker.Assert(gradient.ColorAt(0), Equals, color.NRGBA64{A: 0xffff})
+}
+
+func (suite *GradientSuite) TestAlphaIsPremultiplied(checker *C) {
+	stops := []colorizer.GradientStop{{Position: 0, Color: color.NRGBA{R: 0xff, A: 0}}}
+	gradient, _ := colorizer.NewGradient(stops, colorizer.GradientScalarModulus, 0, 1)
+	checker.Assert(gradient.ColorAt(0.5), Equals, color.NRGBA64{})
+}
+
+func (suite *GradientSuite) TestNaNIsTransparent(checker *C) {
+	gradient, _ := colorizer.NewGradient(suite.blackToWhite, colorizer.GradientScalarModulus, 0, 1)
+	checker.Assert(gradient.ColorAt(cmplx.NaN()), Equals, color.NRGBA64{})
+}
+
+func (suite *GradientSuite) TestInvalidGradientsAreRejected(checker *C) {
+	_, err := colorizer.NewGradient(nil, colorizer.GradientScalarModulus, 0, 1)
+	checker.Assert(err, ErrorMatches, ".*at least one stop.*")
+
+	_, err = colorizer.NewGradient(suite.blackToWhite, colorizer.GradientScalarModulus, 1, 1)
+	checker.Assert(err, ErrorMatches, ".*cannot be empty.*")
+
+	outOfRange := []colorizer.GradientStop{{Position: 1.5}}
+	_, err = colorizer.NewGradient(outOfRange, colorizer.GradientScalarModulus, 0, 1)
+	checker.Assert(err, ErrorMatches, ".*between 0 and 1.*")
+}
+
+func (suite *GradientSuite) TestParseGradientScalar(checker *C) {
+	scalar, err := colorizer.ParseGradientScalar("")
+	checker.Assert(err, IsNil)
+	checker.Assert(scalar, Equals, colorizer.GradientScalarModulus)
+
+	scalar, err = colorizer.ParseGradientScalar("argument")
+	checker.Assert(err, IsNil)
+	checker.Assert(scalar, Equals, colorizer.GradientScalarArgument)
+
+	_, err = colorizer.ParseGradientScalar("hue")
+	checker.Assert(err, ErrorMatches, ".*unknown gradient value.*")
+}
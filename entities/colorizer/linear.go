@@ -0,0 +1,95 @@
+package colorizer
+
+import (
+	"image/color"
+	"math"
+	"sync"
+)
+
+var (
+	srgbToLinearTable     []float64
+	srgbToLinearTableOnce sync.Once
+)
+
+// srgbToLinear converts a 16 bit sRGB channel into linear light, from 0 to 1.
+func srgbToLinear(channel uint16) float64 {
+	srgbToLinearTableOnce.Do(func() {
+		srgbToLinearTable = make([]float64, 0x10000)
+		for index := range srgbToLinearTable {
+			encoded := float64(index) / 0xffff
+			if encoded <= 0.04045 {
+				srgbToLinearTable[index] = encoded / 12.92
+			} else {
+				srgbToLinearTable[index] = math.Pow((encoded+0.055)/1.055, 2.4)
+			}
+		}
+	})
+	return srgbToLinearTable[channel]
+}
+
+// linearToSRGB converts linear light, from 0 to 1, into a 16 bit sRGB channel.
+func linearToSRGB(linear float64) uint16 {
+	if linear <= 0 {
+		return 0
+	}
+	if linear >= 1 {
+		return 0xffff
+	}
+	var encoded float64
+	if linear <= 0.0031308 {
+		encoded = linear * 12.92
+	} else {
+		encoded = 1.055*math.Pow(linear, 1/2.4) - 0.055
+	}
+	return uint16(encoded*0xffff + 0.5)
+}
+
+// LinearColorAverage averages colors in linear light instead of gamma encoded sRGB,
+//   so blending bright and dark colors does not come out too dark.
+//   Like the colors a Colorizer returns, the colors are premultiplied by alpha.
+type LinearColorAverage struct {
+	red   float64
+	green float64
+	blue  float64
+	alpha float64
+	count int
+}
+
+// Add includes sampledColor in the average.
+func (average *LinearColorAverage) Add(sampledColor color.NRGBA64) {
+	average.count++
+	if sampledColor.A == 0 {
+		return
+	}
+	alpha := float64(sampledColor.A) / 0xffff
+	average.red += srgbToLinear(unpremultiply(sampledColor.R, sampledColor.A)) * alpha
+	average.green += srgbToLinear(unpremultiply(sampledColor.G, sampledColor.A)) * alpha
+	average.blue += srgbToLinear(unpremultiply(sampledColor.B, sampledColor.A)) * alpha
+	average.alpha += alpha
+}
+
+// Color returns the average of every added color. With no colors it returns transparent black.
+func (average *LinearColorAverage) Color() color.NRGBA64 {
+	if average.count == 0 || average.alpha == 0 {
+		return color.NRGBA64{}
+	}
+	alpha := average.alpha / float64(average.count)
+	encodedAlpha := uint16(alpha*0xffff + 0.5)
+	return color.NRGBA64{
+		R: premultiply(linearToSRGB(average.red/average.alpha), encodedAlpha),
+		G: premultiply(linearToSRGB(average.green/average.alpha), encodedAlpha),
+		B: premultiply(linearToSRGB(average.blue/average.alpha), encodedAlpha),
+		A: encodedAlpha,
+	}
+}
+
+func unpremultiply(channel, alpha uint16) uint16 {
+	if uint32(channel) >= uint32(alpha) {
+		return 0xffff
+	}
+	return uint16(uint32(channel) * 0xffff / uint32(alpha))
+}
+
+func premultiply(channel, alpha uint16) uint16 {
+	return uint16(uint32(channel) * uint32(alpha) / 0xffff)
+}
@@ -0,0 +1,42 @@
+package colorizer_test
+
+import (
+	. "gopkg.in/check.v1"
+	"image/color"
+	"wallpaper/entities/colorizer"
+)
+
+type LinearColorAverageSuite struct {
+}
+
+var _ = Suite(&LinearColorAverageSuite{})
+
+func (suite *LinearColorAverageSuite) TestAverageOfOneColorIsThatColor(checker *C) {
+	average := colorizer.LinearColorAverage{}
+	average.Add(color.NRGBA64{R: 0x1234, G: 0x8000, B: 0xffff, A: 0xffff})
+	checker.Assert(average.Color(), Equals, color.NRGBA64{R: 0x1234, G: 0x8000, B: 0xffff, A: 0xffff})
+}
+
+func (suite *LinearColorAverageSuite) TestBlackAndWhiteAverageToMiddleGrayInLinearLight(checker *C) {
+	average := colorizer.LinearColorAverage{}
+	average.Add(color.NRGBA64{A: 0xffff})
+	average.Add(color.NRGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff})
+
+	blended := average.Color()
+	checker.Assert(blended.A, Equals, uint16(0xffff))
+	checker.Assert(blended.R>>8, Equals, uint16(0xbc))
+	checker.Assert(blended.G, Equals, blended.R)
+}
+
+func (suite *LinearColorAverageSuite) TestTransparentSamplesOnlyLowerAlpha(checker *C) {
+	average := colorizer.LinearColorAverage{}
+	average.Add(color.NRGBA64{R: 0xffff, A: 0xffff})
+	average.Add(color.NRGBA64{})
+
+	checker.Assert(average.Color(), Equals, color.NRGBA64{R: 0x8000, A: 0x8000})
+}
+
+func (suite *LinearColorAverageSuite) TestAverageOfNothingIsTransparent(checker *C) {
+	average := colorizer.LinearColorAverage{}
+	checker.Assert(average.Color(), Equals, color.NRGBA64{})
+}
@@ -0,0 +1,59 @@
+package colorizer
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// ValueMapping moves a transformed value before SourceImage scales it onto the source.
+//   The real part of the result picks the column and the imaginary part picks the row.
+type ValueMapping interface {
+	Map(transformedValue complex128) complex128
+}
+
+// PolarMapping maps the argument across the source and the modulus down it.
+type PolarMapping struct {
+	// LogModulus uses the natural log of the modulus, so small and large moduli get a similar share of the source.
+	LogModulus bool
+	// Swap maps the modulus across the source and the argument down it.
+	Swap bool
+}
+
+// Map returns the argument and the modulus, or its log, as a complex number.
+func (mapping *PolarMapping) Map(transformedValue complex128) complex128 {
+	radius := cmplx.Abs(transformedValue)
+	if mapping.LogModulus {
+		radius = math.Log(radius)
+	}
+	return orientPolar(cmplx.Phase(transformedValue), radius, mapping.Swap)
+}
+
+// WrappedArgumentMapping maps the argument across the source like PolarMapping,
+//   but wraps it around so the source repeats seamlessly as the argument turns.
+//   Mapped arguments run from 0 to 1.
+type WrappedArgumentMapping struct {
+	// Repeats is how many times the source appears in one full turn. 0 or less is 1.
+	Repeats float64
+	// Offset is the argument, in radians, where the left edge of the source starts.
+	Offset float64
+	// Swap maps the modulus across the source and the argument down it.
+	Swap bool
+}
+
+// Map returns the wrapped argument and the modulus as a complex number.
+func (mapping *WrappedArgumentMapping) Map(transformedValue complex128) complex128 {
+	repeats := mapping.Repeats
+	if repeats <= 0 {
+		repeats = 1
+	}
+	turns := (cmplx.Phase(transformedValue) - mapping.Offset) / (2 * math.Pi) * repeats
+	return orientPolar(turns-math.Floor(turns), cmplx.Abs(transformedValue), mapping.Swap)
+}
+
+// orientPolar puts the argument in the real part unless swap is set.
+func orientPolar(argument, radius float64, swap bool) complex128 {
+	if swap {
+		return complex(radius, argument)
+	}
+	return complex(argument, radius)
+}
@@ -0,0 +1,64 @@
+package colorizer_test
+
+import (
+	. "gopkg.in/check.v1"
+	"image"
+	"image/color"
+	"math"
+	"math/cmplx"
+	"wallpaper/entities/colorizer"
+	"wallpaper/entities/utility"
+)
+
+type MappingSuite struct{}
+
+var _ = Suite(&MappingSuite{})
+
+func (suite *MappingSuite) TestPolarMapsArgumentAcrossAndModulusDown(checker *C) {
+	mapping := &colorizer.PolarMapping{}
+	mapped := mapping.Map(cmplx.Rect(2, math.Pi/2))
+	checker.Assert(real(mapped), utility.NumericallyCloseEnough{}, math.Pi/2, 1e-9)
+	checker.Assert(imag(mapped), utility.NumericallyCloseEnough{}, 2.0, 1e-9)
+
+	mapping.Swap = true
+	mapped = mapping.Map(cmplx.Rect(2, math.Pi/2))
+	checker.Assert(real(mapped), utility.NumericallyCloseEnough{}, 2.0, 1e-9)
+	checker.Assert(imag(mapped), utility.NumericallyCloseEnough{}, math.Pi/2, 1e-9)
+}
+
+func (suite *MappingSuite) TestLogPolarUsesTheLogOfTheModulus(checker *C) {
+	mapping := &colorizer.PolarMapping{LogModulus: true}
+	mapped := mapping.Map(cmplx.Rect(math.E, -1))
+	checker.Assert(real(mapped), utility.NumericallyCloseEnough{}, -1.0, 1e-9)
+	checker.Assert(imag(mapped), utility.NumericallyCloseEnough{}, 1.0, 1e-9)
+}
+
+func (suite *MappingSuite) TestWrappedArgumentRepeatsAroundTheCircle(checker *C) {
+	mapping := &colorizer.WrappedArgumentMapping{Repeats: 4}
+	checker.Assert(real(mapping.Map(cmplx.Rect(1, math.Pi/4))), utility.NumericallyCloseEnough{}, 0.5, 1e-9)
+	checker.Assert(real(mapping.Map(cmplx.Rect(1, math.Pi/4+math.Pi/2))), utility.NumericallyCloseEnough{}, 0.5, 1e-9)
+	checker.Assert(real(mapping.Map(cmplx.Rect(1, -math.Pi/4))), utility.NumericallyCloseEnough{}, 0.5, 1e-9)
+	checker.Assert(imag(mapping.Map(cmplx.Rect(3, 0.1))), utility.NumericallyCloseEnough{}, 3.0, 1e-9)
+}
+
+func (suite *MappingSuite) TestWrappedArgumentOffsetRotatesTheStart(checker *C) {
+	mapping := &colorizer.WrappedArgumentMapping{Offset: math.Pi / 2}
+	checker.Assert(real(mapping.Map(cmplx.Rect(1, math.Pi/2))), utility.NumericallyCloseEnough{}, 0.0, 1e-9)
+	checker.Assert(real(mapping.Map(cmplx.Rect(1, 0))), utility.NumericallyCloseEnough{}, 0.75, 1e-9)
+}
+
+func (suite *MappingSuite) TestSourceImageSamplesTheMappedValue(checker *C) {
+	source := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	source.Set(0, 0, color.NRGBA{R: 0xff, A: 0xff})
+	source.Set(1, 0, color.NRGBA{B: 0xff, A: 0xff})
+	sourceImage := &colorizer.SourceImage{
+		Source:        source,
+		Mapping:       &colorizer.PolarMapping{},
+		ValueSpaceMin: complex(-math.Pi, 0),
+		ValueSpaceMax: complex(math.Pi, 2),
+	}
+
+	checker.Assert(sourceImage.ColorAt(complex(0, -1)), Equals, color.NRGBA64{R: 0xffff, A: 0xffff})
+	checker.Assert(sourceImage.ColorAt(complex(0, 1)), Equals, color.NRGBA64{B: 0xffff, A: 0xffff})
+	checker.Assert(sourceImage.ColorAt(complex(0, 3)), Equals, color.NRGBA64{})
+}
@@ -0,0 +1,73 @@
+package colorizer
+
+import (
+	"image/color"
+	"math"
+)
+
+// oklab is a color in the OKLab perceptual color space.
+//   Equal steps in OKLab look like equal steps in lightness and hue, so gradients blend evenly.
+type oklab struct {
+	lightness float64
+	a         float64
+	b         float64
+}
+
+// linearRGBToOKLab converts linear light red, green and blue, from 0 to 1, into OKLab.
+func linearRGBToOKLab(red, green, blue float64) oklab {
+	long := math.Cbrt(0.4122214708*red + 0.5363325363*green + 0.0514459929*blue)
+	medium := math.Cbrt(0.2119034982*red + 0.6806995451*green + 0.1073969566*blue)
+	short := math.Cbrt(0.0883024619*red + 0.2817188376*green + 0.6299787005*blue)
+	return oklab{
+		lightness: 0.2104542553*long + 0.7936177850*medium - 0.0040720468*short,
+		a:         1.9779984951*long - 2.4285922050*medium + 0.4505937099*short,
+		b:         0.0259040371*long + 0.7827717662*medium - 0.8086757660*short,
+	}
+}
+
+// linearRGB converts the OKLab color back into linear light red, green and blue.
+//   Colors outside of sRGB may be below 0 or above 1.
+func (lab oklab) linearRGB() (float64, float64, float64) {
+	long := lab.lightness + 0.3963377774*lab.a + 0.2158037573*lab.b
+	medium := lab.lightness - 0.1055613458*lab.a - 0.0638541728*lab.b
+	short := lab.lightness - 0.0894841775*lab.a - 1.2914855480*lab.b
+	long, medium, short = long*long*long, medium*medium*medium, short*short*short
+	return 4.0767416621*long - 3.3077115913*medium + 0.2309699292*short,
+		-1.2684380046*long + 2.6097574011*medium - 0.3413193965*short,
+		-0.0041960863*long - 0.7034186147*medium + 1.7076147010*short
+}
+
+// interpolate blends from lab at 0 to other at 1.
+func (lab oklab) interpolate(other oklab, amount float64) oklab {
+	return oklab{
+		lightness: lab.lightness + (other.lightness-lab.lightness)*amount,
+		a:         lab.a + (other.a-lab.a)*amount,
+		b:         lab.b + (other.b-lab.b)*amount,
+	}
+}
+
+// nrgbaToOKLab converts an 8 bit sRGB color into OKLab, ignoring its alpha.
+func nrgbaToOKLab(srgb color.NRGBA) oklab {
+	return linearRGBToOKLab(
+		srgbToLinear(uint16(srgb.R)*0x101),
+		srgbToLinear(uint16(srgb.G)*0x101),
+		srgbToLinear(uint16(srgb.B)*0x101),
+	)
+}
+
+// nrgba converts the OKLab color into an opaque 8 bit sRGB color, clipping anything outside of sRGB.
+func (lab oklab) nrgba() color.NRGBA {
+	red, green, blue := lab.linearRGB()
+	return color.NRGBA{
+		R: uint8(linearToSRGB(red) >> 8),
+		G: uint8(linearToSRGB(green) >> 8),
+		B: uint8(linearToSRGB(blue) >> 8),
+		A: 0xff,
+	}
+}
+
+// squaredDistance returns how far apart the colors look, squared.
+func (lab oklab) squaredDistance(other oklab) float64 {
+	lightness, a, b := lab.lightness-other.lightness, lab.a-other.a, lab.b-other.b
+	return lightness*lightness + a*a + b*b
+}
@@ -0,0 +1,113 @@
+package colorizer
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// OutOfRangeMode decides how SourceImage colors values outside of its value space.
+type OutOfRangeMode string
+
+// Out of range modes.
+const (
+	// OutOfRangeTransparent colors the value transparent black.
+	OutOfRangeTransparent OutOfRangeMode = "transparent"
+	// OutOfRangeBackground colors the value with the background color.
+	OutOfRangeBackground OutOfRangeMode = "background"
+	// OutOfRangeClamp uses the closest color on the edge of the source image.
+	OutOfRangeClamp OutOfRangeMode = "clamp"
+	// OutOfRangeTile repeats the source image in every direction.
+	OutOfRangeTile OutOfRangeMode = "tile"
+	// OutOfRangeMirror repeats the source image, flipping every other copy so the edges line up.
+	OutOfRangeMirror OutOfRangeMode = "mirror"
+	// OutOfRangeFade uses the closest edge color, fading to the background color the farther away the value is.
+	OutOfRangeFade OutOfRangeMode = "fade"
+)
+
+// ParseOutOfRangeMode returns the mode with the given name. An empty name returns OutOfRangeTransparent.
+func ParseOutOfRangeMode(name string) (OutOfRangeMode, error) {
+	mode := OutOfRangeMode(name)
+	switch mode {
+	case "":
+		return OutOfRangeTransparent, nil
+	case OutOfRangeTransparent, OutOfRangeBackground, OutOfRangeClamp, OutOfRangeTile, OutOfRangeMirror, OutOfRangeFade:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown out of range mode %q, expected one of %s, %s, %s, %s, %s or %s", name,
+		OutOfRangeTransparent, OutOfRangeBackground, OutOfRangeClamp, OutOfRangeTile, OutOfRangeMirror, OutOfRangeFade)
+}
+
+// ParseHexColor reads a color written as #rrggbb or #rrggbbaa. Colors without alpha are opaque.
+func ParseHexColor(hex string) (color.NRGBA, error) {
+	digits := strings.TrimPrefix(hex, "#")
+	if len(digits) != 6 && len(digits) != 8 {
+		return color.NRGBA{}, fmt.Errorf("color %q must look like #rrggbb or #rrggbbaa", hex)
+	}
+	if len(digits) == 6 {
+		digits += "ff"
+	}
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("color %q must look like #rrggbb or #rrggbbaa", hex)
+	}
+	return color.NRGBA{
+		R: uint8(value >> 24),
+		G: uint8(value >> 16),
+		B: uint8(value >> 8),
+		A: uint8(value),
+	}, nil
+}
+
+// FormatHexColor writes a color the way ParseHexColor reads it. Opaque colors leave out the alpha.
+func FormatHexColor(hexColor color.NRGBA) string {
+	if hexColor.A == 0xff {
+		return fmt.Sprintf("#%02x%02x%02x", hexColor.R, hexColor.G, hexColor.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", hexColor.R, hexColor.G, hexColor.B, hexColor.A)
+}
+
+// clampFraction keeps a fraction of the value space between 0 and 1.
+func clampFraction(fraction float64) float64 {
+	return math.Max(0, math.Min(1, fraction))
+}
+
+// tileFraction wraps a fraction of the value space around so it repeats between 0 and 1.
+func tileFraction(fraction float64) float64 {
+	return fraction - math.Floor(fraction)
+}
+
+// mirrorFraction bounces a fraction of the value space back and forth between 0 and 1.
+func mirrorFraction(fraction float64) float64 {
+	period := fraction - 2*math.Floor(fraction/2)
+	if period > 1 {
+		return 2 - period
+	}
+	return period
+}
+
+// distanceOutside measures how far a fraction of the value space is beyond 0 or 1.
+func distanceOutside(fraction float64) float64 {
+	if fraction < 0 {
+		return -fraction
+	}
+	if fraction > 1 {
+		return fraction - 1
+	}
+	return 0
+}
+
+// blendColors mixes the premultiplied colors, from all start at 0 to all end at 1.
+func blendColors(start, end color.NRGBA64, amount float64) color.NRGBA64 {
+	blendChannel := func(startChannel, endChannel uint16) uint16 {
+		return uint16(float64(startChannel)*(1-amount) + float64(endChannel)*amount + 0.5)
+	}
+	return color.NRGBA64{
+		R: blendChannel(start.R, end.R),
+		G: blendChannel(start.G, end.G),
+		B: blendChannel(start.B, end.B),
+		A: blendChannel(start.A, end.A),
+	}
+}
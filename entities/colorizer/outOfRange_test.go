@@ -0,0 +1,109 @@
+package colorizer_test
+
+import (
+	. "gopkg.in/check.v1"
+	"image"
+	"image/color"
+	"wallpaper/entities/colorizer"
+)
+
+type OutOfRangeSuite struct {
+	sourceColorizer *colorizer.SourceImage
+	red             color.NRGBA64
+	green           color.NRGBA64
+	background      color.NRGBA64
+}
+
+var _ = Suite(&OutOfRangeSuite{})
+
+func (suite *OutOfRangeSuite) SetUpTest(checker *C) {
+	source := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	source.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	source.Set(1, 0, color.NRGBA{G: 255, A: 255})
+
+	suite.red = color.NRGBA64{R: 0xffff, A: 0xffff}
+	suite.green = color.NRGBA64{G: 0xffff, A: 0xffff}
+	suite.background = color.NRGBA64{B: 0xffff, A: 0xffff}
+	suite.sourceColorizer = &colorizer.SourceImage{
+		Source:        source,
+		ValueSpaceMin: complex(0, 0),
+		ValueSpaceMax: complex(2, 1),
+		Background:    color.NRGBA{B: 255, A: 255},
+	}
+}
+
+func (suite *OutOfRangeSuite) TestParseOutOfRangeMode(checker *C) {
+	mode, err := colorizer.ParseOutOfRangeMode("")
+	checker.Assert(err, IsNil)
+	checker.Assert(mode, Equals, colorizer.OutOfRangeTransparent)
+
+	mode, err = colorizer.ParseOutOfRangeMode("mirror")
+	checker.Assert(err, IsNil)
+	checker.Assert(mode, Equals, colorizer.OutOfRangeMirror)
+
+	_, err = colorizer.ParseOutOfRangeMode("wrap")
+	checker.Assert(err, ErrorMatches, `unknown out of range mode "wrap".*`)
+}
+
+func (suite *OutOfRangeSuite) TestParseHexColor(checker *C) {
+	parsed, err := colorizer.ParseHexColor("#102030")
+	checker.Assert(err, IsNil)
+	checker.Assert(parsed, Equals, color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xff})
+
+	parsed, err = colorizer.ParseHexColor("a0b0c080")
+	checker.Assert(err, IsNil)
+	checker.Assert(parsed, Equals, color.NRGBA{R: 0xa0, G: 0xb0, B: 0xc0, A: 0x80})
+
+	_, err = colorizer.ParseHexColor("#12345")
+	checker.Assert(err, ErrorMatches, `color "#12345" must look like.*`)
+	_, err = colorizer.ParseHexColor("#12345g")
+	checker.Assert(err, NotNil)
+}
+
+func (suite *OutOfRangeSuite) TestTransparentIsTheDefault(checker *C) {
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(-1, 0.5)), Equals, color.NRGBA64{})
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(0.5, 0.5)), Equals, suite.red)
+}
+
+func (suite *OutOfRangeSuite) TestBackground(checker *C) {
+	suite.sourceColorizer.OutOfRange = colorizer.OutOfRangeBackground
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(-1, 0.5)), Equals, suite.background)
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(1.5, 0.5)), Equals, suite.green)
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(2, 1)), Equals, suite.green)
+}
+
+func (suite *OutOfRangeSuite) TestClampUsesTheClosestEdge(checker *C) {
+	suite.sourceColorizer.OutOfRange = colorizer.OutOfRangeClamp
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(-10, 5)), Equals, suite.red)
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(10, -5)), Equals, suite.green)
+}
+
+func (suite *OutOfRangeSuite) TestTileRepeatsTheSource(checker *C) {
+	suite.sourceColorizer.OutOfRange = colorizer.OutOfRangeTile
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(2.5, 0.5)), Equals, suite.red)
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(-0.5, 3.5)), Equals, suite.green)
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(-3.5, -0.5)), Equals, suite.red)
+}
+
+func (suite *OutOfRangeSuite) TestMirrorFlipsEveryOtherCopy(checker *C) {
+	suite.sourceColorizer.OutOfRange = colorizer.OutOfRangeMirror
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(2.5, 0.5)), Equals, suite.green)
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(3.5, 0.5)), Equals, suite.red)
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(-0.5, 0.5)), Equals, suite.red)
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(4.5, 0.5)), Equals, suite.red)
+}
+
+func (suite *OutOfRangeSuite) TestFadeBlendsTowardTheBackground(checker *C) {
+	suite.sourceColorizer.OutOfRange = colorizer.OutOfRangeFade
+	suite.sourceColorizer.FadeDistance = 0.5
+
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(0.5, 0.5)), Equals, suite.red)
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(2.5, 0.5)), Equals, color.NRGBA64{G: 0x8000, B: 0x8000, A: 0xffff})
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(3, 0.5)), Equals, suite.background)
+	checker.Assert(suite.sourceColorizer.ColorAt(complex(30, 0.5)), Equals, suite.background)
+}
+
+func (suite *OutOfRangeSuite) TestFormatHexColor(checker *C) {
+	checker.Assert(colorizer.FormatHexColor(color.NRGBA{R: 0x12, G: 0xab, B: 0x00, A: 0xff}), Equals, "#12ab00")
+	checker.Assert(colorizer.FormatHexColor(color.NRGBA{R: 0xff, A: 0x80}), Equals, "#ff000080")
+}
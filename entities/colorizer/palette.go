@@ -0,0 +1,283 @@
+package colorizer
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+)
+
+// PaletteMethod picks how ExtractPalette chooses its colors.
+type PaletteMethod string
+
+// Palette extraction methods.
+const (
+	// PaletteMethodMedianCut splits the source's colors into boxes of similar colors and averages each box.
+	//   Boxes are cut where the two halves vary the least rather than at the exact median,
+	//   so a small patch of a distinct color gets a box of its own.
+	PaletteMethodMedianCut PaletteMethod = "median_cut"
+	// PaletteMethodKMeans starts with the median cut colors and moves them towards the center of their clusters.
+	PaletteMethodKMeans PaletteMethod = "kmeans"
+)
+
+// ParsePaletteMethod returns the method with the given name. An empty name returns PaletteMethodMedianCut.
+func ParsePaletteMethod(name string) (PaletteMethod, error) {
+	method := PaletteMethod(name)
+	switch method {
+	case "":
+		return PaletteMethodMedianCut, nil
+	case PaletteMethodMedianCut, PaletteMethodKMeans:
+		return method, nil
+	}
+	return "", fmt.Errorf("unknown palette method %q, expected %s or %s", name, PaletteMethodMedianCut, PaletteMethodKMeans)
+}
+
+// maximumPalettePixels is the most source pixels ExtractPalette looks at. Larger images are sampled with a stride.
+const maximumPalettePixels = 1 << 16
+
+// kMeansIterations is how many times PaletteMethodKMeans moves its colors.
+const kMeansIterations = 10
+
+// ExtractPalette picks up to numberOfColors colors that represent source, sorted from darkest to lightest.
+//   Colors are compared in OKLab, so the palette follows what people see rather than raw channel values.
+//   Transparent pixels are ignored. It returns fewer colors if source does not have that many different ones.
+func ExtractPalette(source image.Image, numberOfColors int, method PaletteMethod) ([]color.NRGBA, error) {
+	if numberOfColors < 1 {
+		return nil, fmt.Errorf("palette needs at least 1 color, got %d", numberOfColors)
+	}
+	pixels := paletteSourcePixels(source)
+	if len(pixels) == 0 {
+		return nil, errors.New("cannot extract a palette, the source image has no opaque pixels")
+	}
+
+	centers := medianCut(pixels, numberOfColors)
+	if method == PaletteMethodKMeans {
+		centers = kMeans(pixels, centers, kMeansIterations)
+	}
+
+	sort.SliceStable(centers, func(i, j int) bool {
+		return centers[i].lightness < centers[j].lightness
+	})
+	palette := []color.NRGBA{}
+	for _, center := range centers {
+		palette = append(palette, center.nrgba())
+	}
+	return palette, nil
+}
+
+// paletteSourcePixels converts the source's pixels that are not fully transparent into OKLab.
+func paletteSourcePixels(source image.Image) []oklab {
+	bounds := source.Bounds()
+	stride := 1
+	for (bounds.Dx()/stride)*(bounds.Dy()/stride) > maximumPalettePixels {
+		stride++
+	}
+
+	pixels := []oklab{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stride {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stride {
+			red, green, blue, alpha := source.At(x, y).RGBA()
+			if alpha == 0 {
+				continue
+			}
+			pixels = append(pixels, linearRGBToOKLab(
+				srgbToLinear(unpremultiply(uint16(red), uint16(alpha))),
+				srgbToLinear(unpremultiply(uint16(green), uint16(alpha))),
+				srgbToLinear(unpremultiply(uint16(blue), uint16(alpha))),
+			))
+		}
+	}
+	return pixels
+}
+
+// medianCut cuts the box with the widest channel in two until there are numberOfColors boxes
+//   or every box holds a single color, then returns the average color of each box.
+func medianCut(pixels []oklab, numberOfColors int) []oklab {
+	boxes := [][]oklab{pixels}
+	for len(boxes) < numberOfColors {
+		widestBox, widestChannel, widestRange := -1, 0, 0.0
+		for index, box := range boxes {
+			channel, channelRange := widestOKLabChannel(box)
+			if channelRange > widestRange {
+				widestBox, widestChannel, widestRange = index, channel, channelRange
+			}
+		}
+		if widestBox < 0 {
+			break
+		}
+
+		box := boxes[widestBox]
+		sort.SliceStable(box, func(i, j int) bool {
+			return okLabChannel(box[i], widestChannel) < okLabChannel(box[j], widestChannel)
+		})
+		cut := bestCut(box, widestChannel)
+		boxes[widestBox] = box[:cut]
+		boxes = append(boxes, box[cut:])
+	}
+
+	centers := []oklab{}
+	for _, box := range boxes {
+		centers = append(centers, averageOKLab(box))
+	}
+	return centers
+}
+
+// bestCut returns where to cut box, which is sorted by channel, so the two halves vary the least along it.
+//   It only cuts between different values, so equal colors stay in the same box.
+func bestCut(box []oklab, channel int) int {
+	total, totalSquares := 0.0, 0.0
+	for _, pixel := range box {
+		value := okLabChannel(pixel, channel)
+		total += value
+		totalSquares += value * value
+	}
+
+	bestIndex, bestError := len(box)/2, -1.0
+	before, beforeSquares := 0.0, 0.0
+	for index := 1; index < len(box); index++ {
+		value := okLabChannel(box[index-1], channel)
+		before += value
+		beforeSquares += value * value
+		if okLabChannel(box[index], channel) == value {
+			continue
+		}
+		after, afterSquares := total-before, totalSquares-beforeSquares
+		squaredError := beforeSquares - before*before/float64(index) +
+			afterSquares - after*after/float64(len(box)-index)
+		if bestError < 0 || squaredError < bestError {
+			bestIndex, bestError = index, squaredError
+		}
+	}
+	return bestIndex
+}
+
+// widestOKLabChannel returns the channel whose values spread the furthest across box, and how far.
+func widestOKLabChannel(box []oklab) (int, float64) {
+	if len(box) < 2 {
+		return 0, 0
+	}
+	widestChannel, widestRange := 0, 0.0
+	for channel := 0; channel < 3; channel++ {
+		minimum, maximum := okLabChannel(box[0], channel), okLabChannel(box[0], channel)
+		for _, pixel := range box[1:] {
+			value := okLabChannel(pixel, channel)
+			if value < minimum {
+				minimum = value
+			}
+			if value > maximum {
+				maximum = value
+			}
+		}
+		if maximum-minimum > widestRange {
+			widestChannel, widestRange = channel, maximum-minimum
+		}
+	}
+	return widestChannel, widestRange
+}
+
+func okLabChannel(pixel oklab, channel int) float64 {
+	switch channel {
+	case 1:
+		return pixel.a
+	case 2:
+		return pixel.b
+	}
+	return pixel.lightness
+}
+
+func averageOKLab(pixels []oklab) oklab {
+	sum := oklab{}
+	for _, pixel := range pixels {
+		sum.lightness += pixel.lightness
+		sum.a += pixel.a
+		sum.b += pixel.b
+	}
+	count := float64(len(pixels))
+	return oklab{lightness: sum.lightness / count, a: sum.a / count, b: sum.b / count}
+}
+
+// kMeans assigns every pixel to its nearest center and moves each center to the average of its pixels.
+//   Centers without any pixels stay where they are.
+func kMeans(pixels []oklab, centers []oklab, iterations int) []oklab {
+	for iteration := 0; iteration < iterations; iteration++ {
+		sums := make([]oklab, len(centers))
+		counts := make([]int, len(centers))
+		for _, pixel := range pixels {
+			nearest := nearestOKLab(centers, pixel)
+			sums[nearest].lightness += pixel.lightness
+			sums[nearest].a += pixel.a
+			sums[nearest].b += pixel.b
+			counts[nearest]++
+		}
+
+		moved := false
+		for index := range centers {
+			if counts[index] == 0 {
+				continue
+			}
+			count := float64(counts[index])
+			average := oklab{lightness: sums[index].lightness / count, a: sums[index].a / count, b: sums[index].b / count}
+			if average != centers[index] {
+				centers[index] = average
+				moved = true
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+	return centers
+}
+
+// nearestOKLab returns the index of the palette color that looks closest to target.
+func nearestOKLab(palette []oklab, target oklab) int {
+	nearest, nearestDistance := 0, palette[0].squaredDistance(target)
+	for index, candidate := range palette[1:] {
+		distance := candidate.squaredDistance(target)
+		if distance < nearestDistance {
+			nearest, nearestDistance = index+1, distance
+		}
+	}
+	return nearest
+}
+
+// Quantizer replaces every color from another Colorizer with the nearest color in a palette,
+//   turning smooth shading into flat fields of color. Alpha is kept.
+type Quantizer struct {
+	Colorizer Colorizer
+	palette   []color.NRGBA
+	lab       []oklab
+}
+
+// NewQuantizer returns a Quantizer that snaps colorizer's colors to palette.
+func NewQuantizer(colorizer Colorizer, palette []color.NRGBA) (*Quantizer, error) {
+	if len(palette) == 0 {
+		return nil, errors.New("cannot quantize to an empty palette")
+	}
+	quantizer := &Quantizer{Colorizer: colorizer, palette: palette}
+	for _, paletteColor := range palette {
+		quantizer.lab = append(quantizer.lab, nrgbaToOKLab(paletteColor))
+	}
+	return quantizer, nil
+}
+
+// ColorAt returns the palette color nearest to the wrapped Colorizer's color.
+func (quantizer *Quantizer) ColorAt(transformedValue complex128) color.NRGBA64 {
+	original := quantizer.Colorizer.ColorAt(transformedValue)
+	if original.A == 0 {
+		return original
+	}
+
+	nearest := quantizer.palette[nearestOKLab(quantizer.lab, linearRGBToOKLab(
+		srgbToLinear(unpremultiply(original.R, original.A)),
+		srgbToLinear(unpremultiply(original.G, original.A)),
+		srgbToLinear(unpremultiply(original.B, original.A)),
+	))]
+	return color.NRGBA64{
+		R: premultiply(uint16(nearest.R)*0x101, original.A),
+		G: premultiply(uint16(nearest.G)*0x101, original.A),
+		B: premultiply(uint16(nearest.B)*0x101, original.A),
+		A: original.A,
+	}
+}
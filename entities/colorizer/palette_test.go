@@ -0,0 +1,97 @@
+package colorizer_test
+
+import (
+	. "gopkg.in/check.v1"
+	"image"
+	"image/color"
+	"wallpaper/entities/colorizer"
+)
+
+type PaletteSuite struct {
+	source *image.NRGBA
+}
+
+var _ = Suite(&PaletteSuite{})
+
+// SetUpTest makes a source that is mostly dark red with a bright blue stripe and a transparent corner.
+func (suite *PaletteSuite) SetUpTest(checker *C) {
+	suite.source = image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			switch {
+			case x == 0 && y == 0:
+				suite.source.Set(x, y, color.NRGBA{G: 0xff})
+			case y < 2:
+				suite.source.Set(x, y, color.NRGBA{R: 0x40, G: 0x80, B: 0xff, A: 0xff})
+			default:
+				suite.source.Set(x, y, color.NRGBA{R: 0x80, A: 0xff})
+			}
+		}
+	}
+}
+
+func (suite *PaletteSuite) TestMedianCutFindsTheDistinctColorsDarkestFirst(checker *C) {
+	palette, err := colorizer.ExtractPalette(suite.source, 2, colorizer.PaletteMethodMedianCut)
+	checker.Assert(err, IsNil)
+	checker.Assert(palette, DeepEquals, []color.NRGBA{
+		{R: 0x80, A: 0xff},
+		{R: 0x40, G: 0x80, B: 0xff, A: 0xff},
+	})
+}
+
+func (suite *PaletteSuite) TestKMeansFindsTheDistinctColors(checker *C) {
+	palette, err := colorizer.ExtractPalette(suite.source, 2, colorizer.PaletteMethodKMeans)
+	checker.Assert(err, IsNil)
+	checker.Assert(palette, HasLen, 2)
+	checker.Assert(palette[0], Equals, color.NRGBA{R: 0x80, A: 0xff})
+}
+
+func (suite *PaletteSuite) TestFewerColorsThanAsked(checker *C) {
+	palette, err := colorizer.ExtractPalette(suite.source, 5, colorizer.PaletteMethodMedianCut)
+	checker.Assert(err, IsNil)
+	checker.Assert(palette, HasLen, 2)
+}
+
+func (suite *PaletteSuite) TestInvalidExtractionsAreRejected(checker *C) {
+	_, err := colorizer.ExtractPalette(suite.source, 0, colorizer.PaletteMethodMedianCut)
+	checker.Assert(err, ErrorMatches, ".*at least 1 color.*")
+
+	_, err = colorizer.ExtractPalette(image.NewNRGBA(image.Rect(0, 0, 2, 2)), 4, colorizer.PaletteMethodMedianCut)
+	checker.Assert(err, ErrorMatches, ".*no opaque pixels")
+
+	_, err = colorizer.ParsePaletteMethod("octree")
+	checker.Assert(err, ErrorMatches, ".*unknown palette method.*")
+}
+
+func (suite *PaletteSuite) TestQuantizerSnapsToTheNearestPaletteColor(checker *C) {
+	domainColoring := &colorizer.DomainColoring{}
+	quantizer, err := colorizer.NewQuantizer(domainColoring, []color.NRGBA{
+		{R: 0xff, A: 0xff},
+		{B: 0xff, A: 0xff},
+	})
+	checker.Assert(err, IsNil)
+
+	// Domain coloring turns 1 into pure red and 1i into purple.
+	checker.Assert(quantizer.ColorAt(complex(1, 0)), Equals, color.NRGBA64{R: 0xffff, A: 0xffff})
+	checker.Assert(quantizer.ColorAt(complex(-0.2, -1)), Equals, color.NRGBA64{B: 0xffff, A: 0xffff})
+}
+
+func (suite *PaletteSuite) TestQuantizerKeepsAlpha(checker *C) {
+	halfRed := &colorizer.SourceImage{
+		Source:        &image.NRGBA{Pix: []byte{0xf0, 0, 0, 0x80}, Stride: 4, Rect: image.Rect(0, 0, 1, 1)},
+		ValueSpaceMin: complex(-1, -1),
+		ValueSpaceMax: complex(1, 1),
+	}
+	quantizer, _ := colorizer.NewQuantizer(halfRed, []color.NRGBA{{R: 0xff, A: 0xff}, {G: 0xff, A: 0xff}})
+
+	quantized := quantizer.ColorAt(0)
+	checker.Assert(quantized.A, Equals, uint16(0x8080))
+	checker.Assert(quantized.R, Equals, uint16(0x8080))
+	checker.Assert(quantized.G, Equals, uint16(0))
+	checker.Assert(quantizer.ColorAt(complex(5, 5)), Equals, color.NRGBA64{})
+}
+
+func (suite *PaletteSuite) TestQuantizerNeedsAPalette(checker *C) {
+	_, err := colorizer.NewQuantizer(&colorizer.DomainColoring{}, nil)
+	checker.Assert(err, ErrorMatches, ".*empty palette")
+}
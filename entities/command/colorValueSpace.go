@@ -0,0 +1,248 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"wallpaper/entities/colorizer"
+	"wallpaper/entities/mathutility"
+)
+
+// Default percentiles used by AutoColorValueSpace when none are given.
+const (
+	DefaultAutoLowerPercentile = 1.0
+	DefaultAutoUpperPercentile = 99.0
+)
+
+// ColorValueSpace notes the rectangle of transformed values that maps onto the source image.
+//   If Auto is set, the corners are chosen from the transformed values instead.
+//   Setting Polar, LogPolar or WrappedArgument maps the values around a circle instead of a rectangle,
+//   using that mapping's own bounds.
+type ColorValueSpace struct {
+	ComplexNumberCorners `yaml:",inline"`
+	Auto                 *AutoColorValueSpace    `json:"auto,omitempty" yaml:"auto,omitempty"`
+	Polar                *PolarMapping           `json:"polar,omitempty" yaml:"polar,omitempty"`
+	LogPolar             *PolarMapping           `json:"log_polar,omitempty" yaml:"log_polar,omitempty"`
+	WrappedArgument      *WrappedArgumentMapping `json:"wrapped_argument,omitempty" yaml:"wrapped_argument,omitempty"`
+}
+
+// PolarMapping maps the argument across the source image and the modulus down it.
+type PolarMapping struct {
+	// ArgumentMin and ArgumentMax are in radians. If both are 0, the full turn from -pi to pi is used.
+	ArgumentMin float64 `json:"argument_min" yaml:"argument_min"`
+	ArgumentMax float64 `json:"argument_max" yaml:"argument_max"`
+	// ModulusMin and ModulusMax are plain moduli, even for log polar mappings.
+	ModulusMin float64 `json:"modulus_min" yaml:"modulus_min"`
+	ModulusMax float64 `json:"modulus_max" yaml:"modulus_max"`
+	// Swap maps the modulus across the source image and the argument down it.
+	Swap bool `json:"swap" yaml:"swap"`
+}
+
+// WrappedArgumentMapping maps the argument across the source image, repeating it seamlessly around the circle,
+//   and the modulus down it.
+type WrappedArgumentMapping struct {
+	// Repeats is how many times the source image appears in one full turn. 0 is 1.
+	Repeats float64 `json:"repeats" yaml:"repeats"`
+	// Offset is the argument, in radians, where the source image starts.
+	Offset     float64 `json:"offset" yaml:"offset"`
+	ModulusMin float64 `json:"modulus_min" yaml:"modulus_min"`
+	ModulusMax float64 `json:"modulus_max" yaml:"modulus_max"`
+	// Swap maps the modulus across the source image and the argument down it.
+	Swap bool `json:"swap" yaml:"swap"`
+}
+
+// AutoColorValueSpace chooses the color value space from percentiles of the transformed values,
+//   so a few extreme values do not wash out the rest of the image.
+type AutoColorValueSpace struct {
+	// LowerPercentile and UpperPercentile run from 0 to 100. If both are 0, the defaults are used.
+	LowerPercentile float64 `json:"lower_percentile" yaml:"lower_percentile"`
+	UpperPercentile float64 `json:"upper_percentile" yaml:"upper_percentile"`
+	// Square makes the box as tall as it is wide, growing the shorter side around its center.
+	Square bool `json:"square" yaml:"square"`
+	// Centered moves the box so 0+0i is in the middle, growing it to keep the chosen values inside.
+	Centered bool `json:"centered" yaml:"centered"`
+}
+
+// OutOfRange decides how values outside of the color value space are colored.
+type OutOfRange struct {
+	// Mode is one of transparent (the default), background, clamp, tile, mirror or fade.
+	Mode string `json:"mode" yaml:"mode"`
+	// Background is a color like #rrggbb or #rrggbbaa, used by the background and fade modes.
+	//   Empty is transparent black.
+	Background string `json:"background" yaml:"background"`
+	// FadeDistance is how far past the color value space the fade mode reaches the background,
+	//   as a fraction of the color value space's size. 0 fades over the full size.
+	FadeDistance float64 `json:"fade_distance" yaml:"fade_distance"`
+}
+
+// Validate makes sure at most one mapping is chosen and its bounds make sense.
+func (space *ColorValueSpace) Validate() error {
+	mappings := 0
+	for _, chosen := range []bool{space.Polar != nil, space.LogPolar != nil, space.WrappedArgument != nil} {
+		if chosen {
+			mappings++
+		}
+	}
+	if mappings > 1 {
+		return errors.New("color value space can only use one of polar, log_polar and wrapped_argument")
+	}
+	if mappings == 1 && space.Auto != nil {
+		return errors.New("auto color value space only chooses rectangular bounds, it cannot be used with polar, log_polar or wrapped_argument")
+	}
+
+	switch {
+	case space.Polar != nil:
+		return space.Polar.validate(false)
+	case space.LogPolar != nil:
+		return space.LogPolar.validate(true)
+	case space.WrappedArgument != nil:
+		return validateModulusBounds(space.WrappedArgument.ModulusMin, space.WrappedArgument.ModulusMax, false)
+	}
+	return nil
+}
+
+func (mapping *PolarMapping) validate(logModulus bool) error {
+	argumentMin, argumentMax := mapping.argumentBounds()
+	if argumentMin >= argumentMax {
+		return fmt.Errorf("polar argument_min must be less than argument_max, got %g and %g", argumentMin, argumentMax)
+	}
+	return validateModulusBounds(mapping.ModulusMin, mapping.ModulusMax, logModulus)
+}
+
+func validateModulusBounds(modulusMin, modulusMax float64, logModulus bool) error {
+	if modulusMin < 0 || modulusMin >= modulusMax {
+		return fmt.Errorf("modulus bounds must satisfy 0 <= modulus_min < modulus_max, got %g and %g", modulusMin, modulusMax)
+	}
+	if logModulus && modulusMin == 0 {
+		return errors.New("log polar modulus_min must be more than 0")
+	}
+	return nil
+}
+
+// argumentBounds returns the argument bounds, using the full turn if none were set.
+func (mapping *PolarMapping) argumentBounds() (float64, float64) {
+	if mapping.ArgumentMin == 0 && mapping.ArgumentMax == 0 {
+		return -math.Pi, math.Pi
+	}
+	return mapping.ArgumentMin, mapping.ArgumentMax
+}
+
+// ValueMapping returns the mapping that moves transformed values before they are scaled onto the source image.
+//   Rectangular color value spaces return nil.
+func (space *ColorValueSpace) ValueMapping() colorizer.ValueMapping {
+	switch {
+	case space.Polar != nil:
+		return &colorizer.PolarMapping{Swap: space.Polar.Swap}
+	case space.LogPolar != nil:
+		return &colorizer.PolarMapping{LogModulus: true, Swap: space.LogPolar.Swap}
+	case space.WrappedArgument != nil:
+		return &colorizer.WrappedArgumentMapping{
+			Repeats: space.WrappedArgument.Repeats,
+			Offset:  space.WrappedArgument.Offset,
+			Swap:    space.WrappedArgument.Swap,
+		}
+	}
+	return nil
+}
+
+// Corners returns the corners of the mapped values that cover the source image.
+//   Rectangular color value spaces return their own corners.
+func (space *ColorValueSpace) Corners() (complex128, complex128) {
+	switch {
+	case space.Polar != nil:
+		argumentMin, argumentMax := space.Polar.argumentBounds()
+		return polarCorners(argumentMin, argumentMax, space.Polar.ModulusMin, space.Polar.ModulusMax, space.Polar.Swap)
+	case space.LogPolar != nil:
+		argumentMin, argumentMax := space.LogPolar.argumentBounds()
+		return polarCorners(argumentMin, argumentMax, math.Log(space.LogPolar.ModulusMin), math.Log(space.LogPolar.ModulusMax), space.LogPolar.Swap)
+	case space.WrappedArgument != nil:
+		return polarCorners(0, 1, space.WrappedArgument.ModulusMin, space.WrappedArgument.ModulusMax, space.WrappedArgument.Swap)
+	}
+	return complex(space.MinX, space.MinY), complex(space.MaxX, space.MaxY)
+}
+
+// polarCorners puts the argument bounds in the real part unless swap is set, matching the colorizer's mappings.
+func polarCorners(argumentMin, argumentMax, radiusMin, radiusMax float64, swap bool) (complex128, complex128) {
+	if swap {
+		return complex(radiusMin, argumentMin), complex(radiusMax, argumentMax)
+	}
+	return complex(argumentMin, radiusMin), complex(argumentMax, radiusMax)
+}
+
+// Percentiles returns the lower and upper percentiles, using the defaults if none were set.
+func (auto *AutoColorValueSpace) Percentiles() (float64, float64) {
+	if auto.LowerPercentile == 0 && auto.UpperPercentile == 0 {
+		return DefaultAutoLowerPercentile, DefaultAutoUpperPercentile
+	}
+	return auto.LowerPercentile, auto.UpperPercentile
+}
+
+// Validate makes sure the percentiles are in order and between 0 and 100.
+func (auto *AutoColorValueSpace) Validate() error {
+	lowerPercentile, upperPercentile := auto.Percentiles()
+	if lowerPercentile < 0 || upperPercentile > 100 || lowerPercentile >= upperPercentile {
+		return fmt.Errorf("auto color value space percentiles must satisfy 0 <= lower < upper <= 100, got %g and %g", lowerPercentile, upperPercentile)
+	}
+	return nil
+}
+
+// Bounds picks the corners of the color value space from the transformed values.
+//   Infinite and NaN values are ignored.
+func (auto *AutoColorValueSpace) Bounds(transformedValues []complex128) (ComplexNumberCorners, error) {
+	err := auto.Validate()
+	if err != nil {
+		return ComplexNumberCorners{}, err
+	}
+
+	distribution := mathutility.NewDistribution(transformedValues)
+	if len(distribution.Real) == 0 {
+		return ComplexNumberCorners{}, errors.New("cannot choose a color value space, there are no finite values")
+	}
+
+	lowerPercentile, upperPercentile := auto.Percentiles()
+	lowerBound := distribution.Percentile(lowerPercentile)
+	upperBound := distribution.Percentile(upperPercentile)
+	bounds := ComplexNumberCorners{
+		MinX: real(lowerBound),
+		MinY: imag(lowerBound),
+		MaxX: real(upperBound),
+		MaxY: imag(upperBound),
+	}
+
+	if auto.Centered {
+		halfWidth := math.Max(math.Abs(bounds.MinX), math.Abs(bounds.MaxX))
+		halfHeight := math.Max(math.Abs(bounds.MinY), math.Abs(bounds.MaxY))
+		bounds = ComplexNumberCorners{MinX: -halfWidth, MinY: -halfHeight, MaxX: halfWidth, MaxY: halfHeight}
+	}
+	if auto.Square {
+		bounds = bounds.squared()
+	}
+	return bounds.withArea(), nil
+}
+
+// squared grows the shorter side of the corners so they form a square with the same center.
+func (corners ComplexNumberCorners) squared() ComplexNumberCorners {
+	halfSide := math.Max(corners.MaxX-corners.MinX, corners.MaxY-corners.MinY) / 2
+	centerX := (corners.MinX + corners.MaxX) / 2
+	centerY := (corners.MinY + corners.MaxY) / 2
+	return ComplexNumberCorners{
+		MinX: centerX - halfSide,
+		MinY: centerY - halfSide,
+		MaxX: centerX + halfSide,
+		MaxY: centerY + halfSide,
+	}
+}
+
+// withArea widens any side with no length by 1 in each direction,
+//   so a field of identical values still maps onto the source image.
+func (corners ComplexNumberCorners) withArea() ComplexNumberCorners {
+	if corners.MinX == corners.MaxX {
+		corners.MinX--
+		corners.MaxX++
+	}
+	if corners.MinY == corners.MaxY {
+		corners.MinY--
+		corners.MaxY++
+	}
+	return corners
+}
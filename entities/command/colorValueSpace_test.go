@@ -0,0 +1,170 @@
+package command_test
+
+import (
+	. "gopkg.in/check.v1"
+	"math"
+	"math/cmplx"
+	"wallpaper/entities/colorizer"
+	"wallpaper/entities/command"
+	"wallpaper/entities/utility"
+)
+
+type AutoColorValueSpaceSuite struct {
+	transformedValues []complex128
+}
+
+var _ = Suite(&AutoColorValueSpaceSuite{})
+
+func (suite *AutoColorValueSpaceSuite) SetUpTest(checker *C) {
+	suite.transformedValues = []complex128{}
+	for index := 0; index <= 100; index++ {
+		suite.transformedValues = append(suite.transformedValues, complex(float64(index), float64(index)/10-2))
+	}
+	suite.transformedValues = append(suite.transformedValues, cmplx.Inf(), cmplx.NaN())
+}
+
+func (suite *AutoColorValueSpaceSuite) TestParseAutoFromYAML(checker *C) {
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML([]byte(`color_value_space:
+  auto:
+    lower_percentile: 5
+    upper_percentile: 95
+    square: true
+`))
+	checker.Assert(err, IsNil)
+	checker.Assert(wallpaperCommand.ColorValueSpace.Auto, NotNil)
+	checker.Assert(wallpaperCommand.ColorValueSpace.Auto.LowerPercentile, Equals, 5.0)
+	checker.Assert(wallpaperCommand.ColorValueSpace.Auto.UpperPercentile, Equals, 95.0)
+	checker.Assert(wallpaperCommand.ColorValueSpace.Auto.Square, Equals, true)
+	checker.Assert(wallpaperCommand.ColorValueSpace.Auto.Centered, Equals, false)
+}
+
+func (suite *AutoColorValueSpaceSuite) TestParseAutoFromJSON(checker *C) {
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromJSON([]byte(`{"color_value_space": {"minx": -1, "auto": {"centered": true}}}`))
+	checker.Assert(err, IsNil)
+	checker.Assert(wallpaperCommand.ColorValueSpace.MinX, Equals, -1.0)
+	checker.Assert(wallpaperCommand.ColorValueSpace.Auto.Centered, Equals, true)
+}
+
+func (suite *AutoColorValueSpaceSuite) TestDefaultPercentilesIgnoreInfinityAndNaN(checker *C) {
+	auto := &command.AutoColorValueSpace{}
+	bounds, err := auto.Bounds(suite.transformedValues)
+	checker.Assert(err, IsNil)
+	checker.Assert(bounds.MinX, utility.NumericallyCloseEnough{}, 1.0, 1e-9)
+	checker.Assert(bounds.MaxX, utility.NumericallyCloseEnough{}, 99.0, 1e-9)
+	checker.Assert(bounds.MinY, utility.NumericallyCloseEnough{}, -1.9, 1e-9)
+	checker.Assert(bounds.MaxY, utility.NumericallyCloseEnough{}, 7.9, 1e-9)
+}
+
+func (suite *AutoColorValueSpaceSuite) TestCenteredBoundsSurroundZero(checker *C) {
+	auto := &command.AutoColorValueSpace{LowerPercentile: 0, UpperPercentile: 100, Centered: true}
+	bounds, err := auto.Bounds(suite.transformedValues)
+	checker.Assert(err, IsNil)
+	checker.Assert(bounds, Equals, command.ComplexNumberCorners{MinX: -100, MinY: -8, MaxX: 100, MaxY: 8})
+}
+
+func (suite *AutoColorValueSpaceSuite) TestSquareBoundsGrowTheShorterSide(checker *C) {
+	auto := &command.AutoColorValueSpace{LowerPercentile: 0, UpperPercentile: 100, Square: true}
+	bounds, err := auto.Bounds(suite.transformedValues)
+	checker.Assert(err, IsNil)
+	checker.Assert(bounds.MinX, utility.NumericallyCloseEnough{}, 0.0, 1e-9)
+	checker.Assert(bounds.MaxX, utility.NumericallyCloseEnough{}, 100.0, 1e-9)
+	checker.Assert(bounds.MinY, utility.NumericallyCloseEnough{}, -47.0, 1e-9)
+	checker.Assert(bounds.MaxY, utility.NumericallyCloseEnough{}, 53.0, 1e-9)
+}
+
+func (suite *AutoColorValueSpaceSuite) TestIdenticalValuesStillHaveArea(checker *C) {
+	auto := &command.AutoColorValueSpace{}
+	bounds, err := auto.Bounds([]complex128{complex(2, 3), complex(2, 3)})
+	checker.Assert(err, IsNil)
+	checker.Assert(bounds, Equals, command.ComplexNumberCorners{MinX: 1, MinY: 2, MaxX: 3, MaxY: 4})
+}
+
+func (suite *AutoColorValueSpaceSuite) TestBoundsNeedFiniteValues(checker *C) {
+	auto := &command.AutoColorValueSpace{}
+	_, err := auto.Bounds([]complex128{cmplx.Inf(), cmplx.NaN()})
+	checker.Assert(err, ErrorMatches, ".*no finite values")
+}
+
+func (suite *AutoColorValueSpaceSuite) TestPercentilesMustBeInOrder(checker *C) {
+	auto := &command.AutoColorValueSpace{LowerPercentile: 90, UpperPercentile: 10}
+	checker.Assert(auto.Validate(), ErrorMatches, "auto color value space percentiles must satisfy.*")
+	auto = &command.AutoColorValueSpace{LowerPercentile: 10, UpperPercentile: 101}
+	checker.Assert(auto.Validate(), NotNil)
+}
+
+type ColorValueSpaceMappingSuite struct{}
+
+var _ = Suite(&ColorValueSpaceMappingSuite{})
+
+func (suite *ColorValueSpaceMappingSuite) TestRectangularIsTheDefault(checker *C) {
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML([]byte(`color_value_space:
+  minx: -1
+  miny: -2
+  maxx: 3
+  maxy: 4
+`))
+	checker.Assert(err, IsNil)
+	checker.Assert(wallpaperCommand.ColorValueSpace.Validate(), IsNil)
+	checker.Assert(wallpaperCommand.ColorValueSpace.ValueMapping(), IsNil)
+	minimum, maximum := wallpaperCommand.ColorValueSpace.Corners()
+	checker.Assert(minimum, Equals, complex(-1, -2))
+	checker.Assert(maximum, Equals, complex(3, 4))
+}
+
+func (suite *ColorValueSpaceMappingSuite) TestParsePolarMapping(checker *C) {
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML([]byte(`color_value_space:
+  polar:
+    modulus_min: 0.5
+    modulus_max: 2
+    swap: true
+`))
+	checker.Assert(err, IsNil)
+	checker.Assert(wallpaperCommand.ColorValueSpace.Validate(), IsNil)
+	checker.Assert(wallpaperCommand.ColorValueSpace.ValueMapping(), DeepEquals, &colorizer.PolarMapping{Swap: true})
+	minimum, maximum := wallpaperCommand.ColorValueSpace.Corners()
+	checker.Assert(minimum, Equals, complex(0.5, -math.Pi))
+	checker.Assert(maximum, Equals, complex(2, math.Pi))
+}
+
+func (suite *ColorValueSpaceMappingSuite) TestLogPolarBoundsAreLogged(checker *C) {
+	space := command.ColorValueSpace{
+		LogPolar: &command.PolarMapping{ArgumentMin: 0, ArgumentMax: 1, ModulusMin: 1, ModulusMax: math.E},
+	}
+	checker.Assert(space.Validate(), IsNil)
+	checker.Assert(space.ValueMapping(), DeepEquals, &colorizer.PolarMapping{LogModulus: true})
+	minimum, maximum := space.Corners()
+	checker.Assert(minimum, Equals, complex(0, 0))
+	checker.Assert(real(maximum), Equals, 1.0)
+	checker.Assert(imag(maximum), utility.NumericallyCloseEnough{}, 1.0, 1e-9)
+}
+
+func (suite *ColorValueSpaceMappingSuite) TestWrappedArgumentRunsFromZeroToOne(checker *C) {
+	space := command.ColorValueSpace{
+		WrappedArgument: &command.WrappedArgumentMapping{Repeats: 3, Offset: 1, ModulusMax: 5},
+	}
+	checker.Assert(space.Validate(), IsNil)
+	checker.Assert(space.ValueMapping(), DeepEquals, &colorizer.WrappedArgumentMapping{Repeats: 3, Offset: 1})
+	minimum, maximum := space.Corners()
+	checker.Assert(minimum, Equals, complex(0, 0))
+	checker.Assert(maximum, Equals, complex(1, 5))
+}
+
+func (suite *ColorValueSpaceMappingSuite) TestInvalidMappingsAreRejected(checker *C) {
+	space := command.ColorValueSpace{
+		Polar:    &command.PolarMapping{ModulusMax: 1},
+		LogPolar: &command.PolarMapping{ModulusMax: 1},
+	}
+	checker.Assert(space.Validate(), ErrorMatches, ".*only use one of.*")
+
+	space = command.ColorValueSpace{Polar: &command.PolarMapping{ModulusMax: 1}, Auto: &command.AutoColorValueSpace{}}
+	checker.Assert(space.Validate(), ErrorMatches, ".*only chooses rectangular bounds.*")
+
+	space = command.ColorValueSpace{Polar: &command.PolarMapping{ArgumentMin: 1, ArgumentMax: -1, ModulusMax: 1}}
+	checker.Assert(space.Validate(), ErrorMatches, ".*argument_min must be less than argument_max.*")
+
+	space = command.ColorValueSpace{WrappedArgument: &command.WrappedArgumentMapping{ModulusMin: 2, ModulusMax: 1}}
+	checker.Assert(space.Validate(), ErrorMatches, ".*0 <= modulus_min < modulus_max.*")
+
+	space = command.ColorValueSpace{LogPolar: &command.PolarMapping{ModulusMax: 1}}
+	checker.Assert(space.Validate(), ErrorMatches, ".*must be more than 0")
+}
@@ -1,8 +1,13 @@
 package command
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"gopkg.in/yaml.v2"
+	"wallpaper/entities/colorizer"
+	"wallpaper/entities/field"
+	"wallpaper/entities/formula"
 	"wallpaper/entities/formula/frieze"
 	"wallpaper/entities/formula/rosette"
 	"wallpaper/entities/formula/wavepacket"
@@ -23,13 +28,38 @@ type WidthHeightDimensions struct {
 	Height	int `json:"height" yaml:"height"`
 }
 
+// Supersample takes several samples inside each output pixel and averages their colors.
+type Supersample struct {
+	// Size is the number of sub-samples across and down each pixel, so each pixel takes Size x Size samples.
+	//   0 or 1 takes a single sample.
+	Size	int		`json:"size" yaml:"size"`
+	// Jitter moves each sub-sample to a random spot in its cell instead of the cell's center.
+	Jitter	bool	`json:"jitter" yaml:"jitter"`
+}
+
+// OutputOptions tune how the output image is encoded. Zero values use the output format's defaults.
+type OutputOptions struct {
+	// BitDepth is the number of bits per color channel, 8 or 16.
+	BitDepth	int	`json:"bit_depth" yaml:"bit_depth"`
+	// Quality trades file size for detail in lossy formats, from 1 to 100.
+	Quality		int	`json:"quality" yaml:"quality"`
+}
+
 // CreateWallpaperCommand records the desired command to generate.
 type CreateWallpaperCommand struct {
 	SampleSpace				  ComplexNumberCorners               `json:"sample_space" yaml:"sample_space"`
 	OutputImageSize			  WidthHeightDimensions              `json:"output_size" yaml:"output_size"`
 	SampleSourceFilename	  string                                `json:"sample_source_filename" yaml:"sample_source_filename"`
 	OutputFilename			  string                              `json:"output_filename" yaml:"output_filename"`
-	ColorValueSpace			  ComplexNumberCorners               `json:"color_value_space" yaml:"color_value_space"`
+	OutputFormat			  string                              `json:"output_format" yaml:"output_format"`
+	OutputOptions			  OutputOptions                       `json:"output_options" yaml:"output_options"`
+	ColorValueSpace			  ColorValueSpace          `json:"color_value_space" yaml:"color_value_space"`
+	SamplingFilter			  string                              `json:"sampling_filter" yaml:"sampling_filter"`
+	Supersample				  Supersample                         `json:"supersample" yaml:"supersample"`
+	OutOfRange				  OutOfRange                          `json:"out_of_range" yaml:"out_of_range"`
+	DomainColoring			  *colorizer.DomainColoring           `json:"domain_coloring" yaml:"domain_coloring"`
+	GradientPalette			  *GradientPalette                    `json:"gradient_palette" yaml:"gradient_palette"`
+	QuantizePalette			  *PaletteExtraction                  `json:"quantize_palette" yaml:"quantize_palette"`
 	RosetteFormula			  *rosette.Formula                    `json:"rosette_formula" yaml:"rosette_formula"`
 	FriezeFormula			  *frieze.Formula                      `json:"frieze_formula" yaml:"frieze_formula"`
 	HexagonalWallpaperFormula *wavepacket.HexagonalWallpaperFormula `json:"hexagonal_wallpaper_formula" yaml:"hexagonal_wallpaper_formula"`
@@ -37,6 +67,11 @@ type CreateWallpaperCommand struct {
 	RhombicWallpaperFormula *wavepacket.RhombicWallpaperFormula            `json:"rhombic_wallpaper_formula" yaml:"rhombic_wallpaper_formula"`
 	RectangularWallpaperFormula *wavepacket.RectangularWallpaperFormula            `json:"rectangular_wallpaper_formula" yaml:"rectangular_wallpaper_formula"`
 	GenericWallpaperFormula *wavepacket.GenericWallpaperFormula            `json:"generic_wallpaper_formula" yaml:"generic_wallpaper_formula"`
+	// WallpaperFormula is chosen by its symmetry group, so it can be any of the wallpaper formulas.
+	WallpaperFormula		  formula.Formula                     `json:"wallpaper_formula" yaml:"wallpaper_formula"`
+	FieldFilename			  string                              `json:"field_filename" yaml:"field_filename"`
+	// formulaDescription is the marshaled formula this command was created from.
+	formulaDescription		  []byte
 }
 
 // CreateWallpaperCommandMarshal can be marshaled and converted to a CreateWallpaperCommand
@@ -45,7 +80,15 @@ type CreateWallpaperCommandMarshal struct {
 	OutputImageSize			WidthHeightDimensions                 `json:"output_size" yaml:"output_size"`
 	SampleSourceFilename	string                                   `json:"sample_source_filename" yaml:"sample_source_filename"`
 	OutputFilename			string                                 `json:"output_filename" yaml:"output_filename"`
-	ColorValueSpace			ComplexNumberCorners                  `json:"color_value_space" yaml:"color_value_space"`
+	OutputFormat			string                                 `json:"output_format" yaml:"output_format"`
+	OutputOptions			OutputOptions                          `json:"output_options" yaml:"output_options"`
+	ColorValueSpace			ColorValueSpace             `json:"color_value_space" yaml:"color_value_space"`
+	SamplingFilter			string                                 `json:"sampling_filter" yaml:"sampling_filter"`
+	Supersample				Supersample                            `json:"supersample" yaml:"supersample"`
+	OutOfRange				OutOfRange                             `json:"out_of_range" yaml:"out_of_range"`
+	DomainColoring			*colorizer.DomainColoring              `json:"domain_coloring" yaml:"domain_coloring"`
+	GradientPalette			*GradientPalette                       `json:"gradient_palette" yaml:"gradient_palette"`
+	QuantizePalette			*PaletteExtraction                     `json:"quantize_palette" yaml:"quantize_palette"`
 	RosetteFormula			*rosette.MarshaledFormula              `json:"rosette_formula" yaml:"rosette_formula"`
 	FriezeFormula			*frieze.MarshaledFormula                `json:"frieze_formula" yaml:"frieze_formula"`
 	HexagonalWallpaperFormula *wavepacket.WallpaperFormulaMarshalled `json:"hexagonal_wallpaper_formula" yaml:"hexagonal_wallpaper_formula"`
@@ -53,6 +96,20 @@ type CreateWallpaperCommandMarshal struct {
 	RhombicWallpaperFormula *wavepacket.RhombicWallpaperFormulaMarshalled       `json:"rhombic_wallpaper_formula" yaml:"rhombic_wallpaper_formula"`
 	RectangularWallpaperFormula *wavepacket.RectangularWallpaperFormulaMarshalled            `json:"rectangular_wallpaper_formula" yaml:"rectangular_wallpaper_formula"`
 	GenericWallpaperFormula *wavepacket.GenericWallpaperFormulaMarshalled            `json:"generic_wallpaper_formula" yaml:"generic_wallpaper_formula"`
+	WallpaperFormula		*wavepacket.GroupWallpaperFormulaMarshalled            `json:"wallpaper_formula" yaml:"wallpaper_formula"`
+	FieldFilename			string                                 `json:"field_filename" yaml:"field_filename"`
+}
+
+// formulaMarshal only contains the formulas, so it can describe the formula without any other settings.
+type formulaMarshal struct {
+	RosetteFormula			*rosette.MarshaledFormula              `yaml:"rosette_formula"`
+	FriezeFormula			*frieze.MarshaledFormula                `yaml:"frieze_formula"`
+	HexagonalWallpaperFormula *wavepacket.WallpaperFormulaMarshalled `yaml:"hexagonal_wallpaper_formula"`
+	SquareWallpaperFormula *wavepacket.WallpaperFormulaMarshalled    `yaml:"square_wallpaper_formula"`
+	RhombicWallpaperFormula *wavepacket.RhombicWallpaperFormulaMarshalled       `yaml:"rhombic_wallpaper_formula"`
+	RectangularWallpaperFormula *wavepacket.RectangularWallpaperFormulaMarshalled            `yaml:"rectangular_wallpaper_formula"`
+	GenericWallpaperFormula *wavepacket.GenericWallpaperFormulaMarshalled            `yaml:"generic_wallpaper_formula"`
+	WallpaperFormula		*wavepacket.GroupWallpaperFormulaMarshalled            `yaml:"wallpaper_formula"`
 }
 
 // NewCreateWallpaperCommandFromYAML reads the data and returns a CreateWallpaperCommand from it.
@@ -80,8 +137,32 @@ func newCreateWallpaperCommandFromDatastream(data []byte, unmarshal utility.Unma
 		OutputImageSize:      commandToCreateMarshal.OutputImageSize,
 		SampleSourceFilename: commandToCreateMarshal.SampleSourceFilename,
 		OutputFilename:       commandToCreateMarshal.OutputFilename,
+		OutputFormat:         commandToCreateMarshal.OutputFormat,
+		OutputOptions:        commandToCreateMarshal.OutputOptions,
 		ColorValueSpace:      commandToCreateMarshal.ColorValueSpace,
+		SamplingFilter:       commandToCreateMarshal.SamplingFilter,
+		Supersample:          commandToCreateMarshal.Supersample,
+		OutOfRange:           commandToCreateMarshal.OutOfRange,
+		DomainColoring:       commandToCreateMarshal.DomainColoring,
+		GradientPalette:      commandToCreateMarshal.GradientPalette,
+		QuantizePalette:      commandToCreateMarshal.QuantizePalette,
+		FieldFilename:        commandToCreateMarshal.FieldFilename,
+	}
+
+	formulaDescription, err := yaml.Marshal(formulaMarshal{
+		RosetteFormula:              commandToCreateMarshal.RosetteFormula,
+		FriezeFormula:               commandToCreateMarshal.FriezeFormula,
+		HexagonalWallpaperFormula:   commandToCreateMarshal.HexagonalWallpaperFormula,
+		SquareWallpaperFormula:      commandToCreateMarshal.SquareWallpaperFormula,
+		RhombicWallpaperFormula:     commandToCreateMarshal.RhombicWallpaperFormula,
+		RectangularWallpaperFormula: commandToCreateMarshal.RectangularWallpaperFormula,
+		GenericWallpaperFormula:     commandToCreateMarshal.GenericWallpaperFormula,
+		WallpaperFormula:            commandToCreateMarshal.WallpaperFormula,
+	})
+	if err != nil {
+		return nil, err
 	}
+	commandToCreate.formulaDescription = formulaDescription
 
 	if commandToCreateMarshal.RosetteFormula != nil {
 		commandToCreate.RosetteFormula  = rosette.NewRosetteFormulaFromMarshalObject(*commandToCreateMarshal.RosetteFormula)
@@ -92,24 +173,113 @@ func newCreateWallpaperCommandFromDatastream(data []byte, unmarshal utility.Unma
 	}
 
 	if commandToCreateMarshal.HexagonalWallpaperFormula != nil {
-		commandToCreate.HexagonalWallpaperFormula = wavepacket.NewHexagonalWallpaperFormulaFromMarshalObject(*commandToCreateMarshal.HexagonalWallpaperFormula)
+		commandToCreate.HexagonalWallpaperFormula, err = wavepacket.NewHexagonalWallpaperFormulaFromMarshalObject(*commandToCreateMarshal.HexagonalWallpaperFormula)
+		if err != nil {
+			return nil, fmt.Errorf("hexagonal_wallpaper_formula: %v", err)
+		}
 	}
 
 	if commandToCreateMarshal.SquareWallpaperFormula != nil {
-		commandToCreate.SquareWallpaperFormula = wavepacket.NewSquareWallpaperFormulaFromMarshalObject(*commandToCreateMarshal.SquareWallpaperFormula)
+		commandToCreate.SquareWallpaperFormula, err = wavepacket.NewSquareWallpaperFormulaFromMarshalObject(*commandToCreateMarshal.SquareWallpaperFormula)
+		if err != nil {
+			return nil, fmt.Errorf("square_wallpaper_formula: %v", err)
+		}
 	}
 
 	if commandToCreateMarshal.RhombicWallpaperFormula != nil {
-		commandToCreate.RhombicWallpaperFormula = wavepacket.NewRhombicWallpaperFormulaFromMarshalObject(*commandToCreateMarshal.RhombicWallpaperFormula)
+		commandToCreate.RhombicWallpaperFormula, err = wavepacket.NewRhombicWallpaperFormulaFromMarshalObject(*commandToCreateMarshal.RhombicWallpaperFormula)
+		if err != nil {
+			return nil, fmt.Errorf("rhombic_wallpaper_formula: %v", err)
+		}
 	}
 
 	if commandToCreateMarshal.RectangularWallpaperFormula != nil {
-		commandToCreate.RectangularWallpaperFormula = wavepacket.NewRectangularWallpaperFormulaFromMarshalObject(*commandToCreateMarshal.RectangularWallpaperFormula)
+		commandToCreate.RectangularWallpaperFormula, err = wavepacket.NewRectangularWallpaperFormulaFromMarshalObject(*commandToCreateMarshal.RectangularWallpaperFormula)
+		if err != nil {
+			return nil, fmt.Errorf("rectangular_wallpaper_formula: %v", err)
+		}
 	}
 
 	if commandToCreateMarshal.GenericWallpaperFormula != nil {
-		commandToCreate.GenericWallpaperFormula = wavepacket.NewGenericWallpaperFormulaFromMarshalObject(*commandToCreateMarshal.GenericWallpaperFormula)
+		commandToCreate.GenericWallpaperFormula, err = wavepacket.NewGenericWallpaperFormulaFromMarshalObject(*commandToCreateMarshal.GenericWallpaperFormula)
+		if err != nil {
+			return nil, fmt.Errorf("generic_wallpaper_formula: %v", err)
+		}
+	}
+
+	if commandToCreateMarshal.WallpaperFormula != nil {
+		commandToCreate.WallpaperFormula, err = wavepacket.NewWallpaperFormulaForGroupFromMarshalObject(*commandToCreateMarshal.WallpaperFormula)
+		if err != nil {
+			return nil, fmt.Errorf("wallpaper_formula: %v", err)
+		}
 	}
 
 	return commandToCreate, nil
-}
\ No newline at end of file
+}
+
+// ActiveFormula returns the formula this command renders, or nil if no formula was given.
+func (command *CreateWallpaperCommand) ActiveFormula() formula.Formula {
+	if command.FriezeFormula != nil {
+		return command.FriezeFormula
+	}
+	if command.RosetteFormula != nil {
+		return command.RosetteFormula
+	}
+	if command.WallpaperFormula != nil {
+		return command.WallpaperFormula
+	}
+	if command.HexagonalWallpaperFormula != nil {
+		return command.HexagonalWallpaperFormula
+	}
+	if command.SquareWallpaperFormula != nil {
+		return command.SquareWallpaperFormula
+	}
+	if command.RhombicWallpaperFormula != nil {
+		return command.RhombicWallpaperFormula
+	}
+	if command.RectangularWallpaperFormula != nil {
+		return command.RectangularWallpaperFormula
+	}
+	if command.GenericWallpaperFormula != nil {
+		return command.GenericWallpaperFormula
+	}
+	return nil
+}
+
+// UsesSourceImage returns true if the command colors values by sampling its source image.
+//   Commands with domain coloring or a gradient palette do not sample a source image or need a color value space.
+func (command *CreateWallpaperCommand) UsesSourceImage() bool {
+	return command.DomainColoring == nil && command.GradientPalette == nil
+}
+
+// ExtractsPalette returns true if the command picks a palette from its source image,
+//   to quantize with or to use as a gradient.
+func (command *CreateWallpaperCommand) ExtractsPalette() bool {
+	return command.QuantizePalette != nil || (command.GradientPalette != nil && command.GradientPalette.Extract != nil)
+}
+
+// ValueTerm returns the term whose contribution is colored instead of the formula's total,
+//   or nil to color the total.
+func (command *CreateWallpaperCommand) ValueTerm() *int {
+	if command.DomainColoring != nil || command.GradientPalette == nil {
+		return nil
+	}
+	return command.GradientPalette.Term
+}
+
+// FieldHash identifies the formula, sample space, output size, supersampling and the term being colored.
+//   Two commands with the same hash calculate the same field, no matter how they color it.
+func (command *CreateWallpaperCommand) FieldHash() field.Hash {
+	fieldSettings, _ := yaml.Marshal(struct {
+		SampleSpace     ComplexNumberCorners  `yaml:"sample_space"`
+		OutputImageSize WidthHeightDimensions `yaml:"output_size"`
+		Supersample     Supersample           `yaml:"supersample"`
+		ValueTerm       *int                  `yaml:"value_term,omitempty"`
+	}{
+		SampleSpace:     command.SampleSpace,
+		OutputImageSize: command.OutputImageSize,
+		Supersample:     command.Supersample,
+		ValueTerm:       command.ValueTerm(),
+	})
+	return sha256.Sum256(append(fieldSettings, command.formulaDescription...))
+}
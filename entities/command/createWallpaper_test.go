@@ -1,9 +1,14 @@
 package command_test
 
 import (
+	"fmt"
 	. "gopkg.in/check.v1"
 	"testing"
+	"wallpaper/entities/colorizer"
 	"wallpaper/entities/command"
+	"wallpaper/entities/field"
+	"wallpaper/entities/formula"
+	"wallpaper/entities/formula/wavepacket"
 )
 
 func Test(t *testing.T) { TestingT(t) }
@@ -146,3 +151,200 @@ func (suite *CreateWallpaperCommandSuite) TestCreateFromJSON(checker *C) {
 
 	checker.Assert(wallpaperCommand.FriezeFormula.Terms, HasLen, 2)
 }
+
+func (suite *CreateWallpaperCommandSuite) TestActiveFormulaReturnsTheGivenFormula(checker *C) {
+	yamlByteStream := []byte(`sample_source_filename: input.png
+output_filename: output.png
+square_wallpaper_formula:
+  multiplier:
+    real: 1
+    imaginary: 0
+  wave_packets:
+    -
+      multiplier:
+        real: 1
+        imaginary: 0
+      terms:
+        -
+          power_n: 1
+          power_m: -2
+`)
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML(yamlByteStream)
+	checker.Assert(err, IsNil)
+
+	activeFormula := wallpaperCommand.ActiveFormula()
+	checker.Assert(activeFormula, NotNil)
+	checker.Assert(activeFormula, Equals, formula.Formula(wallpaperCommand.SquareWallpaperFormula))
+}
+
+func (suite *CreateWallpaperCommandSuite) TestWallpaperFormulaPicksTheFormulaForItsGroup(checker *C) {
+	yamlByteStream := []byte(`sample_source_filename: input.png
+output_filename: output.png
+wallpaper_formula:
+  group: "4*2"
+  multiplier:
+    real: 1
+    imaginary: 0
+  terms:
+    -
+      power_n: 1
+      power_m: -2
+`)
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML(yamlByteStream)
+	checker.Assert(err, IsNil)
+
+	squareFormula, ok := wallpaperCommand.ActiveFormula().(*wavepacket.SquareWallpaperFormula)
+	checker.Assert(ok, Equals, true)
+	checker.Assert(squareFormula.HasSymmetry(wavepacket.P4g), Equals, true)
+
+	normalizedYAML, err := wallpaperCommand.NormalizedYAML()
+	checker.Assert(err, IsNil)
+	recreatedCommand, err := command.NewCreateWallpaperCommandFromYAML(normalizedYAML)
+	checker.Assert(err, IsNil)
+	checker.Assert(recreatedCommand.FieldHash(), Equals, wallpaperCommand.FieldHash())
+}
+
+func (suite *CreateWallpaperCommandSuite) TestWallpaperFormulaWithTheWrongLatticeIsAnError(checker *C) {
+	yamlByteStream := []byte(`wallpaper_formula:
+  group: p4
+  lattice_height: 0.5
+  multiplier:
+    real: 1
+    imaginary: 0
+  terms:
+    -
+      power_n: 1
+      power_m: -2
+`)
+	_, err := command.NewCreateWallpaperCommandFromYAML(yamlByteStream)
+	checker.Assert(err, ErrorMatches, "wallpaper_formula: p4 needs a square lattice, .*")
+}
+
+func (suite *CreateWallpaperCommandSuite) TestActiveFormulaIsNilWithoutAFormula(checker *C) {
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML([]byte(`output_filename: output.png`))
+	checker.Assert(err, IsNil)
+	checker.Assert(wallpaperCommand.ActiveFormula(), IsNil)
+}
+
+func (suite *CreateWallpaperCommandSuite) TestFieldHashIgnoresColorSettings(checker *C) {
+	yamlTemplate := `sample_source_filename: %s
+output_filename: output.png
+output_size:
+  width: 80
+  height: 60
+sample_space:
+  minx: -1
+  miny: -1
+  maxx: 1
+  maxy: %s
+color_value_space:
+  minx: %s
+  miny: -2
+  maxx: 2
+  maxy: 2
+rosette_formula:
+  terms:
+    -
+      multiplier:
+        real: 1
+        imaginary: 0
+      power_n: %s
+      power_m: 0
+`
+	fieldHash := func(source, sampleMaxY, colorMinX, powerN string) field.Hash {
+		wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML(
+			[]byte(fmt.Sprintf(yamlTemplate, source, sampleMaxY, colorMinX, powerN)),
+		)
+		checker.Assert(err, IsNil)
+		return wallpaperCommand.FieldHash()
+	}
+
+	original := fieldHash("input.png", "1", "-2", "3")
+	checker.Assert(fieldHash("other.png", "1", "-5", "3"), Equals, original)
+	checker.Assert(fieldHash("input.png", "2", "-2", "3"), Not(Equals), original)
+	checker.Assert(fieldHash("input.png", "1", "-2", "4"), Not(Equals), original)
+}
+
+func (suite *CreateWallpaperCommandSuite) TestSupersampleChangesTheFieldHash(checker *C) {
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML([]byte(`supersample:
+  size: 3
+  jitter: true
+`))
+	checker.Assert(err, IsNil)
+	checker.Assert(wallpaperCommand.Supersample, Equals, command.Supersample{Size: 3, Jitter: true})
+
+	supersampledHash := wallpaperCommand.FieldHash()
+	wallpaperCommand.Supersample.Jitter = false
+	checker.Assert(wallpaperCommand.FieldHash(), Not(Equals), supersampledHash)
+}
+
+func (suite *CreateWallpaperCommandSuite) TestOutOfRangeFromYAML(checker *C) {
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML([]byte(`out_of_range:
+  mode: fade
+  background: "#203040"
+  fade_distance: 0.25
+`))
+	checker.Assert(err, IsNil)
+	checker.Assert(wallpaperCommand.OutOfRange, Equals, command.OutOfRange{Mode: "fade", Background: "#203040", FadeDistance: 0.25})
+}
+
+func (suite *CreateWallpaperCommandSuite) TestOutputFormatFromYAML(checker *C) {
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML([]byte(`output_filename: output.jpg
+output_format: jpeg
+output_options:
+  quality: 75
+`))
+	checker.Assert(err, IsNil)
+	checker.Assert(wallpaperCommand.OutputFormat, Equals, "jpeg")
+	checker.Assert(wallpaperCommand.OutputOptions, Equals, command.OutputOptions{Quality: 75})
+
+	encodedHash := wallpaperCommand.FieldHash()
+	wallpaperCommand.OutputOptions.BitDepth = 16
+	checker.Assert(wallpaperCommand.FieldHash(), Equals, encodedHash)
+}
+
+func (suite *CreateWallpaperCommandSuite) TestDomainColoringDoesNotNeedASourceImage(checker *C) {
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML([]byte(`domain_coloring:
+  modulus_scale: 2
+  modulus_contours: true
+  phase_bands: 12
+`))
+	checker.Assert(err, IsNil)
+	checker.Assert(wallpaperCommand.UsesSourceImage(), Equals, false)
+	checker.Assert(*wallpaperCommand.DomainColoring, Equals, colorizer.DomainColoring{
+		ModulusScale:    2,
+		ModulusContours: true,
+		PhaseBands:      12,
+	})
+
+	wallpaperCommand, err = command.NewCreateWallpaperCommandFromYAML([]byte(`sample_source_filename: input.png`))
+	checker.Assert(err, IsNil)
+	checker.Assert(wallpaperCommand.UsesSourceImage(), Equals, true)
+}
+
+func (suite *CreateWallpaperCommandSuite) TestDesiredSymmetryWithTheWrongLatticeIsAnError(checker *C) {
+	yamlByteStream := []byte(`square_wallpaper_formula:
+  desired_symmetry: p4
+  lattice:
+    x_lattice_vector:
+      real: 1
+      imaginary: 0
+    y_lattice_vector:
+      real: 0
+      imaginary: 0.5
+  multiplier:
+    real: 1
+    imaginary: 0
+  wave_packets:
+    -
+      multiplier:
+        real: 1
+        imaginary: 0
+      terms:
+        -
+          power_n: 1
+          power_m: -2
+`)
+	_, err := command.NewCreateWallpaperCommandFromYAML(yamlByteStream)
+	checker.Assert(err, ErrorMatches, "square_wallpaper_formula: square formulas need square lattice vectors, .*")
+}
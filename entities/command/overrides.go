@@ -0,0 +1,34 @@
+package command
+
+// Overrides replaces some of the CreateWallpaperCommand settings, usually from the command line.
+//   nil fields leave the original setting alone.
+type Overrides struct {
+	OutputFilename *string
+	OutputWidth    *int
+	OutputHeight   *int
+	SampleSpace    *ComplexNumberCorners
+	FieldFilename  *string
+	Supersample    *int
+}
+
+// ApplyOverrides replaces the command's settings with every override that was set.
+func (command *CreateWallpaperCommand) ApplyOverrides(overrides Overrides) {
+	if overrides.OutputFilename != nil {
+		command.OutputFilename = *overrides.OutputFilename
+	}
+	if overrides.OutputWidth != nil {
+		command.OutputImageSize.Width = *overrides.OutputWidth
+	}
+	if overrides.OutputHeight != nil {
+		command.OutputImageSize.Height = *overrides.OutputHeight
+	}
+	if overrides.SampleSpace != nil {
+		command.SampleSpace = *overrides.SampleSpace
+	}
+	if overrides.FieldFilename != nil {
+		command.FieldFilename = *overrides.FieldFilename
+	}
+	if overrides.Supersample != nil {
+		command.Supersample.Size = *overrides.Supersample
+	}
+}
@@ -0,0 +1,81 @@
+package command_test
+
+import (
+	. "gopkg.in/check.v1"
+	"wallpaper/entities/command"
+)
+
+type OverridesSuite struct {
+	wallpaperCommand *command.CreateWallpaperCommand
+}
+
+var _ = Suite(&OverridesSuite{})
+
+func (suite *OverridesSuite) SetUpTest(checker *C) {
+	suite.wallpaperCommand = &command.CreateWallpaperCommand{
+		SampleSpace: command.ComplexNumberCorners{
+			MinX: -1,
+			MinY: -1,
+			MaxX: 1,
+			MaxY: 1,
+		},
+		OutputImageSize: command.WidthHeightDimensions{
+			Width:  800,
+			Height: 600,
+		},
+		OutputFilename: "output.png",
+	}
+}
+
+func (suite *OverridesSuite) TestNoOverridesLeavesCommandAlone(checker *C) {
+	suite.wallpaperCommand.ApplyOverrides(command.Overrides{})
+
+	checker.Assert(suite.wallpaperCommand.OutputFilename, Equals, "output.png")
+	checker.Assert(suite.wallpaperCommand.OutputImageSize.Width, Equals, 800)
+	checker.Assert(suite.wallpaperCommand.OutputImageSize.Height, Equals, 600)
+	checker.Assert(suite.wallpaperCommand.SampleSpace.MinX, Equals, -1.0)
+	checker.Assert(suite.wallpaperCommand.SampleSpace.MaxY, Equals, 1.0)
+}
+
+func (suite *OverridesSuite) TestOverridesReplaceSettings(checker *C) {
+	newFilename := "poster.png"
+	newWidth := 3840
+	newHeight := 2160
+	suite.wallpaperCommand.ApplyOverrides(command.Overrides{
+		OutputFilename: &newFilename,
+		OutputWidth:    &newWidth,
+		OutputHeight:   &newHeight,
+		SampleSpace: &command.ComplexNumberCorners{
+			MinX: -2,
+			MinY: -3,
+			MaxX: 4,
+			MaxY: 5,
+		},
+	})
+
+	checker.Assert(suite.wallpaperCommand.OutputFilename, Equals, "poster.png")
+	checker.Assert(suite.wallpaperCommand.OutputImageSize.Width, Equals, 3840)
+	checker.Assert(suite.wallpaperCommand.OutputImageSize.Height, Equals, 2160)
+	checker.Assert(suite.wallpaperCommand.SampleSpace.MinX, Equals, -2.0)
+	checker.Assert(suite.wallpaperCommand.SampleSpace.MinY, Equals, -3.0)
+	checker.Assert(suite.wallpaperCommand.SampleSpace.MaxX, Equals, 4.0)
+	checker.Assert(suite.wallpaperCommand.SampleSpace.MaxY, Equals, 5.0)
+}
+
+func (suite *OverridesSuite) TestOverrideOnlyOneDimension(checker *C) {
+	newHeight := 200
+	suite.wallpaperCommand.ApplyOverrides(command.Overrides{
+		OutputHeight: &newHeight,
+	})
+
+	checker.Assert(suite.wallpaperCommand.OutputImageSize.Width, Equals, 800)
+	checker.Assert(suite.wallpaperCommand.OutputImageSize.Height, Equals, 200)
+}
+
+func (suite *OverridesSuite) TestSupersampleOverrideKeepsJitter(checker *C) {
+	suite.wallpaperCommand.Supersample.Jitter = true
+	supersample := 4
+	suite.wallpaperCommand.ApplyOverrides(command.Overrides{Supersample: &supersample})
+
+	checker.Assert(suite.wallpaperCommand.Supersample, Equals, command.Supersample{Size: 4, Jitter: true})
+}
@@ -0,0 +1,158 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"image"
+	"image/color"
+	"math"
+	"wallpaper/entities/colorizer"
+)
+
+// GradientStopMarshal places a color, written as #rrggbb or #rrggbbaa, along a gradient palette.
+type GradientStopMarshal struct {
+	Position float64 `json:"position" yaml:"position"`
+	Color    string  `json:"color" yaml:"color"`
+}
+
+// GradientPalette colors values by blending between color stops instead of sampling a source image.
+type GradientPalette struct {
+	// Name picks a palette from the library file instead of listing Stops.
+	Name            string                `json:"name" yaml:"name"`
+	LibraryFilename string                `json:"library_filename" yaml:"library_filename"`
+	Stops           []GradientStopMarshal `json:"stops" yaml:"stops"`
+	// Extract takes the stops from the source image's palette, darkest first, spaced evenly.
+	Extract *PaletteExtraction `json:"extract" yaml:"extract"`
+	// Value is the number that drives the palette: modulus (the default), argument, real or imaginary.
+	Value string `json:"value" yaml:"value"`
+	// Term takes the Value from this term's contribution instead of the formula's total.
+	Term *int `json:"term" yaml:"term"`
+	// ValueMin and ValueMax are scaled onto stop positions 0 to 1.
+	//   If both are 0, argument uses -pi to pi and the others use 0 to 1.
+	ValueMin float64 `json:"value_min" yaml:"value_min"`
+	ValueMax float64 `json:"value_max" yaml:"value_max"`
+}
+
+// PaletteExtraction picks a palette of representative colors from the command's source image.
+type PaletteExtraction struct {
+	Colors int `json:"colors" yaml:"colors"`
+	// Method is median_cut (the default) or kmeans.
+	Method string `json:"method" yaml:"method"`
+}
+
+// Extract returns the palette's colors from source, darkest first.
+func (extraction *PaletteExtraction) Extract(source image.Image) ([]color.NRGBA, error) {
+	method, err := colorizer.ParsePaletteMethod(extraction.Method)
+	if err != nil {
+		return nil, err
+	}
+	return colorizer.ExtractPalette(source, extraction.Colors, method)
+}
+
+// PaletteLibrary holds gradient palettes by name, so commands can share them.
+type PaletteLibrary map[string][]GradientStopMarshal
+
+// NewPaletteLibraryFromYAML reads a library that maps each palette name to its list of stops.
+func NewPaletteLibraryFromYAML(data []byte) (PaletteLibrary, error) {
+	library := PaletteLibrary{}
+	err := yaml.Unmarshal(data, &library)
+	if err != nil {
+		return nil, err
+	}
+	return library, nil
+}
+
+// Gradient turns the palette into a colorizer. library is only used by palettes with a Name,
+//   and extracted holds the colors from Extract for palettes that use it.
+func (palette *GradientPalette) Gradient(library PaletteLibrary, extracted []color.NRGBA) (*colorizer.Gradient, error) {
+	if palette.Term != nil && *palette.Term < 0 {
+		return nil, fmt.Errorf("gradient palette term cannot be negative, got %d", *palette.Term)
+	}
+	scalar, err := colorizer.ParseGradientScalar(palette.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	stops, err := palette.stops(library, extracted)
+	if err != nil {
+		return nil, err
+	}
+	gradientStops := []colorizer.GradientStop{}
+	for _, stop := range stops {
+		stopColor, err := colorizer.ParseHexColor(stop.Color)
+		if err != nil {
+			return nil, err
+		}
+		gradientStops = append(gradientStops, colorizer.GradientStop{Position: stop.Position, Color: stopColor})
+	}
+
+	valueMin, valueMax := palette.ValueMin, palette.ValueMax
+	if valueMin == 0 && valueMax == 0 {
+		valueMax = 1
+		if scalar == colorizer.GradientScalarArgument {
+			valueMin, valueMax = -math.Pi, math.Pi
+		}
+	}
+	return colorizer.NewGradient(gradientStops, scalar, valueMin, valueMax)
+}
+
+// stops returns the palette's own stops, spreads out the extracted colors, or looks up its Name in the library.
+func (palette *GradientPalette) stops(library PaletteLibrary, extracted []color.NRGBA) ([]GradientStopMarshal, error) {
+	sources := 0
+	for _, used := range []bool{palette.Name != "", len(palette.Stops) > 0, palette.Extract != nil} {
+		if used {
+			sources++
+		}
+	}
+	if sources == 0 {
+		return nil, errors.New("gradient palette needs a name, a list of stops or extract")
+	}
+	if sources > 1 {
+		return nil, errors.New("gradient palette can only use one of name, stops and extract")
+	}
+
+	if len(palette.Stops) > 0 {
+		return palette.Stops, nil
+	}
+	if palette.Extract != nil {
+		return evenlySpacedStops(extracted), nil
+	}
+	if library == nil {
+		return nil, fmt.Errorf("gradient palette %q needs a library_filename to look it up in", palette.Name)
+	}
+	stops, ok := library[palette.Name]
+	if !ok {
+		return nil, fmt.Errorf("gradient palette %q is not in the library", palette.Name)
+	}
+	return stops, nil
+}
+
+// evenlySpacedStops spreads the colors from position 0 to 1 in order.
+func evenlySpacedStops(colors []color.NRGBA) []GradientStopMarshal {
+	stops := []GradientStopMarshal{}
+	for index, stopColor := range colors {
+		position := 0.0
+		if len(colors) > 1 {
+			position = float64(index) / float64(len(colors)-1)
+		}
+		stops = append(stops, GradientStopMarshal{Position: position, Color: colorizer.FormatHexColor(stopColor)})
+	}
+	return stops
+}
+
+// InlineLibraryStops copies a named palette's stops out of the library, so the palette no longer needs the library file.
+//   Palettes without a Name are left alone.
+func (palette *GradientPalette) InlineLibraryStops(library PaletteLibrary) error {
+	if palette.Name == "" {
+		return nil
+	}
+	stops, err := palette.stops(library, nil)
+	if err != nil {
+		return err
+	}
+	palette.Stops = append([]GradientStopMarshal{}, stops...)
+	palette.Name = ""
+	palette.LibraryFilename = ""
+	return nil
+}
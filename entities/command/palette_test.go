@@ -0,0 +1,173 @@
+package command_test
+
+import (
+	. "gopkg.in/check.v1"
+	"image"
+	"image/color"
+	"math"
+	"wallpaper/entities/colorizer"
+	"wallpaper/entities/command"
+)
+
+type GradientPaletteSuite struct {
+	library command.PaletteLibrary
+}
+
+var _ = Suite(&GradientPaletteSuite{})
+
+func (suite *GradientPaletteSuite) SetUpTest(checker *C) {
+	var err error
+	suite.library, err = command.NewPaletteLibraryFromYAML([]byte(`sunset:
+  - position: 0
+    color: "#000000"
+  - position: 1
+    color: "#ff0000"
+`))
+	checker.Assert(err, IsNil)
+}
+
+func (suite *GradientPaletteSuite) TestParseGradientPaletteFromYAML(checker *C) {
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML([]byte(`gradient_palette:
+  value: real
+  term: 1
+  value_min: -2
+  value_max: 2
+  stops:
+    - position: 0
+      color: "#000000"
+    - position: 1
+      color: "#ffffff80"
+`))
+	checker.Assert(err, IsNil)
+	checker.Assert(wallpaperCommand.UsesSourceImage(), Equals, false)
+	checker.Assert(*wallpaperCommand.ValueTerm(), Equals, 1)
+
+	palette := wallpaperCommand.GradientPalette
+	checker.Assert(palette.Value, Equals, "real")
+	checker.Assert(palette.ValueMin, Equals, -2.0)
+	checker.Assert(palette.ValueMax, Equals, 2.0)
+	checker.Assert(palette.Stops, HasLen, 2)
+	checker.Assert(palette.Stops[1], Equals, command.GradientStopMarshal{Position: 1, Color: "#ffffff80"})
+}
+
+func (suite *GradientPaletteSuite) TestValueTermOnlyAppliesToGradientPalettes(checker *C) {
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML([]byte(`sample_source_filename: input.png`))
+	checker.Assert(err, IsNil)
+	checker.Assert(wallpaperCommand.ValueTerm(), IsNil)
+}
+
+func (suite *GradientPaletteSuite) TestValueTermChangesTheFieldHash(checker *C) {
+	withoutTerm, _ := command.NewCreateWallpaperCommandFromYAML([]byte(`gradient_palette:
+  name: sunset
+`))
+	withTerm, _ := command.NewCreateWallpaperCommandFromYAML([]byte(`gradient_palette:
+  name: sunset
+  term: 0
+`))
+	checker.Assert(withoutTerm.FieldHash(), Not(Equals), withTerm.FieldHash())
+}
+
+func (suite *GradientPaletteSuite) TestGradientUsesItsOwnStops(checker *C) {
+	palette := &command.GradientPalette{
+		Stops: []command.GradientStopMarshal{
+			{Position: 0, Color: "#000000"},
+			{Position: 1, Color: "#00ff00"},
+		},
+	}
+	gradient, err := palette.Gradient(nil, nil)
+	checker.Assert(err, IsNil)
+	checker.Assert(gradient.Scalar, Equals, colorizer.GradientScalarModulus)
+	checker.Assert(gradient.ScalarMin, Equals, 0.0)
+	checker.Assert(gradient.ScalarMax, Equals, 1.0)
+	checker.Assert(gradient.ColorAt(1), Equals, color.NRGBA64{G: 0xffff, A: 0xffff})
+}
+
+func (suite *GradientPaletteSuite) TestArgumentDefaultsToAFullTurn(checker *C) {
+	palette := &command.GradientPalette{Name: "sunset", Value: "argument"}
+	gradient, err := palette.Gradient(suite.library, nil)
+	checker.Assert(err, IsNil)
+	checker.Assert(gradient.ScalarMin, Equals, -math.Pi)
+	checker.Assert(gradient.ScalarMax, Equals, math.Pi)
+}
+
+func (suite *GradientPaletteSuite) TestGradientLooksUpItsNameInTheLibrary(checker *C) {
+	palette := &command.GradientPalette{Name: "sunset"}
+	gradient, err := palette.Gradient(suite.library, nil)
+	checker.Assert(err, IsNil)
+	checker.Assert(gradient.ColorAt(1), Equals, color.NRGBA64{R: 0xffff, A: 0xffff})
+
+	palette.Name = "dawn"
+	_, err = palette.Gradient(suite.library, nil)
+	checker.Assert(err, ErrorMatches, `gradient palette "dawn" is not in the library`)
+
+	_, err = palette.Gradient(nil, nil)
+	checker.Assert(err, ErrorMatches, `.*needs a library_filename.*`)
+}
+
+func (suite *GradientPaletteSuite) TestInvalidPalettesAreRejected(checker *C) {
+	_, err := (&command.GradientPalette{}).Gradient(nil, nil)
+	checker.Assert(err, ErrorMatches, ".*needs a name, a list of stops or extract")
+
+	bothStopsAndName := &command.GradientPalette{
+		Name:  "sunset",
+		Stops: []command.GradientStopMarshal{{Position: 0, Color: "#000000"}},
+	}
+	_, err = bothStopsAndName.Gradient(suite.library, nil)
+	checker.Assert(err, ErrorMatches, ".*can only use one of.*")
+
+	badColor := &command.GradientPalette{Stops: []command.GradientStopMarshal{{Position: 0, Color: "red"}}}
+	_, err = badColor.Gradient(nil, nil)
+	checker.Assert(err, NotNil)
+
+	negativeTerm := -1
+	negative := &command.GradientPalette{Name: "sunset", Term: &negativeTerm}
+	_, err = negative.Gradient(suite.library, nil)
+	checker.Assert(err, ErrorMatches, ".*cannot be negative.*")
+}
+
+func (suite *GradientPaletteSuite) TestParseQuantizePaletteFromYAML(checker *C) {
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML([]byte(`sample_source_filename: input.png
+quantize_palette:
+  colors: 6
+  method: kmeans
+`))
+	checker.Assert(err, IsNil)
+	checker.Assert(*wallpaperCommand.QuantizePalette, Equals, command.PaletteExtraction{Colors: 6, Method: "kmeans"})
+	checker.Assert(wallpaperCommand.UsesSourceImage(), Equals, true)
+	checker.Assert(wallpaperCommand.ExtractsPalette(), Equals, true)
+}
+
+func (suite *GradientPaletteSuite) TestGradientCanExtractItsStops(checker *C) {
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML([]byte(`gradient_palette:
+  extract:
+    colors: 3
+`))
+	checker.Assert(err, IsNil)
+	checker.Assert(wallpaperCommand.UsesSourceImage(), Equals, false)
+	checker.Assert(wallpaperCommand.ExtractsPalette(), Equals, true)
+
+	source := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	source.Set(0, 0, color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+	source.Set(1, 0, color.NRGBA{A: 0xff})
+	source.Set(2, 0, color.NRGBA{R: 0xff, A: 0xff})
+	extracted, err := wallpaperCommand.GradientPalette.Extract.Extract(source)
+	checker.Assert(err, IsNil)
+
+	gradient, err := wallpaperCommand.GradientPalette.Gradient(nil, extracted)
+	checker.Assert(err, IsNil)
+	checker.Assert(gradient.ColorAt(0), Equals, color.NRGBA64{A: 0xffff})
+	checker.Assert(gradient.ColorAt(0.5), Equals, color.NRGBA64{R: 0xffff, A: 0xffff})
+	checker.Assert(gradient.ColorAt(1), Equals, color.NRGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff})
+}
+
+func (suite *GradientPaletteSuite) TestInlineLibraryStops(checker *C) {
+	palette := &command.GradientPalette{Name: "sunset", LibraryFilename: "palettes.yml"}
+	err := palette.InlineLibraryStops(suite.library)
+	checker.Assert(err, IsNil)
+	checker.Assert(palette.Name, Equals, "")
+	checker.Assert(palette.LibraryFilename, Equals, "")
+	checker.Assert(palette.Stops, DeepEquals, suite.library["sunset"])
+
+	palette = &command.GradientPalette{Name: "dawn"}
+	checker.Assert(palette.InlineLibraryStops(suite.library), ErrorMatches, `.*not in the library`)
+}
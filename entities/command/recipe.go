@@ -0,0 +1,121 @@
+package command
+
+import (
+	"errors"
+	"gopkg.in/yaml.v2"
+	"wallpaper/entities/pngstream"
+)
+
+// Keywords of the PNG text chunks that hold a Recipe.
+const (
+	RecipeKeywordCommand         = "wallpaper:command"
+	RecipeKeywordSourceHash      = "wallpaper:source-sha256"
+	RecipeKeywordVersion         = "wallpaper:version"
+	RecipeKeywordColorValueSpace = "wallpaper:color-value-space"
+)
+
+// Recipe records everything needed to render an image again. It is stored in the image's metadata.
+type Recipe struct {
+	// Command is the full command as normalized YAML.
+	Command []byte
+	// SourceHash is the hex SHA-256 of the source image file. Empty if the command did not read one.
+	SourceHash string
+	// Version is the version of the program that rendered the image.
+	Version string
+	// ColorValueSpace holds the corners that were actually used, which may have been chosen automatically.
+	//   nil if the command did not sample a source image.
+	ColorValueSpace *ComplexNumberCorners
+}
+
+// NormalizedYAML writes the command, including any overrides and chosen bounds, as YAML.
+//   Reading the YAML back creates the same command.
+func (command *CreateWallpaperCommand) NormalizedYAML() ([]byte, error) {
+	var formulas formulaMarshal
+	err := yaml.Unmarshal(command.formulaDescription, &formulas)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(CreateWallpaperCommandMarshal{
+		SampleSpace:                 command.SampleSpace,
+		OutputImageSize:             command.OutputImageSize,
+		SampleSourceFilename:        command.SampleSourceFilename,
+		OutputFilename:              command.OutputFilename,
+		OutputFormat:                command.OutputFormat,
+		OutputOptions:               command.OutputOptions,
+		ColorValueSpace:             command.ColorValueSpace,
+		SamplingFilter:              command.SamplingFilter,
+		Supersample:                 command.Supersample,
+		OutOfRange:                  command.OutOfRange,
+		DomainColoring:              command.DomainColoring,
+		GradientPalette:             command.GradientPalette,
+		QuantizePalette:             command.QuantizePalette,
+		RosetteFormula:              formulas.RosetteFormula,
+		FriezeFormula:               formulas.FriezeFormula,
+		HexagonalWallpaperFormula:   formulas.HexagonalWallpaperFormula,
+		SquareWallpaperFormula:      formulas.SquareWallpaperFormula,
+		RhombicWallpaperFormula:     formulas.RhombicWallpaperFormula,
+		RectangularWallpaperFormula: formulas.RectangularWallpaperFormula,
+		GenericWallpaperFormula:     formulas.GenericWallpaperFormula,
+		WallpaperFormula:            formulas.WallpaperFormula,
+		FieldFilename:               command.FieldFilename,
+	})
+}
+
+// TextChunks stores the recipe as PNG text chunks.
+func (recipe *Recipe) TextChunks() ([]pngstream.TextChunk, error) {
+	chunks := []pngstream.TextChunk{
+		{Keyword: RecipeKeywordCommand, Text: string(recipe.Command)},
+		{Keyword: RecipeKeywordVersion, Text: recipe.Version},
+	}
+	if recipe.SourceHash != "" {
+		chunks = append(chunks, pngstream.TextChunk{Keyword: RecipeKeywordSourceHash, Text: recipe.SourceHash})
+	}
+	if recipe.ColorValueSpace != nil {
+		bounds, err := yaml.Marshal(recipe.ColorValueSpace)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, pngstream.TextChunk{Keyword: RecipeKeywordColorValueSpace, Text: string(bounds)})
+	}
+	return chunks, nil
+}
+
+// NewRecipeFromTextChunks reads a recipe back out of an image's text chunks. Unrelated chunks are ignored.
+func NewRecipeFromTextChunks(chunks []pngstream.TextChunk) (*Recipe, error) {
+	recipe := &Recipe{}
+	for _, chunk := range chunks {
+		switch chunk.Keyword {
+		case RecipeKeywordCommand:
+			recipe.Command = []byte(chunk.Text)
+		case RecipeKeywordSourceHash:
+			recipe.SourceHash = chunk.Text
+		case RecipeKeywordVersion:
+			recipe.Version = chunk.Text
+		case RecipeKeywordColorValueSpace:
+			recipe.ColorValueSpace = &ComplexNumberCorners{}
+			err := yaml.Unmarshal([]byte(chunk.Text), recipe.ColorValueSpace)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if recipe.Command == nil {
+		return nil, errors.New("image has no wallpaper command in its metadata")
+	}
+	return recipe, nil
+}
+
+// WallpaperCommand recreates the command from the recipe.
+//   The color value space is fixed to the recorded corners, so it is not chosen again.
+func (recipe *Recipe) WallpaperCommand() (*CreateWallpaperCommand, error) {
+	wallpaperCommand, err := NewCreateWallpaperCommandFromYAML(recipe.Command)
+	if err != nil {
+		return nil, err
+	}
+	if recipe.ColorValueSpace != nil {
+		wallpaperCommand.ColorValueSpace.ComplexNumberCorners = *recipe.ColorValueSpace
+		wallpaperCommand.ColorValueSpace.Auto = nil
+	}
+	return wallpaperCommand, nil
+}
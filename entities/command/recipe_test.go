@@ -0,0 +1,87 @@
+package command_test
+
+import (
+	. "gopkg.in/check.v1"
+	"wallpaper/entities/command"
+	"wallpaper/entities/pngstream"
+)
+
+type RecipeSuite struct {
+	wallpaperCommand *command.CreateWallpaperCommand
+}
+
+var _ = Suite(&RecipeSuite{})
+
+func (suite *RecipeSuite) SetUpTest(checker *C) {
+	var err error
+	suite.wallpaperCommand, err = command.NewCreateWallpaperCommandFromYAML([]byte(`sample_space:
+  minx: -2
+  miny: -1
+  maxx: 2
+  maxy: 1
+output_size:
+  width: 40
+  height: 20
+sample_source_filename: input.png
+color_value_space:
+  auto:
+    square: true
+rosette_formula:
+  terms:
+    -
+      multiplier:
+        real: 1
+        imaginary: 0
+      power_n: 3
+      power_m: 0
+`))
+	checker.Assert(err, IsNil)
+}
+
+func (suite *RecipeSuite) TestNormalizedYAMLRecreatesTheCommand(checker *C) {
+	suite.wallpaperCommand.OutputImageSize.Width = 80
+
+	normalizedYAML, err := suite.wallpaperCommand.NormalizedYAML()
+	checker.Assert(err, IsNil)
+	recreated, err := command.NewCreateWallpaperCommandFromYAML(normalizedYAML)
+	checker.Assert(err, IsNil)
+	checker.Assert(recreated.OutputImageSize.Width, Equals, 80)
+	checker.Assert(recreated.SampleSourceFilename, Equals, "input.png")
+	checker.Assert(recreated.ColorValueSpace.Auto.Square, Equals, true)
+	checker.Assert(recreated.RosetteFormula, NotNil)
+	checker.Assert(recreated.FieldHash(), Equals, suite.wallpaperCommand.FieldHash())
+}
+
+func (suite *RecipeSuite) TestRecipeSurvivesTextChunks(checker *C) {
+	normalizedYAML, _ := suite.wallpaperCommand.NormalizedYAML()
+	recipe := &command.Recipe{
+		Command:         normalizedYAML,
+		SourceHash:      "abc123",
+		Version:         "1.2.3",
+		ColorValueSpace: &command.ComplexNumberCorners{MinX: -3, MinY: -3, MaxX: 3, MaxY: 3},
+	}
+	chunks, err := recipe.TextChunks()
+	checker.Assert(err, IsNil)
+
+	unrelated := pngstream.TextChunk{Keyword: "Comment", Text: "hello"}
+	readBack, err := command.NewRecipeFromTextChunks(append(chunks, unrelated))
+	checker.Assert(err, IsNil)
+	checker.Assert(readBack, DeepEquals, recipe)
+}
+
+func (suite *RecipeSuite) TestWallpaperCommandUsesTheRecordedBounds(checker *C) {
+	normalizedYAML, _ := suite.wallpaperCommand.NormalizedYAML()
+	recipe := &command.Recipe{
+		Command:         normalizedYAML,
+		ColorValueSpace: &command.ComplexNumberCorners{MinX: -3, MinY: -2, MaxX: 3, MaxY: 2},
+	}
+	reproduced, err := recipe.WallpaperCommand()
+	checker.Assert(err, IsNil)
+	checker.Assert(reproduced.ColorValueSpace.Auto, IsNil)
+	checker.Assert(reproduced.ColorValueSpace.ComplexNumberCorners, Equals, command.ComplexNumberCorners{MinX: -3, MinY: -2, MaxX: 3, MaxY: 2})
+}
+
+func (suite *RecipeSuite) TestRecipeNeedsACommand(checker *C) {
+	_, err := command.NewRecipeFromTextChunks([]pngstream.TextChunk{{Keyword: command.RecipeKeywordVersion, Text: "1.2.3"}})
+	checker.Assert(err, ErrorMatches, ".*no wallpaper command.*")
+}
@@ -0,0 +1,147 @@
+package encoder
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"path/filepath"
+	"strings"
+	"wallpaper/entities/pngstream"
+)
+
+// Options tune how an image is encoded. Zero values use the format's defaults.
+type Options struct {
+	// BitDepth is the number of bits per color channel.
+	BitDepth int
+	// Quality trades file size for detail, from 1 to 100. Only lossy formats use it.
+	Quality int
+}
+
+// EncodeFunc writes outputImage to output. metadata is stored if the format can hold text, otherwise it is dropped.
+type EncodeFunc func(output io.Writer, outputImage image.Image, options Options, metadata []pngstream.TextChunk) error
+
+// Format writes images in one file format.
+type Format struct {
+	// Name is used to pick the format in a config file.
+	Name string
+	// Extensions are the filename extensions that pick this format, including the leading dot.
+	Extensions []string
+	// BitDepths lists the bits per channel the format can write. The first is the default.
+	BitDepths []int
+	// DefaultQuality is used when no quality is given. 0 means the format has no quality setting.
+	DefaultQuality int
+	// Encode writes an image in this format. options has already been resolved.
+	Encode EncodeFunc
+}
+
+// registeredFormats holds every format that can be picked, in the order they were registered.
+var registeredFormats []*Format
+
+// Register makes a format available to ByName and ForFilename.
+//   A format with the same name as an earlier one replaces it.
+func Register(format *Format) {
+	for index, registered := range registeredFormats {
+		if registered.Name == format.Name {
+			registeredFormats[index] = format
+			return
+		}
+	}
+	registeredFormats = append(registeredFormats, format)
+}
+
+// Names returns the names of every registered format.
+func Names() []string {
+	names := []string{}
+	for _, format := range registeredFormats {
+		names = append(names, format.Name)
+	}
+	return names
+}
+
+// ByName returns the registered format with the given name, ignoring case.
+func ByName(name string) (*Format, error) {
+	for _, format := range registeredFormats {
+		if strings.EqualFold(format.Name, name) {
+			return format, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown output format %q, expected one of %s", name, strings.Join(Names(), ", "))
+}
+
+// ForFilename picks the format from the filename's extension. Filenames without an extension are written as PNG.
+func ForFilename(filename string) (*Format, error) {
+	extension := filepath.Ext(filename)
+	if extension == "" {
+		return ByName("png")
+	}
+	for _, format := range registeredFormats {
+		for _, formatExtension := range format.Extensions {
+			if strings.EqualFold(formatExtension, extension) {
+				return format, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("cannot tell the output format from the extension %q, set output_format to one of %s", extension, strings.Join(Names(), ", "))
+}
+
+// ResolveOptions fills in the format's defaults and makes sure it can use the options.
+func (format *Format) ResolveOptions(options Options) (Options, error) {
+	if options.BitDepth == 0 {
+		options.BitDepth = format.BitDepths[0]
+	}
+	if !format.supportsBitDepth(options.BitDepth) {
+		return Options{}, fmt.Errorf("%s cannot write %d bits per channel, expected one of %v", format.Name, options.BitDepth, format.BitDepths)
+	}
+
+	if format.DefaultQuality == 0 {
+		if options.Quality != 0 {
+			return Options{}, fmt.Errorf("%s does not have a quality setting", format.Name)
+		}
+		return options, nil
+	}
+	if options.Quality == 0 {
+		options.Quality = format.DefaultQuality
+	}
+	if options.Quality < 1 || options.Quality > 100 {
+		return Options{}, fmt.Errorf("%s quality must be between 1 and 100, got %d", format.Name, options.Quality)
+	}
+	return options, nil
+}
+
+func (format *Format) supportsBitDepth(bitDepth int) bool {
+	for _, supported := range format.BitDepths {
+		if supported == bitDepth {
+			return true
+		}
+	}
+	return false
+}
+
+// nrgbaImage returns outputImage with 8 or 16 bits per channel and no premultiplied alpha,
+//   converting it only if it is not one already.
+func nrgbaImage(outputImage image.Image, bitDepth int) image.Image {
+	bounds := outputImage.Bounds()
+	if bitDepth == 16 {
+		if sixteenBit, ok := outputImage.(*image.NRGBA64); ok {
+			return sixteenBit
+		}
+		converted := image.NewNRGBA64(bounds)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				converted.Set(x, y, outputImage.At(x, y))
+			}
+		}
+		return converted
+	}
+
+	if eightBit, ok := outputImage.(*image.NRGBA); ok {
+		return eightBit
+	}
+	converted := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			converted.Set(x, y, outputImage.At(x, y))
+		}
+	}
+	return converted
+}
@@ -0,0 +1,197 @@
+package encoder_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	. "gopkg.in/check.v1"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"wallpaper/entities/encoder"
+	"wallpaper/entities/pngstream"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type RegistrySuite struct{}
+
+var _ = Suite(&RegistrySuite{})
+
+func (suite *RegistrySuite) TestFormatIsChosenByExtension(checker *C) {
+	for filename, expectedName := range map[string]string{
+		"out.png":           "png",
+		"out.JPG":           "jpeg",
+		"out.jpeg":          "jpeg",
+		"dir.v2/out.tif":    "tiff",
+		"out.tiff":          "tiff",
+		"no_extension_here": "png",
+	} {
+		format, err := encoder.ForFilename(filename)
+		checker.Assert(err, IsNil)
+		checker.Assert(format.Name, Equals, expectedName, Commentf(filename))
+	}
+}
+
+func (suite *RegistrySuite) TestUnknownFormatsListTheKnownOnes(checker *C) {
+	_, err := encoder.ForFilename("out.bmp")
+	checker.Assert(err, ErrorMatches, `cannot tell the output format from the extension ".bmp", set output_format to one of png, jpeg, tiff`)
+
+	_, err = encoder.ByName("gif")
+	checker.Assert(err, ErrorMatches, `unknown output format "gif", expected one of png, jpeg, tiff`)
+
+	format, err := encoder.ByName("JPEG")
+	checker.Assert(err, IsNil)
+	checker.Assert(format.Name, Equals, "jpeg")
+}
+
+func (suite *RegistrySuite) TestResolveOptionsFillsInDefaults(checker *C) {
+	jpegFormat, _ := encoder.ByName("jpeg")
+	options, err := jpegFormat.ResolveOptions(encoder.Options{})
+	checker.Assert(err, IsNil)
+	checker.Assert(options, Equals, encoder.Options{BitDepth: 8, Quality: encoder.DefaultJPEGQuality})
+
+	pngFormat, _ := encoder.ByName("png")
+	options, err = pngFormat.ResolveOptions(encoder.Options{BitDepth: 16})
+	checker.Assert(err, IsNil)
+	checker.Assert(options, Equals, encoder.Options{BitDepth: 16})
+}
+
+func (suite *RegistrySuite) TestResolveOptionsRejectsWhatTheFormatCannotDo(checker *C) {
+	jpegFormat, _ := encoder.ByName("jpeg")
+	_, err := jpegFormat.ResolveOptions(encoder.Options{BitDepth: 16})
+	checker.Assert(err, ErrorMatches, `jpeg cannot write 16 bits per channel, expected one of \[8\]`)
+	_, err = jpegFormat.ResolveOptions(encoder.Options{Quality: 101})
+	checker.Assert(err, ErrorMatches, "jpeg quality must be between 1 and 100, got 101")
+
+	pngFormat, _ := encoder.ByName("png")
+	_, err = pngFormat.ResolveOptions(encoder.Options{Quality: 50})
+	checker.Assert(err, ErrorMatches, "png does not have a quality setting")
+}
+
+type FormatSuite struct {
+	eightBit   *image.NRGBA
+	sixteenBit *image.NRGBA64
+}
+
+var _ = Suite(&FormatSuite{})
+
+func (suite *FormatSuite) SetUpTest(checker *C) {
+	suite.eightBit = image.NewNRGBA(image.Rect(0, 0, 7, 5))
+	suite.sixteenBit = image.NewNRGBA64(image.Rect(0, 0, 7, 5))
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 7; x++ {
+			suite.eightBit.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 30), G: uint8(y * 50), B: 200, A: uint8(255 - x)})
+			suite.sixteenBit.SetNRGBA64(x, y, color.NRGBA64{R: uint16(x * 7001), G: uint16(y*9001 + 1), B: 40000, A: uint16(65535 - x)})
+		}
+	}
+}
+
+func (suite *FormatSuite) encode(checker *C, name string, outputImage image.Image, options encoder.Options, metadata []pngstream.TextChunk) []byte {
+	format, err := encoder.ByName(name)
+	checker.Assert(err, IsNil)
+	options, err = format.ResolveOptions(options)
+	checker.Assert(err, IsNil)
+	var output bytes.Buffer
+	checker.Assert(format.Encode(&output, outputImage, options, metadata), IsNil)
+	return output.Bytes()
+}
+
+func (suite *FormatSuite) TestSixteenBitPNGKeepsEverySampleAndTheMetadata(checker *C) {
+	metadata := []pngstream.TextChunk{{Keyword: "wallpaper:version", Text: "test"}}
+	encoded := suite.encode(checker, "png", suite.sixteenBit, encoder.Options{BitDepth: 16}, metadata)
+
+	decoded, err := png.Decode(bytes.NewReader(encoded))
+	checker.Assert(err, IsNil)
+	checker.Assert(decoded.(*image.NRGBA64).Pix, DeepEquals, suite.sixteenBit.Pix)
+
+	text, err := pngstream.ReadText(bytes.NewReader(encoded))
+	checker.Assert(err, IsNil)
+	checker.Assert(text, DeepEquals, metadata)
+}
+
+func (suite *FormatSuite) TestEightBitPNGConvertsOtherImages(checker *C) {
+	encoded := suite.encode(checker, "png", suite.sixteenBit, encoder.Options{BitDepth: 8}, nil)
+
+	// The bit depth follows the signature, the IHDR chunk's length and type, and the width and height.
+	checker.Assert(encoded[24], Equals, byte(8))
+	decoded, err := png.Decode(bytes.NewReader(encoded))
+	checker.Assert(err, IsNil)
+	checker.Assert(decoded.Bounds(), Equals, suite.sixteenBit.Bounds())
+}
+
+func (suite *FormatSuite) TestJPEGDecodes(checker *C) {
+	opaque := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for index := range opaque.Pix {
+		opaque.Pix[index] = 128
+		if index%4 == 3 {
+			opaque.Pix[index] = 255
+		}
+	}
+	encoded := suite.encode(checker, "jpeg", opaque, encoder.Options{Quality: 95}, nil)
+
+	decoded, err := jpeg.Decode(bytes.NewReader(encoded))
+	checker.Assert(err, IsNil)
+	checker.Assert(decoded.Bounds(), Equals, opaque.Bounds())
+	red, _, _, _ := decoded.At(8, 8).RGBA()
+	checker.Assert(red>>8 >= 126 && red>>8 <= 130, Equals, true)
+}
+
+// readTIFF reads the tags and pixel data of a single strip, big-endian TIFF.
+func readTIFF(checker *C, encoded []byte) (map[uint16][]uint32, []byte) {
+	checker.Assert(string(encoded[0:4]), Equals, "MM\x00\x2a")
+	ifdOffset := binary.BigEndian.Uint32(encoded[4:8])
+	entries := int(binary.BigEndian.Uint16(encoded[ifdOffset:]))
+
+	tags := map[uint16][]uint32{}
+	for index := 0; index < entries; index++ {
+		entry := encoded[int(ifdOffset)+2+index*12:]
+		tag := binary.BigEndian.Uint16(entry[0:2])
+		fieldType := binary.BigEndian.Uint16(entry[2:4])
+		count := binary.BigEndian.Uint32(entry[4:8])
+		switch {
+		case fieldType == 3 && count == 1:
+			tags[tag] = []uint32{uint32(binary.BigEndian.Uint16(entry[8:10]))}
+		case fieldType == 3:
+			offset := binary.BigEndian.Uint32(entry[8:12])
+			for value := uint32(0); value < count; value++ {
+				tags[tag] = append(tags[tag], uint32(binary.BigEndian.Uint16(encoded[offset+value*2:])))
+			}
+		case fieldType == 5:
+			offset := binary.BigEndian.Uint32(entry[8:12])
+			tags[tag] = []uint32{binary.BigEndian.Uint32(encoded[offset:]), binary.BigEndian.Uint32(encoded[offset+4:])}
+		default:
+			tags[tag] = []uint32{binary.BigEndian.Uint32(entry[8:12])}
+		}
+	}
+	pixelStart := tags[273][0]
+	return tags, encoded[pixelStart : pixelStart+tags[279][0]]
+}
+
+func (suite *FormatSuite) TestTIFFHoldsTheUncompressedPixels(checker *C) {
+	tags, pixels := readTIFF(checker, suite.encode(checker, "tiff", suite.eightBit, encoder.Options{}, nil))
+	checker.Assert(tags[256], DeepEquals, []uint32{7})
+	checker.Assert(tags[257], DeepEquals, []uint32{5})
+	checker.Assert(tags[258], DeepEquals, []uint32{8, 8, 8, 8})
+	checker.Assert(tags[259], DeepEquals, []uint32{1})
+	checker.Assert(tags[262], DeepEquals, []uint32{2})
+	checker.Assert(tags[277], DeepEquals, []uint32{4})
+	checker.Assert(tags[282], DeepEquals, []uint32{72, 1})
+	checker.Assert(tags[338], DeepEquals, []uint32{2})
+	checker.Assert(pixels, DeepEquals, suite.eightBit.Pix)
+}
+
+func (suite *FormatSuite) TestSixteenBitTIFF(checker *C) {
+	tags, pixels := readTIFF(checker, suite.encode(checker, "tiff", suite.sixteenBit, encoder.Options{BitDepth: 16}, nil))
+	checker.Assert(tags[258], DeepEquals, []uint32{16, 16, 16, 16})
+	checker.Assert(pixels, DeepEquals, suite.sixteenBit.Pix)
+}
+
+func (suite *FormatSuite) TestTIFFOfASubImageOnlyHoldsItsPixels(checker *C) {
+	subImage := suite.eightBit.SubImage(image.Rect(2, 1, 5, 4)).(*image.NRGBA)
+	tags, pixels := readTIFF(checker, suite.encode(checker, "tiff", subImage, encoder.Options{}, nil))
+	checker.Assert(tags[256], DeepEquals, []uint32{3})
+	checker.Assert(len(pixels), Equals, 3*3*4)
+	checker.Assert(pixels[0:4], DeepEquals, subImage.Pix[0:4])
+}
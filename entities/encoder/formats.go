@@ -0,0 +1,43 @@
+package encoder
+
+import (
+	"image"
+	"image/jpeg"
+	"io"
+	"wallpaper/entities/pngstream"
+)
+
+// DefaultJPEGQuality is the JPEG quality used when none is given.
+const DefaultJPEGQuality = 90
+
+func init() {
+	Register(&Format{
+		Name:       "png",
+		Extensions: []string{".png"},
+		BitDepths:  []int{8, 16},
+		Encode:     encodePNG,
+	})
+	Register(&Format{
+		Name:           "jpeg",
+		Extensions:     []string{".jpg", ".jpeg"},
+		BitDepths:      []int{8},
+		DefaultQuality: DefaultJPEGQuality,
+		Encode:         encodeJPEG,
+	})
+	Register(&Format{
+		Name:       "tiff",
+		Extensions: []string{".tif", ".tiff"},
+		BitDepths:  []int{8, 16},
+		Encode:     encodeTIFF,
+	})
+}
+
+// encodePNG writes a PNG with the metadata in text chunks.
+func encodePNG(output io.Writer, outputImage image.Image, options Options, metadata []pngstream.TextChunk) error {
+	return pngstream.EncodeWithText(output, nrgbaImage(outputImage, options.BitDepth), metadata)
+}
+
+// encodeJPEG writes a JPEG. JPEGs have no alpha, so transparent pixels turn black, and the metadata is dropped.
+func encodeJPEG(output io.Writer, outputImage image.Image, options Options, metadata []pngstream.TextChunk) error {
+	return jpeg.Encode(output, outputImage, &jpeg.Options{Quality: options.Quality})
+}
@@ -0,0 +1,132 @@
+package encoder
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"wallpaper/entities/pngstream"
+)
+
+// TIFF field types.
+const (
+	tiffShort    = 3
+	tiffLong     = 4
+	tiffRational = 5
+)
+
+// TIFF tags written for every image, in the ascending order the IFD requires.
+const (
+	tiffTagImageWidth                = 256
+	tiffTagImageLength               = 257
+	tiffTagBitsPerSample             = 258
+	tiffTagCompression               = 259
+	tiffTagPhotometricInterpretation = 262
+	tiffTagStripOffsets              = 273
+	tiffTagSamplesPerPixel           = 277
+	tiffTagRowsPerStrip              = 278
+	tiffTagStripByteCounts           = 279
+	tiffTagXResolution               = 282
+	tiffTagYResolution               = 283
+	tiffTagPlanarConfiguration       = 284
+	tiffTagResolutionUnit            = 296
+	tiffTagExtraSamples              = 338
+)
+
+// tiffHeaderSize is the byte order mark, the magic number and the offset of the first IFD.
+const tiffHeaderSize = 8
+
+// tiffDotsPerInch is the resolution stored in the file. Wallpapers have no physical size, so it is the usual screen value.
+const tiffDotsPerInch = 72
+
+type tiffEntry struct {
+	tag       uint16
+	fieldType uint16
+	count     uint32
+	// value holds values that fit in 4 bytes. Larger values are written after the IFD and value is their offset.
+	value uint32
+}
+
+// encodeTIFF writes a baseline, uncompressed, big-endian RGBA TIFF with unassociated alpha in a single strip.
+//   The metadata is dropped.
+func encodeTIFF(output io.Writer, outputImage image.Image, options Options, metadata []pngstream.TextChunk) error {
+	pixelImage := nrgbaImage(outputImage, options.BitDepth)
+	bounds := pixelImage.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	bytesPerSample := options.BitDepth / 8
+	rowLength := width * 4 * bytesPerSample
+	pixelDataSize := uint64(rowLength) * uint64(height)
+	if pixelDataSize > 1<<32-1 {
+		return fmt.Errorf("a %dx%d image is too big for a tiff", width, height)
+	}
+
+	const entries = 14
+	ifdSize := 2 + entries*12 + 4
+	bitsPerSampleOffset := uint32(tiffHeaderSize + ifdSize)
+	xResolutionOffset := bitsPerSampleOffset + 8
+	yResolutionOffset := xResolutionOffset + 8
+	pixelDataOffset := yResolutionOffset + 8
+
+	ifd := []tiffEntry{
+		{tiffTagImageWidth, tiffLong, 1, uint32(width)},
+		{tiffTagImageLength, tiffLong, 1, uint32(height)},
+		{tiffTagBitsPerSample, tiffShort, 4, bitsPerSampleOffset},
+		{tiffTagCompression, tiffShort, 1, 1},
+		{tiffTagPhotometricInterpretation, tiffShort, 1, 2},
+		{tiffTagStripOffsets, tiffLong, 1, pixelDataOffset},
+		{tiffTagSamplesPerPixel, tiffShort, 1, 4},
+		{tiffTagRowsPerStrip, tiffLong, 1, uint32(height)},
+		{tiffTagStripByteCounts, tiffLong, 1, uint32(pixelDataSize)},
+		{tiffTagXResolution, tiffRational, 1, xResolutionOffset},
+		{tiffTagYResolution, tiffRational, 1, yResolutionOffset},
+		{tiffTagPlanarConfiguration, tiffShort, 1, 1},
+		{tiffTagResolutionUnit, tiffShort, 1, 2},
+		// Unassociated alpha, because the pixels are not premultiplied.
+		{tiffTagExtraSamples, tiffShort, 1, 2},
+	}
+
+	bufferedOutput := bufio.NewWriter(output)
+	header := []byte{'M', 'M', 0, 42, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(header[4:8], tiffHeaderSize)
+	bufferedOutput.Write(header)
+
+	entry := make([]byte, 12)
+	binary.Write(bufferedOutput, binary.BigEndian, uint16(len(ifd)))
+	for _, field := range ifd {
+		binary.BigEndian.PutUint16(entry[0:2], field.tag)
+		binary.BigEndian.PutUint16(entry[2:4], field.fieldType)
+		binary.BigEndian.PutUint32(entry[4:8], field.count)
+		if field.fieldType == tiffShort && field.count == 1 {
+			// Short values are left justified in the value field.
+			binary.BigEndian.PutUint16(entry[8:10], uint16(field.value))
+			binary.BigEndian.PutUint16(entry[10:12], 0)
+		} else {
+			binary.BigEndian.PutUint32(entry[8:12], field.value)
+		}
+		bufferedOutput.Write(entry)
+	}
+	binary.Write(bufferedOutput, binary.BigEndian, uint32(0))
+
+	bitsPerSample := uint16(options.BitDepth)
+	binary.Write(bufferedOutput, binary.BigEndian, [4]uint16{bitsPerSample, bitsPerSample, bitsPerSample, bitsPerSample})
+	binary.Write(bufferedOutput, binary.BigEndian, [2]uint32{tiffDotsPerInch, 1})
+	binary.Write(bufferedOutput, binary.BigEndian, [2]uint32{tiffDotsPerInch, 1})
+
+	// Both image types hold their samples in RGBA order, and NRGBA64 samples are already big-endian.
+	var pixels []byte
+	var stride int
+	switch typedImage := pixelImage.(type) {
+	case *image.NRGBA:
+		pixels, stride = typedImage.Pix, typedImage.Stride
+	case *image.NRGBA64:
+		pixels, stride = typedImage.Pix, typedImage.Stride
+	}
+	for row := 0; row < height; row++ {
+		_, err := bufferedOutput.Write(pixels[row*stride : row*stride+rowLength])
+		if err != nil {
+			return err
+		}
+	}
+	return bufferedOutput.Flush()
+}
@@ -0,0 +1,204 @@
+package field
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// fileMagic starts every field file.
+var fileMagic = []byte("WPFIELD\x00")
+
+// fileVersion is the current version of the field file layout.
+const fileVersion = 2
+
+// bytesPerValue is the size of one complex128 in a field file.
+const bytesPerValue = 16
+
+// Hash identifies the formula and sample space settings that produced a field.
+type Hash [sha256.Size]byte
+
+// Field holds the formula's values for every output pixel in row order,
+//   so the output can be colored again without calculating the formula.
+//   Supersampled pixels hold one value per sub-sample.
+type Field struct {
+	Width           int
+	Height          int
+	SamplesPerPixel int
+	Hash            Hash
+	Values          []complex128
+}
+
+// Header describes a field without loading its values.
+type Header struct {
+	Width           int
+	Height          int
+	SamplesPerPixel int
+	Hash            Hash
+}
+
+// New returns a field of the given size with every value set to 0.
+func New(width, height, samplesPerPixel int, hash Hash) *Field {
+	return &Field{
+		Width:           width,
+		Height:          height,
+		SamplesPerPixel: samplesPerPixel,
+		Hash:            hash,
+		Values:          make([]complex128, width*height*samplesPerPixel),
+	}
+}
+
+// PixelValues returns the values for the pixel at (x, y). Changing them changes the field.
+func (field *Field) PixelValues(x, y int) []complex128 {
+	start := (y*field.Width + x) * field.SamplesPerPixel
+	return field.Values[start : start+field.SamplesPerPixel]
+}
+
+// Write stores the field in its binary form: magic, then version, width, height and samples per pixel
+//   as little endian uint32s, the hash, then each value as 2 little endian float64s.
+func (field *Field) Write(output io.Writer) error {
+	if field.SamplesPerPixel < 1 || len(field.Values) != field.Width*field.Height*field.SamplesPerPixel {
+		return fmt.Errorf("field is %dx%d with %d samples per pixel but has %d values",
+			field.Width, field.Height, field.SamplesPerPixel, len(field.Values))
+	}
+
+	bufferedOutput := bufio.NewWriter(output)
+	header := make([]byte, 16)
+	binary.LittleEndian.PutUint32(header[0:4], fileVersion)
+	binary.LittleEndian.PutUint32(header[4:8], uint32(field.Width))
+	binary.LittleEndian.PutUint32(header[8:12], uint32(field.Height))
+	binary.LittleEndian.PutUint32(header[12:16], uint32(field.SamplesPerPixel))
+	bufferedOutput.Write(fileMagic)
+	bufferedOutput.Write(header)
+	bufferedOutput.Write(field.Hash[:])
+
+	rowLength := field.Width * field.SamplesPerPixel
+	row := make([]byte, rowLength*bytesPerValue)
+	for y := 0; y < field.Height; y++ {
+		for index, value := range field.Values[y*rowLength : (y+1)*rowLength] {
+			binary.LittleEndian.PutUint64(row[index*bytesPerValue:], math.Float64bits(real(value)))
+			binary.LittleEndian.PutUint64(row[index*bytesPerValue+8:], math.Float64bits(imag(value)))
+		}
+		_, err := bufferedOutput.Write(row)
+		if err != nil {
+			return err
+		}
+	}
+	return bufferedOutput.Flush()
+}
+
+// ReadHeader reads the size and hash at the start of a field file.
+func ReadHeader(input io.Reader) (*Header, error) {
+	magic := make([]byte, len(fileMagic))
+	_, err := io.ReadFull(input, magic)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read field header: %v", err)
+	}
+	if !bytes.Equal(magic, fileMagic) {
+		return nil, errors.New("not a field file")
+	}
+
+	header := make([]byte, 16)
+	_, err = io.ReadFull(input, header)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read field header: %v", err)
+	}
+	version := binary.LittleEndian.Uint32(header[0:4])
+	if version != fileVersion {
+		return nil, fmt.Errorf("field file version %d is not supported", version)
+	}
+
+	fieldHeader := &Header{
+		Width:           int(binary.LittleEndian.Uint32(header[4:8])),
+		Height:          int(binary.LittleEndian.Uint32(header[8:12])),
+		SamplesPerPixel: int(binary.LittleEndian.Uint32(header[12:16])),
+	}
+	if fieldHeader.SamplesPerPixel < 1 {
+		return nil, errors.New("field header has no samples per pixel")
+	}
+	_, err = io.ReadFull(input, fieldHeader.Hash[:])
+	if err != nil {
+		return nil, fmt.Errorf("cannot read field header: %v", err)
+	}
+	return fieldHeader, nil
+}
+
+// Read loads a field written by Write.
+func Read(input io.Reader) (*Field, error) {
+	bufferedInput := bufio.NewReader(input)
+	header, err := ReadHeader(bufferedInput)
+	if err != nil {
+		return nil, err
+	}
+
+	field := New(header.Width, header.Height, header.SamplesPerPixel, header.Hash)
+	rowLength := field.Width * field.SamplesPerPixel
+	row := make([]byte, rowLength*bytesPerValue)
+	for y := 0; y < field.Height; y++ {
+		_, err = io.ReadFull(bufferedInput, row)
+		if err != nil {
+			return nil, fmt.Errorf("field ends early at row %d: %v", y, err)
+		}
+		rowValues := field.Values[y*rowLength : (y+1)*rowLength]
+		for index := range rowValues {
+			realPart := math.Float64frombits(binary.LittleEndian.Uint64(row[index*bytesPerValue:]))
+			imaginaryPart := math.Float64frombits(binary.LittleEndian.Uint64(row[index*bytesPerValue+8:]))
+			rowValues[index] = complex(realPart, imaginaryPart)
+		}
+	}
+	return field, nil
+}
+
+// Save writes the field to the named file.
+func (field *Field) Save(filename string) error {
+	fieldFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = field.Write(fieldFile)
+	if err != nil {
+		fieldFile.Close()
+		return fmt.Errorf("cannot write field %s: %v", filename, err)
+	}
+	return fieldFile.Close()
+}
+
+// Load reads a field from the named file.
+func Load(filename string) (*Field, error) {
+	fieldFile, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fieldFile.Close()
+	return Read(fieldFile)
+}
+
+// LoadIfMatches loads the named field only if it was made with the given hash and size.
+//   It returns nil without an error if the file does not exist or is out of date.
+func LoadIfMatches(filename string, hash Hash, width, height int) (*Field, error) {
+	fieldFile, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer fieldFile.Close()
+
+	header, err := ReadHeader(fieldFile)
+	if err != nil || header.Hash != hash || header.Width != width || header.Height != height {
+		return nil, nil
+	}
+
+	_, err = fieldFile.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	return Read(fieldFile)
+}
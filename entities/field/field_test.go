@@ -0,0 +1,104 @@
+package field_test
+
+import (
+	"bytes"
+	. "gopkg.in/check.v1"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"wallpaper/entities/field"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type FieldSuite struct {
+	valueField *field.Field
+	directory  string
+}
+
+var _ = Suite(&FieldSuite{})
+
+func (suite *FieldSuite) SetUpTest(checker *C) {
+	suite.valueField = field.New(3, 2, 2, field.Hash{1, 2, 3})
+	suite.valueField.PixelValues(0, 0)[0] = complex(1, -1)
+	suite.valueField.PixelValues(2, 1)[1] = complex(-0.25, 1e10)
+	suite.valueField.PixelValues(1, 1)[0] = complex(math.Inf(1), 0)
+	suite.directory = checker.MkDir()
+}
+
+func (suite *FieldSuite) TestWriteAndReadRoundTrip(checker *C) {
+	var buffer bytes.Buffer
+	err := suite.valueField.Write(&buffer)
+	checker.Assert(err, IsNil)
+
+	readField, err := field.Read(&buffer)
+	checker.Assert(err, IsNil)
+	checker.Assert(readField, DeepEquals, suite.valueField)
+}
+
+func (suite *FieldSuite) TestReadHeaderDoesNotNeedTheValues(checker *C) {
+	var buffer bytes.Buffer
+	suite.valueField.Write(&buffer)
+
+	header, err := field.ReadHeader(bytes.NewReader(buffer.Bytes()[:56]))
+	checker.Assert(err, IsNil)
+	checker.Assert(header.Width, Equals, 3)
+	checker.Assert(header.Height, Equals, 2)
+	checker.Assert(header.SamplesPerPixel, Equals, 2)
+	checker.Assert(header.Hash, Equals, field.Hash{1, 2, 3})
+}
+
+func (suite *FieldSuite) TestPixelValuesHoldEachSubsample(checker *C) {
+	checker.Assert(suite.valueField.Values, HasLen, 3*2*2)
+	checker.Assert(suite.valueField.PixelValues(2, 1), DeepEquals, []complex128{0, complex(-0.25, 1e10)})
+	checker.Assert(suite.valueField.Values[11], Equals, complex(-0.25, 1e10))
+}
+
+func (suite *FieldSuite) TestReadRejectsOtherFiles(checker *C) {
+	_, err := field.Read(bytes.NewReader([]byte("not a field, just some bytes")))
+	checker.Assert(err, ErrorMatches, "not a field file")
+}
+
+func (suite *FieldSuite) TestReadRejectsTruncatedFields(checker *C) {
+	var buffer bytes.Buffer
+	suite.valueField.Write(&buffer)
+
+	_, err := field.Read(bytes.NewReader(buffer.Bytes()[:buffer.Len()-1]))
+	checker.Assert(err, ErrorMatches, "field ends early at row 1.*")
+}
+
+func (suite *FieldSuite) TestLoadIfMatchesReusesAMatchingField(checker *C) {
+	filename := filepath.Join(suite.directory, "cached.field")
+	checker.Assert(suite.valueField.Save(filename), IsNil)
+
+	loadedField, err := field.LoadIfMatches(filename, field.Hash{1, 2, 3}, 3, 2)
+	checker.Assert(err, IsNil)
+	checker.Assert(loadedField, DeepEquals, suite.valueField)
+}
+
+func (suite *FieldSuite) TestLoadIfMatchesIgnoresStaleFields(checker *C) {
+	filename := filepath.Join(suite.directory, "cached.field")
+	checker.Assert(suite.valueField.Save(filename), IsNil)
+
+	loadedField, err := field.LoadIfMatches(filename, field.Hash{9}, 3, 2)
+	checker.Assert(err, IsNil)
+	checker.Assert(loadedField, IsNil)
+
+	loadedField, err = field.LoadIfMatches(filename, field.Hash{1, 2, 3}, 4, 2)
+	checker.Assert(err, IsNil)
+	checker.Assert(loadedField, IsNil)
+}
+
+func (suite *FieldSuite) TestLoadIfMatchesIgnoresMissingAndCorruptFiles(checker *C) {
+	loadedField, err := field.LoadIfMatches(filepath.Join(suite.directory, "missing.field"), field.Hash{}, 3, 2)
+	checker.Assert(err, IsNil)
+	checker.Assert(loadedField, IsNil)
+
+	corruptFilename := filepath.Join(suite.directory, "corrupt.field")
+	checker.Assert(ioutil.WriteFile(corruptFilename, []byte("garbage"), os.ModePerm), IsNil)
+	loadedField, err = field.LoadIfMatches(corruptFilename, field.Hash{}, 3, 2)
+	checker.Assert(err, IsNil)
+	checker.Assert(loadedField, IsNil)
+}
@@ -0,0 +1,256 @@
+package catalog
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"wallpaper/entities/formula"
+	"wallpaper/entities/formula/coefficient"
+)
+
+// Family sorts the groups by the kind of pattern they describe.
+type Family string
+
+// The 3 families of symmetry groups the formulas can make.
+const (
+	// RosetteFamily groups have a center and no translations.
+	RosetteFamily Family = "rosette"
+	// FriezeFamily groups repeat along one direction.
+	FriezeFamily Family = "frieze"
+	// WallpaperFamily groups repeat along two directions.
+	WallpaperFamily Family = "wallpaper"
+)
+
+// Group describes one symmetry group: its names, what generates it and how formula coefficients make it.
+type Group struct {
+	Family Family
+	// Name is the name the rest of the program uses: the short international name of wallpaper groups,
+	//   the full Hermann–Mauguin name of friezes and Cn or Dn for rosettes.
+	Name string
+	// HermannMauguin is the full Hermann–Mauguin name, like p4gm.
+	HermannMauguin string
+	// IUC is the short international (IUC) name, like p4g.
+	IUC string
+	// Orbifold is Conway's orbifold name, like 4*2.
+	Orbifold string
+	// Conway is Conway's nickname for a frieze group, like spinning hop. Other groups have none.
+	Conway string
+	// Lattices lists the lattice shapes that can hold a wallpaper group, most general first.
+	Lattices []formula.LatticeType
+	// LockedRelationships are locked inside every wave packet of a wallpaper group's lattice, giving its rotations.
+	LockedRelationships []coefficient.Relationship
+	// Relationships are the coefficient relationships between terms or wave packets that give the rest of the symmetry.
+	//   A group without any always holds once its locked relationships do.
+	Relationships []coefficient.Relationship
+	// generators are placed on a lattice by Generators.
+	generators []generator
+}
+
+// Lattice returns the most general lattice shape that holds the group, or "" for rosettes and friezes.
+func (group *Group) Lattice() formula.LatticeType {
+	if len(group.Lattices) == 0 {
+		return ""
+	}
+	return group.Lattices[0]
+}
+
+// FitsLattice returns true if a lattice of this shape can hold the group.
+func (group *Group) FitsLattice(latticeType formula.LatticeType) bool {
+	for _, lattice := range group.Lattices {
+		if lattice == latticeType {
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns every name the group can be found by.
+func (group *Group) Names() []string {
+	names := []string{}
+	for _, name := range []string{group.Name, group.IUC, group.HermannMauguin, group.Orbifold, group.Conway} {
+		if name != "" && !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Generators returns the isometries that generate the group, placed on the lattice.
+//   Wallpaper groups expect vectors of the shape Lattice returns. Friezes repeat along
+//   the first vector, which is FriezeLattice for the frieze formulas. Rosettes only use the first vector's direction.
+func (group *Group) Generators(lattice formula.LatticeVectorPair) []Isometry {
+	isometries := []Isometry{}
+	for _, generator := range group.generators {
+		isometries = append(isometries, generator.isometry(lattice))
+	}
+	return isometries
+}
+
+// DescribeGenerators writes each generator in lattice coordinates.
+func (group *Group) DescribeGenerators() []string {
+	descriptions := []string{}
+	for _, generator := range group.generators {
+		descriptions = append(descriptions, generator.String())
+	}
+	return descriptions
+}
+
+// FriezeLattice is the lattice the frieze formulas repeat on: every 2 pi along the real axis.
+var FriezeLattice = formula.LatticeVectorPair{
+	XLatticeVector: complex(2*math.Pi, 0),
+	YLatticeVector: complex(0, 1),
+}
+
+// Wallpapers returns the 17 wallpaper groups, in the order of the international tables.
+func Wallpapers() []*Group {
+	return append([]*Group{}, wallpaperGroups...)
+}
+
+// Friezes returns the 7 frieze groups.
+func Friezes() []*Group {
+	return append([]*Group{}, friezeGroups...)
+}
+
+// WallpapersForLattice returns the wallpaper groups a lattice of this shape can hold.
+func WallpapersForLattice(latticeType formula.LatticeType) []*Group {
+	groups := []*Group{}
+	for _, group := range wallpaperGroups {
+		if group.FitsLattice(latticeType) {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// normalizeName makes names comparable: lower case, without spaces, with the orbifold cross written as x
+//   and the rosette's cone point • written as a full stop.
+func normalizeName(name string) string {
+	name = strings.Replace(name, "×", "x", -1)
+	name = strings.Replace(name, "•", ".", -1)
+	name = strings.Replace(name, "∞", "oo", -1)
+	return strings.ToLower(strings.Join(strings.Fields(name), ""))
+}
+
+// findGroup returns the group that has the name.
+func findGroup(groups []*Group, name string) *Group {
+	normalizedName := normalizeName(name)
+	for _, group := range groups {
+		for _, groupName := range group.Names() {
+			if normalizeName(groupName) == normalizedName {
+				return group
+			}
+		}
+	}
+	return nil
+}
+
+// describeGroups lists each group's name with its orbifold name.
+func describeGroups(groups []*Group) string {
+	descriptions := []string{}
+	for _, group := range groups {
+		descriptions = append(descriptions, fmt.Sprintf("%s (%s)", group.Name, group.Orbifold))
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// FindWallpaper returns the wallpaper group with the given Hermann–Mauguin or orbifold name.
+//   Case does not matter, and the orbifold cross can be written as x or ×.
+func FindWallpaper(name string) (*Group, error) {
+	group := findGroup(wallpaperGroups, name)
+	if group == nil {
+		return nil, fmt.Errorf("unknown wallpaper group %q, expected one of %s", name, describeGroups(wallpaperGroups))
+	}
+	return group, nil
+}
+
+// FindFrieze returns the frieze group with the given Hermann–Mauguin, orbifold or Conway name.
+//   Orbifold names can write infinity as ∞ or oo.
+func FindFrieze(name string) (*Group, error) {
+	group := findGroup(friezeGroups, name)
+	if group == nil {
+		return nil, fmt.Errorf("unknown frieze group %q, expected one of %s", name, describeGroups(friezeGroups))
+	}
+	return group, nil
+}
+
+// FindRosette returns the rosette group named Cn or Dn, or with the orbifold name n• or *n•.
+//   The • can be written as a full stop, like 4. or *4.
+func FindRosette(name string) (*Group, error) {
+	normalizedName := normalizeName(name)
+	if strings.HasPrefix(normalizedName, "c") || strings.HasPrefix(normalizedName, "d") {
+		folds, err := strconv.Atoi(normalizedName[1:])
+		if err == nil && folds > 0 {
+			return Rosette(folds, normalizedName[0] == 'd'), nil
+		}
+	} else {
+		// Plane orbifold names end with the point at infinity, like 4• or *4•.
+		digits := strings.TrimPrefix(normalizedName, "*")
+		if strings.HasSuffix(digits, ".") {
+			folds, err := strconv.Atoi(strings.TrimSuffix(digits, "."))
+			if err == nil && folds > 0 {
+				return Rosette(folds, digits != normalizedName), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unknown rosette group %q, expected Cn or Dn with n at least 1", name)
+}
+
+// Find returns every wallpaper and frieze group with the name, since some names like p2mm belong to both.
+//   If neither family has it, the name is read as a rosette group.
+func Find(name string) ([]*Group, error) {
+	groups := []*Group{}
+	for _, family := range [][]*Group{wallpaperGroups, friezeGroups} {
+		group := findGroup(family, name)
+		if group != nil {
+			groups = append(groups, group)
+		}
+	}
+	if len(groups) > 0 {
+		return groups, nil
+	}
+	rosette, err := FindRosette(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown symmetry group %q, expected a wallpaper group like p4g, a frieze group like p11g or a rosette group like D4", name)
+	}
+	return []*Group{rosette}, nil
+}
+
+// Rosette returns the cyclic group Cn, which turns by 360/n degrees, or the dihedral group Dn, which also has a mirror.
+//   folds must be at least 1.
+func Rosette(folds int, dihedral bool) *Group {
+	group := &Group{
+		Family:         RosetteFamily,
+		Name:           fmt.Sprintf("C%d", folds),
+		HermannMauguin: strconv.Itoa(folds),
+		Orbifold:       fmt.Sprintf("%d•", folds),
+	}
+	if folds > 1 {
+		group.generators = []generator{rotate(360 / float64(folds))}
+	}
+	if dihedral {
+		group.Name = fmt.Sprintf("D%d", folds)
+		group.Orbifold = "*" + group.Orbifold
+		switch {
+		case folds == 1:
+			group.HermannMauguin = "m"
+		case folds%2 == 0:
+			group.HermannMauguin += "mm"
+		default:
+			group.HermannMauguin += "m"
+		}
+		group.Relationships = []coefficient.Relationship{coefficient.PlusMPlusN}
+		group.generators = append(group.generators, reflect(0, 1))
+	}
+	group.IUC = group.HermannMauguin
+	return group
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
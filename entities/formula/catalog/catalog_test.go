@@ -0,0 +1,276 @@
+package catalog_test
+
+import (
+	. "gopkg.in/check.v1"
+	"math"
+	"math/cmplx"
+	"testing"
+	"wallpaper/entities/formula"
+	"wallpaper/entities/formula/catalog"
+	"wallpaper/entities/formula/coefficient"
+	"wallpaper/entities/formula/exponential"
+	"wallpaper/entities/formula/frieze"
+	"wallpaper/entities/formula/rosette"
+	"wallpaper/entities/formula/wavepacket"
+	"wallpaper/entities/utility"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type GroupNameSuite struct{}
+
+var _ = Suite(&GroupNameSuite{})
+
+func (suite *GroupNameSuite) TestCatalogHasEveryGroup(checker *C) {
+	checker.Assert(catalog.Wallpapers(), HasLen, 17)
+	checker.Assert(catalog.Friezes(), HasLen, 7)
+	checker.Assert(catalog.WallpapersForLattice(formula.HexagonalLattice), HasLen, 9)
+	checker.Assert(catalog.WallpapersForLattice(formula.SquareLattice), HasLen, 12)
+	checker.Assert(catalog.WallpapersForLattice(formula.ObliqueLattice), HasLen, 2)
+}
+
+func (suite *GroupNameSuite) TestFindWallpaperByAnyName(checker *C) {
+	names := map[string]string{
+		"p4g":  "p4g",
+		"p4gm": "p4g",
+		"4*2":  "p4g",
+		"22×":  "pgg",
+		"C2MM": "cmm",
+		"o":    "p1",
+		"p1m1": "pm",
+	}
+	for name, expectedName := range names {
+		group, err := catalog.FindWallpaper(name)
+		checker.Assert(err, IsNil)
+		checker.Assert(group.Name, Equals, expectedName, Commentf("%s", name))
+		checker.Assert(group.Family, Equals, catalog.WallpaperFamily)
+	}
+
+	_, err := catalog.FindWallpaper("p5")
+	checker.Assert(err, ErrorMatches, `unknown wallpaper group "p5", expected one of p1 \(o\), .*`)
+}
+
+func (suite *GroupNameSuite) TestFindFriezeByAnyName(checker *C) {
+	names := map[string]string{
+		"p11g":          "p11g",
+		"step":          "p11g",
+		"∞×":            "p11g",
+		"oox":           "p11g",
+		"spinning hop":  "p211",
+		"p2":            "p211",
+		"*22∞":          "p2mm",
+		"Spinning Jump": "p2mm",
+	}
+	for name, expectedName := range names {
+		group, err := catalog.FindFrieze(name)
+		checker.Assert(err, IsNil)
+		checker.Assert(group.Name, Equals, expectedName, Commentf("%s", name))
+	}
+
+	_, err := catalog.FindFrieze("p4g")
+	checker.Assert(err, ErrorMatches, `unknown frieze group "p4g", .*`)
+}
+
+func (suite *GroupNameSuite) TestFindRosetteByAnyName(checker *C) {
+	names := map[string]string{
+		"C4":   "C4",
+		"d3":   "D3",
+		"4•":   "C4",
+		"*3•":  "D3",
+		"*12.": "D12",
+	}
+	for name, expectedName := range names {
+		group, err := catalog.FindRosette(name)
+		checker.Assert(err, IsNil)
+		checker.Assert(group.Name, Equals, expectedName, Commentf("%s", name))
+	}
+
+	for _, name := range []string{"C0", "D", "*34", "44", "0•", "p4"} {
+		_, err := catalog.FindRosette(name)
+		checker.Assert(err, ErrorMatches, `unknown rosette group .*`, Commentf("%s", name))
+	}
+}
+
+func (suite *GroupNameSuite) TestRosetteNames(checker *C) {
+	checker.Assert(catalog.Rosette(4, false).Orbifold, Equals, "4•")
+	checker.Assert(catalog.Rosette(4, true).HermannMauguin, Equals, "4mm")
+	checker.Assert(catalog.Rosette(3, true).HermannMauguin, Equals, "3m")
+	checker.Assert(catalog.Rosette(3, true).Orbifold, Equals, "*3•")
+	checker.Assert(catalog.Rosette(1, true).HermannMauguin, Equals, "m")
+}
+
+func (suite *GroupNameSuite) TestFindReturnsEveryFamilyWithTheName(checker *C) {
+	groups, err := catalog.Find("p2mm")
+	checker.Assert(err, IsNil)
+	checker.Assert(groups, HasLen, 2)
+	checker.Assert(groups[0].Name, Equals, "pmm")
+	checker.Assert(groups[1].Name, Equals, "p2mm")
+
+	groups, err = catalog.Find("D6")
+	checker.Assert(err, IsNil)
+	checker.Assert(groups, HasLen, 1)
+	checker.Assert(groups[0].Family, Equals, catalog.RosetteFamily)
+
+	_, err = catalog.Find("p5")
+	checker.Assert(err, ErrorMatches, `unknown symmetry group "p5", .*`)
+}
+
+func (suite *GroupNameSuite) TestLatticeIsTheMostGeneralShape(checker *C) {
+	for name, expectedLattice := range map[string]formula.LatticeType{
+		"p2":  formula.ObliqueLattice,
+		"pgg": formula.RectangularLattice,
+		"cm":  formula.RhombicLattice,
+		"p4":  formula.SquareLattice,
+		"p6m": formula.HexagonalLattice,
+	} {
+		group, _ := catalog.FindWallpaper(name)
+		checker.Assert(group.Lattice(), Equals, expectedLattice, Commentf("%s", name))
+	}
+	group, _ := catalog.FindFrieze("p111")
+	checker.Assert(group.Lattice(), Equals, formula.LatticeType(""))
+}
+
+type IsometrySuite struct{}
+
+var _ = Suite(&IsometrySuite{})
+
+func (suite *IsometrySuite) TestGeneratorsArePlacedOnTheLattice(checker *C) {
+	group, _ := catalog.FindWallpaper("p4g")
+	lattice := formula.LatticeVectorPair{
+		XLatticeVector: complex(2, 0),
+		YLatticeVector: complex(0, 2),
+	}
+	generators := group.Generators(lattice)
+	checker.Assert(generators, HasLen, 4)
+	checker.Assert(generators[1].Kind, Equals, catalog.Translation)
+	checker.Assert(generators[1].Apply(complex(1, 1)), Equals, complex(1, 3))
+
+	checker.Assert(generators[2].Kind, Equals, catalog.Rotation)
+	checker.Assert(cmplx.Abs(generators[2].Apply(complex(1, 0))-complex(0, 1)), utility.NumericallyCloseEnough{}, 0.0, 1e-9)
+
+	checker.Assert(generators[3].Kind, Equals, catalog.GlideReflection)
+	checker.Assert(cmplx.Abs(generators[3].Apply(complex(1, 0))-complex(1, 2)), utility.NumericallyCloseEnough{}, 0.0, 1e-9)
+	checker.Assert(group.DescribeGenerators()[3], Equals, "glide reflection across the line through (0,0) along (1,1), moving (0.5,0.5)")
+}
+
+// samplePoints are spread around the plane so a symmetry that holds at all of them is unlikely to be a coincidence.
+var samplePoints = []complex128{
+	complex(0.1, 0.2),
+	complex(-0.37, 0.81),
+	complex(1.3, -0.6),
+	complex(2.9, 1.7),
+}
+
+// checkGeneratorsPreserveFormula asserts the formula has the same value before and after each generator moves z.
+func checkGeneratorsPreserveFormula(checker *C, group *catalog.Group, lattice formula.LatticeVectorPair, calculate func(complex128) complex128) {
+	for generatorIndex, generator := range group.Generators(lattice) {
+		for _, z := range samplePoints {
+			difference := cmplx.Abs(calculate(generator.Apply(z)) - calculate(z))
+			checker.Assert(difference, utility.NumericallyCloseEnough{}, 0.0, 1e-6, Commentf("%s generator %d at %v", group.Name, generatorIndex, z))
+		}
+	}
+}
+
+// wallpaperTestLattices have the conventional shape for each kind of lattice.
+var wallpaperTestLattices = map[formula.LatticeType]formula.LatticeVectorPair{
+	formula.ObliqueLattice:     {XLatticeVector: complex(1, 0), YLatticeVector: complex(0.3, 1.2)},
+	formula.RectangularLattice: {XLatticeVector: complex(1, 0), YLatticeVector: complex(0, 0.7)},
+	formula.RhombicLattice:     {XLatticeVector: complex(0.5, 0.7), YLatticeVector: complex(0.5, -0.7)},
+	formula.SquareLattice:      {XLatticeVector: complex(1, 0), YLatticeVector: complex(0, 1)},
+	formula.HexagonalLattice:   {XLatticeVector: complex(1, 0), YLatticeVector: complex(-0.5, math.Sqrt(3)/2)},
+}
+
+// wallpaperFormulaForGroup makes a wave packet for each term and each of the group's coefficient relationships,
+//   then locks the group's locked relationships into every packet.
+func wallpaperFormulaForGroup(group *catalog.Group, lattice formula.LatticeVectorPair) *wavepacket.WallpaperFormula {
+	wavePackets := []*wavepacket.WavePacket{}
+	for _, term := range []struct {
+		powerN     int
+		powerM     int
+		multiplier complex128
+	}{
+		{powerN: 2, powerM: -1, multiplier: complex(1, 0.5)},
+		{powerN: 1, powerM: 3, multiplier: complex(-0.5, 1)},
+	} {
+		wavePackets = append(wavePackets, &wavepacket.WavePacket{
+			Terms:      []*formula.EisensteinFormulaTerm{{PowerN: term.powerN, PowerM: term.powerM}},
+			Multiplier: term.multiplier,
+		})
+		pairings := coefficient.Pairing{PowerN: term.powerN, PowerM: term.powerM}.GenerateCoefficientSets(group.Relationships)
+		for _, pairing := range pairings {
+			multiplier := term.multiplier
+			if pairing.NegateMultiplier {
+				multiplier *= -1
+			}
+			wavePackets = append(wavePackets, &wavepacket.WavePacket{
+				Terms:      []*formula.EisensteinFormulaTerm{{PowerN: pairing.PowerN, PowerM: pairing.PowerM}},
+				Multiplier: multiplier,
+			})
+		}
+	}
+
+	wallpaperFormula := &wavepacket.WallpaperFormula{
+		WavePackets: wavePackets,
+		Multiplier:  1,
+		Lattice:     &lattice,
+	}
+	wallpaperFormula.SetUp(group.LockedRelationships)
+	return wallpaperFormula
+}
+
+func (suite *IsometrySuite) TestWallpaperGeneratorsPreserveTheirFormulas(checker *C) {
+	for _, group := range catalog.Wallpapers() {
+		lattice := wallpaperTestLattices[group.Lattice()]
+		wallpaperFormula := wallpaperFormulaForGroup(group, lattice)
+		checkGeneratorsPreserveFormula(checker, group, lattice, func(z complex128) complex128 {
+			return wallpaperFormula.Calculate(z).Total
+		})
+	}
+}
+
+func (suite *IsometrySuite) TestWallpaperGroupsFitEveryListedLattice(checker *C) {
+	for _, group := range catalog.Wallpapers() {
+		for _, latticeType := range group.Lattices {
+			checker.Assert(catalog.WallpapersForLattice(latticeType), Not(HasLen), 0)
+			found := false
+			for _, compatibleGroup := range catalog.WallpapersForLattice(latticeType) {
+				found = found || compatibleGroup == group
+			}
+			checker.Assert(found, Equals, true, Commentf("%s on %s", group.Name, latticeType))
+		}
+		checker.Assert(wavepacket.LatticeTypeForSymmetry(wavepacket.Symmetry(group.Name)), Equals, group.Lattice())
+	}
+}
+
+func (suite *IsometrySuite) TestFriezeGeneratorsPreserveTheirFormulas(checker *C) {
+	for _, group := range catalog.Friezes() {
+		friezeFormula := frieze.Formula{
+			Terms: []*exponential.RosetteFriezeTerm{
+				{Multiplier: complex(1, 0.5), PowerN: 2, PowerM: -1, CoefficientRelationships: group.Relationships},
+				{Multiplier: complex(-0.5, 1), PowerN: 1, PowerM: 3, CoefficientRelationships: group.Relationships},
+			},
+		}
+		checker.Assert(friezeFormula.Symmetries(), Not(HasLen), 0)
+
+		checkGeneratorsPreserveFormula(checker, group, catalog.FriezeLattice, func(z complex128) complex128 {
+			return friezeFormula.Calculate(z).Total
+		})
+	}
+}
+
+func (suite *IsometrySuite) TestRosetteGeneratorsPreserveTheirFormulas(checker *C) {
+	for _, dihedral := range []bool{false, true} {
+		group := catalog.Rosette(3, dihedral)
+		rosetteFormula := rosette.Formula{
+			Terms: []*exponential.RosetteFriezeTerm{
+				{Multiplier: complex(1, 0.5), PowerN: 4, PowerM: 1, CoefficientRelationships: group.Relationships},
+				{Multiplier: complex(-0.5, 1), PowerN: 2, PowerM: -4, CoefficientRelationships: group.Relationships},
+			},
+		}
+		checker.Assert(rosetteFormula.Symmetries()[len(rosetteFormula.Symmetries())-1], Equals, group.Name)
+
+		checkGeneratorsPreserveFormula(checker, group, formula.LatticeVectorPair{XLatticeVector: 1, YLatticeVector: 1i}, func(z complex128) complex128 {
+			return rosetteFormula.Calculate(z).Total
+		})
+	}
+}
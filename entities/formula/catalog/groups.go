@@ -0,0 +1,313 @@
+package catalog
+
+import (
+	"wallpaper/entities/formula"
+	"wallpaper/entities/formula/coefficient"
+)
+
+// Each group's relationships are listed in the order the wave packets are added for it.
+var (
+	squareLockedRelationships = []coefficient.Relationship{
+		coefficient.PlusMMinusN,
+		coefficient.MinusNMinusM,
+		coefficient.MinusMPlusN,
+	}
+	hexagonalLockedRelationships = []coefficient.Relationship{
+		coefficient.PlusMMinusSumNAndM,
+		coefficient.MinusSumNAndMPlusN,
+	}
+)
+
+// wallpaperGroups generators are in lattice coordinates of the conventional vectors for the group's lattice.
+//   Rhombic lattices use two vectors of the same length, so their mirrors run along the diagonals.
+var wallpaperGroups = []*Group{
+	{
+		Family:         WallpaperFamily,
+		Name:           "p1",
+		IUC:            "p1",
+		HermannMauguin: "p1",
+		Orbifold:       "o",
+		Lattices: []formula.LatticeType{
+			formula.ObliqueLattice,
+			formula.RectangularLattice,
+			formula.RhombicLattice,
+			formula.SquareLattice,
+			formula.HexagonalLattice,
+		},
+		generators: []generator{translate(1), translate(1i)},
+	},
+	{
+		Family:         WallpaperFamily,
+		Name:           "p2",
+		IUC:            "p2",
+		HermannMauguin: "p2",
+		Orbifold:       "2222",
+		Lattices: []formula.LatticeType{
+			formula.ObliqueLattice,
+			formula.RectangularLattice,
+			formula.RhombicLattice,
+			formula.SquareLattice,
+			formula.HexagonalLattice,
+		},
+		Relationships: []coefficient.Relationship{coefficient.MinusNMinusM},
+		generators:    []generator{translate(1), translate(1i), rotate(180)},
+	},
+	{
+		Family:         WallpaperFamily,
+		Name:           "pm",
+		IUC:            "pm",
+		HermannMauguin: "p1m1",
+		Orbifold:       "**",
+		Lattices:       []formula.LatticeType{formula.RectangularLattice, formula.SquareLattice},
+		Relationships:  []coefficient.Relationship{coefficient.PlusNMinusM},
+		generators:     []generator{translate(1), translate(1i), reflect(0, 1)},
+	},
+	{
+		Family:         WallpaperFamily,
+		Name:           "pg",
+		IUC:            "pg",
+		HermannMauguin: "p1g1",
+		Orbifold:       "xx",
+		Lattices:       []formula.LatticeType{formula.RectangularLattice, formula.SquareLattice},
+		Relationships:  []coefficient.Relationship{coefficient.PlusNMinusMNegateMultiplierIfOddPowerN},
+		generators:     []generator{translate(1), translate(1i), glideReflect(0, 1, 0.5)},
+	},
+	{
+		Family:         WallpaperFamily,
+		Name:           "cm",
+		IUC:            "cm",
+		HermannMauguin: "c1m1",
+		Orbifold:       "*x",
+		Lattices:       []formula.LatticeType{formula.RhombicLattice, formula.SquareLattice, formula.HexagonalLattice},
+		Relationships:  []coefficient.Relationship{coefficient.PlusMPlusN},
+		generators:     []generator{translate(1), translate(1i), reflect(0, 1+1i)},
+	},
+	{
+		Family:         WallpaperFamily,
+		Name:           "pmm",
+		IUC:            "pmm",
+		HermannMauguin: "p2mm",
+		Orbifold:       "*2222",
+		Lattices:       []formula.LatticeType{formula.RectangularLattice, formula.SquareLattice},
+		Relationships: []coefficient.Relationship{
+			coefficient.MinusNMinusM,
+			coefficient.MinusNPlusM,
+			coefficient.PlusNMinusM,
+		},
+		generators: []generator{translate(1), translate(1i), reflect(0, 1), reflect(0, 1i)},
+	},
+	{
+		Family:         WallpaperFamily,
+		Name:           "pmg",
+		IUC:            "pmg",
+		HermannMauguin: "p2mg",
+		Orbifold:       "22*",
+		Lattices:       []formula.LatticeType{formula.RectangularLattice, formula.SquareLattice},
+		Relationships: []coefficient.Relationship{
+			coefficient.MinusNMinusM,
+			coefficient.MinusNPlusMNegateMultiplierIfOddPowerN,
+			coefficient.PlusNMinusMNegateMultiplierIfOddPowerN,
+		},
+		generators: []generator{translate(1), translate(1i), rotate(180), glideReflect(0, 1, 0.5)},
+	},
+	{
+		Family:         WallpaperFamily,
+		Name:           "pgg",
+		IUC:            "pgg",
+		HermannMauguin: "p2gg",
+		Orbifold:       "22x",
+		Lattices:       []formula.LatticeType{formula.RectangularLattice, formula.SquareLattice},
+		Relationships: []coefficient.Relationship{
+			coefficient.MinusNMinusM,
+			coefficient.MinusNPlusMNegateMultiplierIfOddPowerSum,
+			coefficient.PlusNMinusMNegateMultiplierIfOddPowerSum,
+		},
+		generators: []generator{translate(1), translate(1i), rotate(180), glideReflect(0.25i, 1, 0.5)},
+	},
+	{
+		Family:         WallpaperFamily,
+		Name:           "cmm",
+		IUC:            "cmm",
+		HermannMauguin: "c2mm",
+		Orbifold:       "2*22",
+		Lattices:       []formula.LatticeType{formula.RhombicLattice, formula.SquareLattice, formula.HexagonalLattice},
+		Relationships: []coefficient.Relationship{
+			coefficient.MinusNMinusM,
+			coefficient.PlusMPlusN,
+			coefficient.MinusMMinusN,
+		},
+		generators: []generator{translate(1), translate(1i), reflect(0, 1+1i), reflect(0, 1-1i)},
+	},
+	{
+		Family:              WallpaperFamily,
+		Name:                "p4",
+		IUC:                 "p4",
+		HermannMauguin:      "p4",
+		Orbifold:            "442",
+		Lattices:            []formula.LatticeType{formula.SquareLattice},
+		LockedRelationships: squareLockedRelationships,
+		generators:          []generator{translate(1), translate(1i), rotate(90)},
+	},
+	{
+		Family:              WallpaperFamily,
+		Name:                "p4m",
+		IUC:                 "p4m",
+		HermannMauguin:      "p4mm",
+		Orbifold:            "*442",
+		Lattices:            []formula.LatticeType{formula.SquareLattice},
+		LockedRelationships: squareLockedRelationships,
+		Relationships:       []coefficient.Relationship{coefficient.PlusMPlusN},
+		generators:          []generator{translate(1), translate(1i), rotate(90), reflect(0, 1+1i)},
+	},
+	{
+		Family:              WallpaperFamily,
+		Name:                "p4g",
+		IUC:                 "p4g",
+		HermannMauguin:      "p4gm",
+		Orbifold:            "4*2",
+		Lattices:            []formula.LatticeType{formula.SquareLattice},
+		LockedRelationships: squareLockedRelationships,
+		Relationships:       []coefficient.Relationship{coefficient.PlusMPlusNNegateMultiplierIfOddPowerSum},
+		generators:          []generator{translate(1), translate(1i), rotate(90), glideReflect(0, 1+1i, 0.5+0.5i)},
+	},
+	{
+		Family:              WallpaperFamily,
+		Name:                "p3",
+		IUC:                 "p3",
+		HermannMauguin:      "p3",
+		Orbifold:            "333",
+		Lattices:            []formula.LatticeType{formula.HexagonalLattice},
+		LockedRelationships: hexagonalLockedRelationships,
+		generators:          []generator{translate(1), translate(1i), rotate(120)},
+	},
+	{
+		Family:              WallpaperFamily,
+		Name:                "p3m1",
+		IUC:                 "p3m1",
+		HermannMauguin:      "p3m1",
+		Orbifold:            "*333",
+		Lattices:            []formula.LatticeType{formula.HexagonalLattice},
+		LockedRelationships: hexagonalLockedRelationships,
+		Relationships:       []coefficient.Relationship{coefficient.MinusMMinusN},
+		generators:          []generator{translate(1), translate(1i), rotate(120), reflect(0, 1-1i)},
+	},
+	{
+		Family:              WallpaperFamily,
+		Name:                "p31m",
+		IUC:                 "p31m",
+		HermannMauguin:      "p31m",
+		Orbifold:            "3*3",
+		Lattices:            []formula.LatticeType{formula.HexagonalLattice},
+		LockedRelationships: hexagonalLockedRelationships,
+		Relationships:       []coefficient.Relationship{coefficient.PlusMPlusN},
+		generators:          []generator{translate(1), translate(1i), rotate(120), reflect(0, 1+1i)},
+	},
+	{
+		Family:              WallpaperFamily,
+		Name:                "p6",
+		IUC:                 "p6",
+		HermannMauguin:      "p6",
+		Orbifold:            "632",
+		Lattices:            []formula.LatticeType{formula.HexagonalLattice},
+		LockedRelationships: hexagonalLockedRelationships,
+		Relationships:       []coefficient.Relationship{coefficient.MinusNMinusM},
+		generators:          []generator{translate(1), translate(1i), rotate(60)},
+	},
+	{
+		Family:              WallpaperFamily,
+		Name:                "p6m",
+		IUC:                 "p6m",
+		HermannMauguin:      "p6mm",
+		Orbifold:            "*632",
+		Lattices:            []formula.LatticeType{formula.HexagonalLattice},
+		LockedRelationships: hexagonalLockedRelationships,
+		Relationships: []coefficient.Relationship{
+			coefficient.MinusNMinusM,
+			coefficient.PlusMPlusN,
+			coefficient.MinusMMinusN,
+		},
+		generators: []generator{translate(1), translate(1i), rotate(60), reflect(0, 1+1i)},
+	},
+}
+
+// friezeGroups generators are in lattice coordinates of FriezeLattice, so the pattern repeats along (1,0).
+//   Frieze names are the full Hermann–Mauguin names, with the short IUC name alongside.
+var friezeGroups = []*Group{
+	{
+		Family:         FriezeFamily,
+		Name:           "p111",
+		HermannMauguin: "p111",
+		IUC:            "p1",
+		Orbifold:       "∞∞",
+		Conway:         "hop",
+		generators:     []generator{translate(1)},
+	},
+	{
+		Family:         FriezeFamily,
+		Name:           "p211",
+		HermannMauguin: "p211",
+		IUC:            "p2",
+		Orbifold:       "22∞",
+		Conway:         "spinning hop",
+		Relationships:  []coefficient.Relationship{coefficient.MinusNMinusM},
+		generators:     []generator{translate(1), rotate(180)},
+	},
+	{
+		Family:         FriezeFamily,
+		Name:           "p1m1",
+		HermannMauguin: "p1m1",
+		IUC:            "p1m1",
+		Orbifold:       "*∞∞",
+		Conway:         "sidle",
+		Relationships:  []coefficient.Relationship{coefficient.PlusMPlusN},
+		generators:     []generator{translate(1), reflect(0, 1i)},
+	},
+	{
+		Family:         FriezeFamily,
+		Name:           "p11g",
+		HermannMauguin: "p11g",
+		IUC:            "p11g",
+		Orbifold:       "∞x",
+		Conway:         "step",
+		Relationships:  []coefficient.Relationship{coefficient.MinusMMinusNNegateMultiplierIfOddPowerSum},
+		generators:     []generator{glideReflect(0, 1, 0.5)},
+	},
+	{
+		Family:         FriezeFamily,
+		Name:           "p11m",
+		HermannMauguin: "p11m",
+		IUC:            "p11m",
+		Orbifold:       "∞*",
+		Conway:         "jump",
+		Relationships:  []coefficient.Relationship{coefficient.MinusMMinusN},
+		generators:     []generator{translate(1), reflect(0, 1)},
+	},
+	{
+		Family:         FriezeFamily,
+		Name:           "p2mm",
+		HermannMauguin: "p2mm",
+		IUC:            "p2mm",
+		Orbifold:       "*22∞",
+		Conway:         "spinning jump",
+		Relationships: []coefficient.Relationship{
+			coefficient.MinusNMinusM,
+			coefficient.PlusMPlusN,
+			coefficient.MinusMMinusN,
+		},
+		generators: []generator{translate(1), reflect(0, 1), reflect(0, 1i)},
+	},
+	{
+		Family:         FriezeFamily,
+		Name:           "p2mg",
+		HermannMauguin: "p2mg",
+		IUC:            "p2mg",
+		Orbifold:       "2*∞",
+		Conway:         "spinning sidle",
+		Relationships: []coefficient.Relationship{
+			coefficient.MinusNMinusM,
+			coefficient.PlusMPlusNNegateMultiplierIfOddPowerSum,
+			coefficient.MinusMMinusNNegateMultiplierIfOddPowerSum,
+		},
+		generators: []generator{rotate(180), glideReflect(0, 1, 0.5)},
+	},
+}
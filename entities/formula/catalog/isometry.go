@@ -0,0 +1,128 @@
+package catalog
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"wallpaper/entities/formula"
+)
+
+// IsometryKind names the kind of distance preserving map.
+type IsometryKind string
+
+// The 4 kinds of isometries that generate the symmetry groups.
+const (
+	Translation     IsometryKind = "translation"
+	Rotation        IsometryKind = "rotation"
+	Reflection      IsometryKind = "reflection"
+	GlideReflection IsometryKind = "glide reflection"
+)
+
+// Isometry maps the plane onto itself without changing any distances.
+//   It sends z to Linear * z + Translation, or Linear * conj(z) + Translation if it Reflects.
+type Isometry struct {
+	Kind        IsometryKind
+	Linear      complex128
+	Reflects    bool
+	Translation complex128
+}
+
+// Apply moves the point z.
+func (isometry Isometry) Apply(z complex128) complex128 {
+	if isometry.Reflects {
+		z = cmplx.Conj(z)
+	}
+	return isometry.Linear*z + isometry.Translation
+}
+
+// generator describes an isometry relative to a lattice, so the same description works for every lattice
+//   of the right shape. Points and directions are in lattice coordinates.
+type generator struct {
+	kind IsometryKind
+	// degrees is the counterclockwise angle of a rotation.
+	degrees float64
+	// point is the center of a rotation, or a point on the line of a reflection.
+	point complex128
+	// direction is the vector of a translation, or the direction of the line of a reflection.
+	direction complex128
+	// glide is how far a glide reflection moves along its line after reflecting.
+	glide complex128
+}
+
+// translate is a generator that moves the plane along the vector.
+func translate(vector complex128) generator {
+	return generator{kind: Translation, direction: vector}
+}
+
+// rotate is a generator that turns the plane around the origin.
+func rotate(degrees float64) generator {
+	return generator{kind: Rotation, degrees: degrees}
+}
+
+// reflect is a generator that mirrors the plane across the line through point with the given direction.
+func reflect(point complex128, direction complex128) generator {
+	return generator{kind: Reflection, point: point, direction: direction}
+}
+
+// glideReflect is a generator that mirrors the plane across a line and then slides it along the line.
+func glideReflect(point complex128, direction complex128, glide complex128) generator {
+	return generator{kind: GlideReflection, point: point, direction: direction, glide: glide}
+}
+
+// toCartesian converts a point in lattice coordinates to cartesian coordinates.
+func toCartesian(lattice formula.LatticeVectorPair, latticePoint complex128) complex128 {
+	return complex(real(latticePoint), 0)*lattice.XLatticeVector + complex(imag(latticePoint), 0)*lattice.YLatticeVector
+}
+
+// isometry places the generator on the lattice.
+func (generator generator) isometry(lattice formula.LatticeVectorPair) Isometry {
+	point := toCartesian(lattice, generator.point)
+	switch generator.kind {
+	case Translation:
+		return Isometry{
+			Kind:        Translation,
+			Linear:      1,
+			Translation: toCartesian(lattice, generator.direction),
+		}
+	case Rotation:
+		turn := cmplx.Rect(1, generator.degrees*math.Pi/180)
+		return Isometry{
+			Kind:        Rotation,
+			Linear:      turn,
+			Translation: point - turn*point,
+		}
+	}
+
+	direction := toCartesian(lattice, generator.direction)
+	unitDirection := direction / complex(cmplx.Abs(direction), 0)
+	mirror := unitDirection * unitDirection
+	return Isometry{
+		Kind:        generator.kind,
+		Linear:      mirror,
+		Reflects:    true,
+		Translation: point - mirror*cmplx.Conj(point) + toCartesian(lattice, generator.glide),
+	}
+}
+
+// String describes the generator in lattice coordinates.
+func (generator generator) String() string {
+	switch generator.kind {
+	case Translation:
+		return fmt.Sprintf("translation by %s", describeLatticePoint(generator.direction))
+	case Rotation:
+		return fmt.Sprintf("rotation by %g degrees about %s", generator.degrees, describeLatticePoint(generator.point))
+	case Reflection:
+		return fmt.Sprintf("reflection across the line through %s along %s", describeLatticePoint(generator.point), describeLatticePoint(generator.direction))
+	}
+	return fmt.Sprintf(
+		"glide reflection across the line through %s along %s, moving %s",
+		describeLatticePoint(generator.point),
+		describeLatticePoint(generator.direction),
+		describeLatticePoint(generator.glide),
+	)
+}
+
+// describeLatticePoint writes the point as its lattice coordinates.
+func describeLatticePoint(latticePoint complex128) string {
+	return fmt.Sprintf("(%g,%g)", real(latticePoint), imag(latticePoint))
+}
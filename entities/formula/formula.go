@@ -13,10 +13,27 @@ type CalculationResultForFormula struct {
 	ContributionByTerm	[]complex128
 }
 
+// Formula transforms a point in the sample space into a value that can be colored.
+//   Every rosette, frieze and wallpaper formula satisfies it.
+type Formula interface {
+	// SetUp prepares the formula before the first Calculate call.
+	SetUp() error
+	// Calculate applies the formula to the complex number z.
+	Calculate(z complex128) *CalculationResultForFormula
+	// Symmetries lists the names of the symmetry groups the formula has.
+	Symmetries() []string
+	// Validate returns an error if the formula cannot be calculated.
+	Validate() error
+}
+
 // LatticeVectorPairMarshal can be marshaled and converted to a LatticeVectorPair
 type LatticeVectorPairMarshal struct {
 	XLatticeVector			utility.ComplexNumberForMarshal	`json:"x_lattice_vector" yaml:"x_lattice_vector"`
 	YLatticeVector			utility.ComplexNumberForMarshal	`json:"y_lattice_vector" yaml:"y_lattice_vector"`
+	// RotationDegrees turns the whole lattice counterclockwise.
+	RotationDegrees			float64							`json:"rotation_degrees,omitempty" yaml:"rotation_degrees,omitempty"`
+	// Scale multiplies the length of both vectors. 0 leaves them alone.
+	Scale					float64							`json:"scale,omitempty" yaml:"scale,omitempty"`
 }
 
 // LatticeVectorPair defines the shape of the wallpaper lattice.
@@ -56,29 +73,8 @@ func(lattice LatticeVectorPair)Validate() error {
 	return nil
 }
 
-// ConvertToLatticeCoordinates converts a point from cartesian coordinates to the lattice coordinates
+// ConvertToLatticeCoordinates converts a point from cartesian coordinates to the lattice coordinates.
+//   Converting many points with the same lattice is faster with a LatticeCoordinateConverter.
 func (lattice LatticeVectorPair) ConvertToLatticeCoordinates(cartesianPoint complex128) complex128 {
-
-	vector1 := lattice.XLatticeVector
-	vector2 := lattice.YLatticeVector
-	swapVectorsDuringCalculation := real(vector1) < 1e-6
-
-	if swapVectorsDuringCalculation == true {
-		vector1 = lattice.YLatticeVector
-		vector2 = lattice.XLatticeVector
-	}
-
-	scalarForVector2Numerator := (real(vector1) * imag(cartesianPoint)) - (imag(vector1) * real(cartesianPoint))
-	scalarForVector2Denominator := (real(vector1) * imag(vector2)) - (imag(vector1) * real(vector2))
-	scalarForVector2 := scalarForVector2Numerator / scalarForVector2Denominator
-
-	scalarForVector1Numerator := real(cartesianPoint) - (scalarForVector2 * real(vector2))
-	scalarForVector1Denominator := real(vector1)
-	scalarForVector1 := scalarForVector1Numerator / scalarForVector1Denominator
-
-	if swapVectorsDuringCalculation {
-		return complex(scalarForVector2, scalarForVector1)
-	}
-
-	return complex(scalarForVector1, scalarForVector2)
+	return lattice.CoordinateConverter().Convert(cartesianPoint)
 }
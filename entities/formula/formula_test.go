@@ -2,6 +2,8 @@ package formula_test
 
 import (
 	. "gopkg.in/check.v1"
+	"math"
+	"math/cmplx"
 	"wallpaper/entities/formula"
 	"wallpaper/entities/utility"
 )
@@ -69,3 +71,152 @@ func (suite *LatticeVectorSuite) TestConvertToLatticeVectorEvenIfFirstVectorHasZ
 	checker.Assert(real(latticeCoordinate), utility.NumericallyCloseEnough{}, 2.0, 1e-6)
 	checker.Assert(imag(latticeCoordinate), utility.NumericallyCloseEnough{}, 1.0, 1e-6)
 }
+
+type LatticeShapeSuite struct {}
+
+var _ = Suite(&LatticeShapeSuite{})
+
+func (suite *LatticeShapeSuite) TestClassifyLatticeShapes(checker *C) {
+	shapes := []struct {
+		lattice formula.LatticeVectorPair
+		expectedShape formula.LatticeType
+	}{
+		{formula.LatticeVectorPair{XLatticeVector: complex(1, 0), YLatticeVector: complex(0.3, 1.2)}, formula.ObliqueLattice},
+		{formula.LatticeVectorPair{XLatticeVector: complex(1, 0), YLatticeVector: complex(0, 0.5)}, formula.RectangularLattice},
+		{formula.LatticeVectorPair{XLatticeVector: complex(0.5, 1), YLatticeVector: complex(0.5, -1)}, formula.RhombicLattice},
+		{formula.LatticeVectorPair{XLatticeVector: complex(2, 0), YLatticeVector: complex(0, -2)}, formula.SquareLattice},
+		{formula.LatticeVectorPair{XLatticeVector: complex(1, 0), YLatticeVector: complex(-0.5, math.Sqrt(3)/2)}, formula.HexagonalLattice},
+	}
+	for _, shape := range shapes {
+		checker.Assert(shape.lattice.Classify(), Equals, shape.expectedShape, Commentf("%v", shape.lattice))
+	}
+}
+
+func (suite *LatticeShapeSuite) TestRotatingAndScalingKeepsTheShape(checker *C) {
+	lattice := formula.NewLatticeVectorPairFromMarshal(formula.LatticeVectorPairMarshal{
+		XLatticeVector: utility.ComplexNumberForMarshal{Real: 1, Imaginary: 0},
+		YLatticeVector: utility.ComplexNumberForMarshal{Real: -0.5, Imaginary: math.Sqrt(3)/2},
+		RotationDegrees: 90,
+		Scale: 2,
+	})
+	checker.Assert(real(lattice.XLatticeVector), utility.NumericallyCloseEnough{}, 0, 1e-6)
+	checker.Assert(imag(lattice.XLatticeVector), utility.NumericallyCloseEnough{}, 2, 1e-6)
+	checker.Assert(real(lattice.YLatticeVector), utility.NumericallyCloseEnough{}, -math.Sqrt(3), 1e-6)
+	checker.Assert(imag(lattice.YLatticeVector), utility.NumericallyCloseEnough{}, -1, 1e-6)
+	checker.Assert(lattice.Classify(), Equals, formula.HexagonalLattice)
+}
+
+func (suite *LatticeShapeSuite) TestMissingScaleLeavesTheVectorsAlone(checker *C) {
+	lattice := formula.NewLatticeVectorPairFromMarshal(formula.LatticeVectorPairMarshal{
+		XLatticeVector: utility.ComplexNumberForMarshal{Real: 1, Imaginary: 0},
+		YLatticeVector: utility.ComplexNumberForMarshal{Real: 0.3, Imaginary: 1.2},
+	})
+	checker.Assert(lattice.XLatticeVector, Equals, complex(1, 0))
+	checker.Assert(lattice.YLatticeVector, Equals, complex(0.3, 1.2))
+}
+
+type LatticeReductionSuite struct {}
+
+var _ = Suite(&LatticeReductionSuite{})
+
+func (suite *LatticeReductionSuite) TestGaussReduceFindsTheShortestVectors(checker *C) {
+	skewedSquareLattice := formula.LatticeVectorPair{
+		XLatticeVector: complex(3, 1),
+		YLatticeVector: complex(1, 0),
+	}
+	reduced := skewedSquareLattice.GaussReduce()
+	checker.Assert(cmplx.Abs(reduced.XLatticeVector), utility.NumericallyCloseEnough{}, 1, 1e-6)
+	checker.Assert(cmplx.Abs(reduced.YLatticeVector), utility.NumericallyCloseEnough{}, 1, 1e-6)
+	checker.Assert(reduced.CellArea(), utility.NumericallyCloseEnough{}, skewedSquareLattice.CellArea(), 1e-6)
+	checker.Assert(reduced.Classify(), Equals, formula.SquareLattice)
+}
+
+func (suite *LatticeReductionSuite) TestGaussReduceTurnsHexagonalVectors120DegreesApart(checker *C) {
+	sixtyDegreeLattice := formula.LatticeVectorPair{
+		XLatticeVector: complex(1, 0),
+		YLatticeVector: complex(0.5, math.Sqrt(3)/2),
+	}
+	checker.Assert(sixtyDegreeLattice.Classify(), Equals, formula.RhombicLattice)
+	reduced := sixtyDegreeLattice.GaussReduce()
+	checker.Assert(reduced.Classify(), Equals, formula.HexagonalLattice)
+}
+
+func (suite *LatticeReductionSuite) TestGaussReduceKeepsReducedVectors(checker *C) {
+	hexagonalLattice := formula.LatticeVectorPair{
+		XLatticeVector: complex(1, 0),
+		YLatticeVector: complex(-0.5, math.Sqrt(3)/2),
+	}
+	checker.Assert(hexagonalLattice.GaussReduce(), Equals, hexagonalLattice)
+}
+
+func (suite *LatticeReductionSuite) TestBravaisTypeIgnoresTheChoiceOfVectors(checker *C) {
+	lattices := []struct {
+		lattice formula.LatticeVectorPair
+		expectedType formula.LatticeType
+	}{
+		{formula.LatticeVectorPair{XLatticeVector: complex(1, 0), YLatticeVector: complex(1, 1)}, formula.SquareLattice},
+		{formula.LatticeVectorPair{XLatticeVector: complex(1, 0), YLatticeVector: complex(2.5, math.Sqrt(3)/2)}, formula.HexagonalLattice},
+		{formula.LatticeVectorPair{XLatticeVector: complex(2, 0), YLatticeVector: complex(2, 0.5)}, formula.RectangularLattice},
+		{formula.LatticeVectorPair{XLatticeVector: complex(1, 0), YLatticeVector: complex(0.5, 2)}, formula.RhombicLattice},
+		{formula.LatticeVectorPair{XLatticeVector: complex(0.5, 1), YLatticeVector: complex(1.5, 1)}, formula.RhombicLattice},
+		{formula.LatticeVectorPair{XLatticeVector: complex(1, 0), YLatticeVector: complex(0.3, 1.2)}, formula.ObliqueLattice},
+	}
+	for _, example := range lattices {
+		checker.Assert(example.lattice.BravaisType(1e-6), Equals, example.expectedType, Commentf("%v", example.lattice))
+	}
+}
+
+func (suite *LatticeReductionSuite) TestBravaisTypeUsesTheTolerance(checker *C) {
+	almostSquareLattice := formula.LatticeVectorPair{
+		XLatticeVector: complex(1, 0),
+		YLatticeVector: complex(0.01, 1),
+	}
+	checker.Assert(almostSquareLattice.BravaisType(1e-6), Equals, formula.ObliqueLattice)
+	checker.Assert(almostSquareLattice.BravaisType(2e-2), Equals, formula.SquareLattice)
+}
+
+func (suite *LatticeReductionSuite) TestConventionalBasisOfRhombicLatticeHasEqualLengths(checker *C) {
+	centeredRectangularLattice := formula.LatticeVectorPair{
+		XLatticeVector: complex(1, 0),
+		YLatticeVector: complex(0.5, 2),
+	}
+	conventional := centeredRectangularLattice.ConventionalBasis(1e-6)
+	checker.Assert(conventional.Classify(), Equals, formula.RhombicLattice)
+	checker.Assert(conventional.CellArea(), utility.NumericallyCloseEnough{}, 2, 1e-6)
+}
+
+func (suite *LatticeReductionSuite) TestCellArea(checker *C) {
+	lattice := formula.LatticeVectorPair{
+		XLatticeVector: complex(0, 2),
+		YLatticeVector: complex(3, 1),
+	}
+	checker.Assert(lattice.CellArea(), utility.NumericallyCloseEnough{}, 6, 1e-6)
+}
+
+func (suite *LatticeReductionSuite) TestReciprocalVectorsAreDualToTheLattice(checker *C) {
+	lattice := formula.LatticeVectorPair{
+		XLatticeVector: complex(1, 0),
+		YLatticeVector: complex(-0.5, math.Sqrt(3)/2),
+	}
+	reciprocal := lattice.Reciprocal()
+	dot := func(vector1 complex128, vector2 complex128) float64 {
+		return real(vector1)*real(vector2) + imag(vector1)*imag(vector2)
+	}
+	checker.Assert(dot(lattice.XLatticeVector, reciprocal.XLatticeVector), utility.NumericallyCloseEnough{}, 1, 1e-6)
+	checker.Assert(dot(lattice.XLatticeVector, reciprocal.YLatticeVector), utility.NumericallyCloseEnough{}, 0, 1e-6)
+	checker.Assert(dot(lattice.YLatticeVector, reciprocal.XLatticeVector), utility.NumericallyCloseEnough{}, 0, 1e-6)
+	checker.Assert(dot(lattice.YLatticeVector, reciprocal.YLatticeVector), utility.NumericallyCloseEnough{}, 1, 1e-6)
+	checker.Assert(reciprocal.CellArea(), utility.NumericallyCloseEnough{}, 1/lattice.CellArea(), 1e-6)
+}
+
+func (suite *LatticeReductionSuite) TestConvertWhenTheFirstVectorIsVertical(checker *C) {
+	lattice := formula.LatticeVectorPair{
+		XLatticeVector: complex(0, 1),
+		YLatticeVector: complex(2, 1),
+	}
+	converter := lattice.CoordinateConverter()
+	latticeCoordinate := converter.Convert(complex(3, 4))
+	checker.Assert(real(latticeCoordinate), utility.NumericallyCloseEnough{}, 2.5, 1e-6)
+	checker.Assert(imag(latticeCoordinate), utility.NumericallyCloseEnough{}, 1.5, 1e-6)
+	checker.Assert(lattice.ConvertToLatticeCoordinates(complex(3, 4)), Equals, latticeCoordinate)
+}
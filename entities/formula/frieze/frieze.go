@@ -1,9 +1,11 @@
 package frieze
 
 import (
+	"errors"
 	"gopkg.in/yaml.v2"
 	"math/cmplx"
 	"wallpaper/entities/formula"
+	"wallpaper/entities/formula/catalog"
 	"wallpaper/entities/formula/coefficient"
 	"wallpaper/entities/formula/exponential"
 	"wallpaper/entities/utility"
@@ -30,6 +32,31 @@ func (friezeFormula Formula) Calculate(z complex128) *formula.CalculationResultF
 	return result
 }
 
+// SetUp does nothing, frieze terms are ready to calculate.
+func (friezeFormula *Formula) SetUp() error {
+	return nil
+}
+
+// Validate returns an error if the formula cannot be calculated.
+func (friezeFormula *Formula) Validate() error {
+	if len(friezeFormula.Terms) == 0 {
+		return errors.New("frieze formula needs at least one term")
+	}
+	return nil
+}
+
+// Symmetries lists the names of the frieze symmetries the formula has, in catalog order.
+func (friezeFormula *Formula) Symmetries() []string {
+	symmetriesFound := friezeFormula.AnalyzeForSymmetry()
+	symmetryNames := []string{}
+	for _, group := range catalog.Friezes() {
+		if symmetriesFound.Has(group.Name) {
+			symmetryNames = append(symmetryNames, group.Name)
+		}
+	}
+	return symmetryNames
+}
+
 func (friezeFormula *Formula) calculateTerm(term *exponential.RosetteFriezeTerm, z complex128) complex128 {
 	sum := complex(0.0,0.0)
 
@@ -61,6 +88,23 @@ type Symmetry struct {
 	P2mg bool
 }
 
+// Has returns true if the frieze group was found. Any of the group's catalog names work.
+func (symmetry *Symmetry) Has(groupName string) bool {
+	group, err := catalog.FindFrieze(groupName)
+	if err != nil {
+		return false
+	}
+	return map[string]bool{
+		"p111": symmetry.P111,
+		"p211": symmetry.P211,
+		"p1m1": symmetry.P1m1,
+		"p11g": symmetry.P11g,
+		"p11m": symmetry.P11m,
+		"p2mm": symmetry.P2mm,
+		"p2mg": symmetry.P2mg,
+	}[group.Name]
+}
+
 //AnalyzeForSymmetry scans the formula and returns a list of symmetries.
 func (friezeFormula Formula) AnalyzeForSymmetry() *Symmetry {
 	symmetriesFound := &Symmetry{
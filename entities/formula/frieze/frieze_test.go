@@ -294,3 +294,23 @@ func (suite *FriezeFormulaSuite) TestCreateFriezeFormulaWithJSON(checker *C) {
 	checker.Assert(rosetteFormula.Terms[0].IgnoreComplexConjugate, Equals, false)
 	checker.Assert(rosetteFormula.Terms[1].CoefficientRelationships[0], Equals, coefficient.Relationship(coefficient.MinusMMinusNNegateMultiplierIfOddPowerSum))
 }
+
+func (suite *FriezeFormulaSuite) TestSymmetriesListsFriezeGroupNames(checker *C) {
+	friezeFormula := frieze.Formula{
+		Terms: []*exponential.RosetteFriezeTerm{
+			{
+				Multiplier:             complex(1, 0),
+				PowerN:                 2,
+				PowerM:                 0,
+				IgnoreComplexConjugate: false,
+				CoefficientRelationships: []coefficient.Relationship{coefficient.MinusNMinusM},
+			},
+		},
+	}
+	checker.Assert(friezeFormula.Symmetries(), DeepEquals, []string{"p111", "p211"})
+}
+
+func (suite *FriezeFormulaSuite) TestValidateNeedsTerms(checker *C) {
+	friezeFormula := frieze.Formula{}
+	checker.Assert(friezeFormula.Validate(), ErrorMatches, "frieze formula needs at least one term")
+}
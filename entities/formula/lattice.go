@@ -0,0 +1,186 @@
+package formula
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// LatticeType names the shape of a pair of lattice vectors.
+type LatticeType string
+
+// The 5 shapes a pair of lattice vectors can have.
+const (
+	// ObliqueLattice vectors have no special lengths or angles.
+	ObliqueLattice LatticeType = "oblique"
+	// RectangularLattice vectors are perpendicular.
+	RectangularLattice LatticeType = "rectangular"
+	// RhombicLattice vectors have the same length, also called a centered rectangular lattice.
+	RhombicLattice LatticeType = "rhombic"
+	// SquareLattice vectors have the same length and are perpendicular.
+	SquareLattice LatticeType = "square"
+	// HexagonalLattice vectors have the same length and are 120 degrees apart.
+	HexagonalLattice LatticeType = "hexagonal"
+)
+
+// DefaultLatticeTolerance is how far lengths and angles can be from exact, relative to the vector lengths,
+//   and still count as equal or perpendicular.
+const DefaultLatticeTolerance = 1e-6
+
+// NewLatticeVectorPairFromMarshal converts the marshaled vectors into a LatticeVectorPair.
+//   The whole lattice is rotated counterclockwise by RotationDegrees and then multiplied by Scale.
+func NewLatticeVectorPairFromMarshal(marshalObject LatticeVectorPairMarshal) *LatticeVectorPair {
+	scale := marshalObject.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	transform := cmplx.Rect(scale, marshalObject.RotationDegrees*math.Pi/180)
+	return &LatticeVectorPair{
+		XLatticeVector: complex(marshalObject.XLatticeVector.Real, marshalObject.XLatticeVector.Imaginary) * transform,
+		YLatticeVector: complex(marshalObject.YLatticeVector.Real, marshalObject.YLatticeVector.Imaginary) * transform,
+	}
+}
+
+// Classify returns the shape the two vectors make, as they are given.
+//   Square and hexagonal lattices are also rhombic, and square lattices are also rectangular,
+//   but the most specific shape is returned. BravaisType finds the shape of the lattice itself,
+//   however its vectors are chosen.
+func (lattice LatticeVectorPair) Classify() LatticeType {
+	length1 := cmplx.Abs(lattice.XLatticeVector)
+	length2 := cmplx.Abs(lattice.YLatticeVector)
+	if length1 == 0 || length2 == 0 {
+		return ObliqueLattice
+	}
+	cosine := (real(lattice.XLatticeVector)*real(lattice.YLatticeVector) + imag(lattice.XLatticeVector)*imag(lattice.YLatticeVector)) / (length1 * length2)
+
+	sameLength := math.Abs(length1-length2) <= DefaultLatticeTolerance*math.Max(length1, length2)
+	perpendicular := math.Abs(cosine) <= DefaultLatticeTolerance
+	switch {
+	case sameLength && perpendicular:
+		return SquareLattice
+	case sameLength && math.Abs(cosine+0.5) <= DefaultLatticeTolerance:
+		return HexagonalLattice
+	case perpendicular:
+		return RectangularLattice
+	case sameLength:
+		return RhombicLattice
+	}
+	return ObliqueLattice
+}
+
+// dotProduct treats both numbers as 2D vectors.
+func dotProduct(vector1 complex128, vector2 complex128) float64 {
+	return real(vector1)*real(vector2) + imag(vector1)*imag(vector2)
+}
+
+// determinant returns the signed area of the parallelogram the two vectors make.
+func (lattice LatticeVectorPair) determinant() float64 {
+	return real(lattice.XLatticeVector)*imag(lattice.YLatticeVector) - imag(lattice.XLatticeVector)*real(lattice.YLatticeVector)
+}
+
+// CellArea returns the area of one lattice cell. Every basis of the same lattice has the same area.
+func (lattice LatticeVectorPair) CellArea() float64 {
+	return math.Abs(lattice.determinant())
+}
+
+// GaussReduce returns the shortest pair of vectors that make the same lattice.
+//   The first vector is no longer than the second, and the angle between them is from 90 to 120 degrees,
+//   so a hexagonal lattice comes back 120 degrees apart. Vectors that are already reduced come back unchanged.
+//   The lattice must be valid.
+func (lattice LatticeVectorPair) GaussReduce() LatticeVectorPair {
+	vector1 := lattice.XLatticeVector
+	vector2 := lattice.YLatticeVector
+	for {
+		if dotProduct(vector2, vector2) < dotProduct(vector1, vector1)*(1-DefaultLatticeTolerance) {
+			vector1, vector2 = vector2, vector1
+		}
+		projection := dotProduct(vector1, vector2) / dotProduct(vector1, vector1)
+		// Written this way round so a zero vector, which makes the projection NaN, stops the loop too.
+		if !(math.Abs(projection) > 0.5+DefaultLatticeTolerance) {
+			break
+		}
+		vector2 -= complex(math.Round(projection), 0) * vector1
+	}
+	if dotProduct(vector1, vector2) > 0 {
+		vector2 = -vector2
+	}
+	return LatticeVectorPair{
+		XLatticeVector: vector1,
+		YLatticeVector: vector2,
+	}
+}
+
+// BravaisType returns the shape of the lattice, no matter which vectors describe it.
+//   Lengths and angles within tolerance of each other, relative to the vector lengths, count as equal.
+//   The lattice must be valid.
+func (lattice LatticeVectorPair) BravaisType(tolerance float64) LatticeType {
+	reduced := lattice.GaussReduce()
+	length1 := cmplx.Abs(reduced.XLatticeVector)
+	length2 := cmplx.Abs(reduced.YLatticeVector)
+	dot := dotProduct(reduced.XLatticeVector, reduced.YLatticeVector)
+
+	sameLength := math.Abs(length1-length2) <= tolerance*length2
+	perpendicular := math.Abs(dot) <= tolerance*length1*length2
+	// A centered rectangular lattice may reduce to vectors of different lengths,
+	//   where the second one reaches halfway along the first.
+	halfwayAlong := math.Abs(2*dot+length1*length1) <= 2*tolerance*length1*length2
+	switch {
+	case sameLength && perpendicular:
+		return SquareLattice
+	case sameLength && halfwayAlong:
+		return HexagonalLattice
+	case perpendicular:
+		return RectangularLattice
+	case sameLength || halfwayAlong:
+		return RhombicLattice
+	}
+	return ObliqueLattice
+}
+
+// ConventionalBasis returns the Gauss reduced vectors, except rhombic lattices always get two vectors of the same length.
+//   Each shape of lattice then has vectors in the shape Classify expects for it.
+func (lattice LatticeVectorPair) ConventionalBasis(tolerance float64) LatticeVectorPair {
+	reduced := lattice.GaussReduce()
+	if lattice.BravaisType(tolerance) != RhombicLattice {
+		return reduced
+	}
+	length1 := cmplx.Abs(reduced.XLatticeVector)
+	length2 := cmplx.Abs(reduced.YLatticeVector)
+	if math.Abs(length1-length2) <= tolerance*length2 {
+		return reduced
+	}
+	return LatticeVectorPair{
+		XLatticeVector: reduced.YLatticeVector,
+		YLatticeVector: reduced.YLatticeVector + reduced.XLatticeVector,
+	}
+}
+
+// Reciprocal returns the dual lattice, whose vectors have a dot product of 1 with the matching vector of this lattice
+//   and 0 with the other one. Multiply by 2 pi for the physicist's reciprocal lattice. The lattice must be valid.
+func (lattice LatticeVectorPair) Reciprocal() LatticeVectorPair {
+	determinant := lattice.determinant()
+	return LatticeVectorPair{
+		XLatticeVector: complex(imag(lattice.YLatticeVector)/determinant, -real(lattice.YLatticeVector)/determinant),
+		YLatticeVector: complex(-imag(lattice.XLatticeVector)/determinant, real(lattice.XLatticeVector)/determinant),
+	}
+}
+
+// LatticeCoordinateConverter converts cartesian points into lattice coordinates.
+//   It keeps the inverse of the lattice vectors, so it is only worked out once.
+type LatticeCoordinateConverter struct {
+	reciprocal LatticeVectorPair
+}
+
+// CoordinateConverter returns a converter into this lattice's coordinates. The lattice must be valid.
+func (lattice LatticeVectorPair) CoordinateConverter() *LatticeCoordinateConverter {
+	return &LatticeCoordinateConverter{
+		reciprocal: lattice.Reciprocal(),
+	}
+}
+
+// Convert returns how many of each lattice vector add up to the cartesian point.
+func (converter *LatticeCoordinateConverter) Convert(cartesianPoint complex128) complex128 {
+	return complex(
+		dotProduct(converter.reciprocal.XLatticeVector, cartesianPoint),
+		dotProduct(converter.reciprocal.YLatticeVector, cartesianPoint),
+	)
+}
@@ -2,9 +2,11 @@ package rosette
 
 import (
 	"encoding/json"
+	"errors"
 	"gopkg.in/yaml.v2"
 	"math/cmplx"
 	"wallpaper/entities/formula"
+	"wallpaper/entities/formula/catalog"
 	"wallpaper/entities/formula/coefficient"
 	"wallpaper/entities/formula/exponential"
 	"wallpaper/entities/utility"
@@ -33,6 +35,30 @@ func (r Formula) Calculate(z complex128) *formula.CalculationResultForFormula {
 	return result
 }
 
+// SetUp does nothing, rosette terms are ready to calculate.
+func (r *Formula) SetUp() error {
+	return nil
+}
+
+// Validate returns an error if the formula cannot be calculated.
+func (r *Formula) Validate() error {
+	if len(r.Terms) == 0 {
+		return errors.New("rosette formula needs at least one term")
+	}
+	return nil
+}
+
+// Symmetries lists the rosette groups of the formula, like C3 for 3 fold rotation,
+//   followed by D3 if it also has a mirror.
+func (r *Formula) Symmetries() []string {
+	symmetriesFound := r.AnalyzeForSymmetry()
+	symmetryNames := []string{catalog.Rosette(symmetriesFound.Multifold, false).Name}
+	if symmetriesFound.Mirror {
+		symmetryNames = append(symmetryNames, catalog.Rosette(symmetriesFound.Multifold, true).Name)
+	}
+	return symmetryNames
+}
+
 func (r *Formula) calculateTerm(term *exponential.RosetteFriezeTerm, z complex128) complex128 {
 	sum := complex(0.0,0.0)
 
@@ -56,6 +82,8 @@ func (r *Formula) calculateTerm(term *exponential.RosetteFriezeTerm, z complex12
 // Symmetry notes the kinds of symmetries the rosette formula contains.
 type Symmetry struct {
 	Multifold int
+	// Mirror is true if the pattern is reflected across the real axis, making it dihedral.
+	Mirror bool
 }
 
 // AnalyzeForSymmetry analyzes the formula for symmetries.
@@ -65,9 +93,26 @@ func (r Formula) AnalyzeForSymmetry() *Symmetry {
 	}
 
 	r.calculateMultifoldSymmetry(symmetriesFound)
+	r.calculateMirrorSymmetry(symmetriesFound)
 	return symmetriesFound
 }
 
+// calculateMirrorSymmetry looks for the dihedral group's coefficient relationships in every term.
+func (r Formula) calculateMirrorSymmetry(symmetriesFound *Symmetry) {
+	mirrorRelationships := catalog.Rosette(symmetriesFound.Multifold, true).Relationships
+	symmetriesFound.Mirror = len(r.Terms) > 0
+	for _, term := range r.Terms {
+		if term.IgnoreComplexConjugate {
+			symmetriesFound.Mirror = false
+		}
+		for _, relationship := range mirrorRelationships {
+			if !coefficientRelationshipsIncludes(term.CoefficientRelationships, relationship) {
+				symmetriesFound.Mirror = false
+			}
+		}
+	}
+}
+
 func (r Formula) calculateMultifoldSymmetry(symmetriesFound *Symmetry) {
 	termPowerDifferences := []int{}
 
@@ -95,6 +140,15 @@ func (r Formula) calculateMultifoldSymmetry(symmetriesFound *Symmetry) {
 	}
 }
 
+func coefficientRelationshipsIncludes(relationships []coefficient.Relationship, relationshipToFind coefficient.Relationship) bool {
+	for _, relationship := range relationships {
+		if relationship == relationshipToFind {
+			return true
+		}
+	}
+	return false
+}
+
 // getGreatestCommonDenominator finds the largest integer that divides into
 //   integers a and b, leaving 0 behind.
 func getGreatestCommonDenominator(a, b int) int {
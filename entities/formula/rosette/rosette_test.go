@@ -189,3 +189,23 @@ func (suite *RosetteFormulaTest) TestRosetteFormulaFromJSON(checker *C) {
 	checker.Assert(rosetteFormula.Terms[0].IgnoreComplexConjugate, Equals, false)
 	checker.Assert(rosetteFormula.Terms[1].CoefficientRelationships[0], Equals, coefficient.Relationship(coefficient.MinusMMinusNNegateMultiplierIfOddPowerSum))
 }
+
+func (suite *RosetteFormulaTest) TestSymmetriesNameTheMultifoldRotation(checker *C) {
+	rosetteFormula := rosette.Formula{
+		Terms: []*exponential.RosetteFriezeTerm{
+			{
+				Multiplier:             complex(1, 0),
+				PowerN:                 6,
+				PowerM:                 2,
+			},
+		},
+	}
+	checker.Assert(rosetteFormula.Symmetries(), DeepEquals, []string{"C4"})
+}
+
+func (suite *RosetteFormulaTest) TestValidateNeedsTerms(checker *C) {
+	rosetteFormula := rosette.Formula{
+		Terms: []*exponential.RosetteFriezeTerm{},
+	}
+	checker.Assert(rosetteFormula.Validate(), ErrorMatches, "rosette formula needs at least one term")
+}
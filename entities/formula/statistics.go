@@ -0,0 +1,40 @@
+package formula
+
+import "wallpaper/entities/mathutility"
+
+// ResultStatistics summarizes many calculation results, in total and for each term.
+type ResultStatistics struct {
+	Total  mathutility.Statistics
+	ByTerm []mathutility.Statistics
+}
+
+// NewResultStatistics summarizes the results.
+func NewResultStatistics(results []*CalculationResultForFormula) *ResultStatistics {
+	statistics := &ResultStatistics{}
+	for _, result := range results {
+		statistics.Add(result)
+	}
+	return statistics
+}
+
+// Add includes the result's total and each of its term contributions.
+func (statistics *ResultStatistics) Add(result *CalculationResultForFormula) {
+	statistics.Total.Add(result.Total)
+	for index, contribution := range result.ContributionByTerm {
+		if index >= len(statistics.ByTerm) {
+			statistics.ByTerm = append(statistics.ByTerm, mathutility.Statistics{})
+		}
+		statistics.ByTerm[index].Add(contribution)
+	}
+}
+
+// Merge includes every result summarized by other.
+func (statistics *ResultStatistics) Merge(other *ResultStatistics) {
+	statistics.Total.Merge(&other.Total)
+	for index := range other.ByTerm {
+		if index >= len(statistics.ByTerm) {
+			statistics.ByTerm = append(statistics.ByTerm, mathutility.Statistics{})
+		}
+		statistics.ByTerm[index].Merge(&other.ByTerm[index])
+	}
+}
@@ -0,0 +1,46 @@
+package formula_test
+
+import (
+	. "gopkg.in/check.v1"
+	"math/cmplx"
+	"wallpaper/entities/formula"
+)
+
+type ResultStatisticsSuite struct {
+	results []*formula.CalculationResultForFormula
+}
+
+var _ = Suite(&ResultStatisticsSuite{})
+
+func (suite *ResultStatisticsSuite) SetUpTest(checker *C) {
+	suite.results = []*formula.CalculationResultForFormula{
+		{
+			Total:              complex(3, 1),
+			ContributionByTerm: []complex128{complex(1, 1), complex(2, 0)},
+		},
+		{
+			Total:              cmplx.Inf(),
+			ContributionByTerm: []complex128{complex(-1, 4), cmplx.Inf()},
+		},
+	}
+}
+
+func (suite *ResultStatisticsSuite) TestStatisticsForTotalAndEachTerm(checker *C) {
+	statistics := formula.NewResultStatistics(suite.results)
+
+	checker.Assert(statistics.Total.Count, Equals, 1)
+	checker.Assert(statistics.Total.InfCount, Equals, 1)
+	checker.Assert(statistics.ByTerm, HasLen, 2)
+	checker.Assert(statistics.ByTerm[0].Min, Equals, complex(-1, 1))
+	checker.Assert(statistics.ByTerm[0].Max, Equals, complex(1, 4))
+	checker.Assert(statistics.ByTerm[1].Count, Equals, 1)
+	checker.Assert(statistics.ByTerm[1].InfCount, Equals, 1)
+}
+
+func (suite *ResultStatisticsSuite) TestMergeAddsNewTerms(checker *C) {
+	merged := formula.NewResultStatistics(suite.results[:1])
+	merged.Merge(&formula.ResultStatistics{})
+	merged.Merge(formula.NewResultStatistics(suite.results[1:]))
+
+	checker.Assert(merged, DeepEquals, formula.NewResultStatistics(suite.results))
+}
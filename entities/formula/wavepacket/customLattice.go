@@ -0,0 +1,100 @@
+package wavepacket
+
+import (
+	"fmt"
+	"strings"
+	"wallpaper/entities/formula"
+	"wallpaper/entities/formula/catalog"
+	"wallpaper/entities/formula/coefficient"
+)
+
+// CompatibleSymmetries returns the wallpaper groups a lattice of this shape can hold.
+func CompatibleSymmetries(latticeType formula.LatticeType) []Symmetry {
+	symmetries := []Symmetry{}
+	for _, group := range catalog.WallpapersForLattice(latticeType) {
+		symmetries = append(symmetries, Symmetry(group.Name))
+	}
+	return symmetries
+}
+
+// LatticeTypeForSymmetry returns the most general lattice shape that can hold the symmetry.
+func LatticeTypeForSymmetry(symmetry Symmetry) formula.LatticeType {
+	group, err := catalog.FindWallpaper(string(symmetry))
+	if err != nil {
+		return ""
+	}
+	return group.Lattice()
+}
+
+// lockedRelationshipsForLattice returns the relationships locked into every wave packet of a latticeType formula,
+//   which give its groups their rotations.
+func lockedRelationshipsForLattice(latticeType formula.LatticeType) []coefficient.Relationship {
+	for _, group := range catalog.Wallpapers() {
+		if group.Lattice() == latticeType {
+			return group.LockedRelationships
+		}
+	}
+	return nil
+}
+
+// describeSymmetries joins the symmetry names with commas.
+func describeSymmetries(symmetries []Symmetry) string {
+	names := []string{}
+	for _, symmetry := range symmetries {
+		names = append(names, string(symmetry))
+	}
+	return strings.Join(names, ", ")
+}
+
+// chooseLattice returns the lattice given in the config, or defaultLattice if there was none.
+//   The wave packets of each formula are written for vectors of a certain shape, so a custom lattice must have
+//   one of the allowed shapes. Vectors that describe a lattice of the right shape some other way,
+//   like a hexagonal lattice with vectors 60 degrees apart, are swapped for its conventional vectors.
+//   No shapes allows any lattice.
+func (wallpaperFormula *WallpaperFormula) chooseLattice(formulaName string, defaultLattice formula.LatticeVectorPair, allowedShapes ...formula.LatticeType) (*formula.LatticeVectorPair, error) {
+	if wallpaperFormula == nil || wallpaperFormula.CustomLattice == nil {
+		err := defaultLattice.Validate()
+		if err != nil {
+			return nil, err
+		}
+		return &defaultLattice, nil
+	}
+
+	lattice := *wallpaperFormula.CustomLattice
+	err := lattice.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if len(allowedShapes) == 0 || latticeTypeIsOneOf(lattice.Classify(), allowedShapes) {
+		return &lattice, nil
+	}
+
+	shape := lattice.BravaisType(formula.DefaultLatticeTolerance)
+	if latticeTypeIsOneOf(shape, allowedShapes) {
+		conventionalLattice := lattice.ConventionalBasis(formula.DefaultLatticeTolerance)
+		return &conventionalLattice, nil
+	}
+	return nil, fmt.Errorf(
+		"%s formulas need %s lattice vectors, but the lattice vectors are %s, which only allow %s",
+		formulaName,
+		allowedShapes[0],
+		shape,
+		describeSymmetries(CompatibleSymmetries(shape)),
+	)
+}
+
+// latticeTypeIsOneOf returns true if latticeType is in the list.
+func latticeTypeIsOneOf(latticeType formula.LatticeType, latticeTypes []formula.LatticeType) bool {
+	for _, candidate := range latticeTypes {
+		if latticeType == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// useLattice sets the lattice and works out how to convert points into its coordinates.
+func (wallpaperFormula *WallpaperFormula) useLattice(lattice *formula.LatticeVectorPair) {
+	wallpaperFormula.Lattice = lattice
+	wallpaperFormula.latticeConverter = lattice.CoordinateConverter()
+}
@@ -0,0 +1,229 @@
+package wavepacket_test
+
+import (
+	. "gopkg.in/check.v1"
+	"math"
+	"wallpaper/entities/formula"
+	"wallpaper/entities/formula/wavepacket"
+	"wallpaper/entities/utility"
+)
+
+type CustomLatticeSuite struct {
+	wallpaperFormula *wavepacket.WallpaperFormula
+}
+
+var _ = Suite(&CustomLatticeSuite{})
+
+func (suite *CustomLatticeSuite) SetUpTest(checker *C) {
+	suite.wallpaperFormula = &wavepacket.WallpaperFormula{
+		WavePackets: []*wavepacket.WavePacket{
+			{
+				Terms: []*formula.EisensteinFormulaTerm{
+					{
+						PowerN: 1,
+						PowerM: -2,
+					},
+				},
+				Multiplier: complex(1, 0),
+			},
+		},
+		Multiplier: complex(1, 0),
+	}
+}
+
+func (suite *CustomLatticeSuite) TestSquareFormulaUsesCustomSquareLattice(checker *C) {
+	suite.wallpaperFormula.CustomLattice = &formula.LatticeVectorPair{
+		XLatticeVector: complex(0, 2),
+		YLatticeVector: complex(-2, 0),
+	}
+	squareFormula := &wavepacket.SquareWallpaperFormula{Formula: suite.wallpaperFormula}
+	checker.Assert(squareFormula.Validate(), IsNil)
+	checker.Assert(squareFormula.SetUp(), IsNil)
+	checker.Assert(squareFormula.Formula.Lattice.XLatticeVector, Equals, complex(0, 2))
+	checker.Assert(squareFormula.Formula.Lattice.YLatticeVector, Equals, complex(-2, 0))
+}
+
+func (suite *CustomLatticeSuite) TestSquareFormulaRejectsRectangularLattice(checker *C) {
+	suite.wallpaperFormula.CustomLattice = &formula.LatticeVectorPair{
+		XLatticeVector: complex(1, 0),
+		YLatticeVector: complex(0, 0.5),
+	}
+	squareFormula := &wavepacket.SquareWallpaperFormula{Formula: suite.wallpaperFormula}
+	expectedError := "square formulas need square lattice vectors, but the lattice vectors are rectangular, which only allow p1, p2, pm, pg, pmm, pmg, pgg"
+	checker.Assert(squareFormula.Validate(), ErrorMatches, expectedError)
+	checker.Assert(squareFormula.SetUp(), ErrorMatches, expectedError)
+}
+
+func (suite *CustomLatticeSuite) TestRhombicFormulaAcceptsHexagonalLattice(checker *C) {
+	suite.wallpaperFormula.CustomLattice = &formula.LatticeVectorPair{
+		XLatticeVector: complex(1, 0),
+		YLatticeVector: complex(-0.5, math.Sqrt(3)/2),
+	}
+	rhombicFormula := &wavepacket.RhombicWallpaperFormula{Formula: suite.wallpaperFormula, LatticeHeight: 0.5}
+	checker.Assert(rhombicFormula.Validate(), IsNil)
+	checker.Assert(rhombicFormula.SetUp(), IsNil)
+	checker.Assert(rhombicFormula.Formula.Lattice.YLatticeVector, Equals, complex(-0.5, math.Sqrt(3)/2))
+}
+
+func (suite *CustomLatticeSuite) TestRectangularFormulaRejectsObliqueLattice(checker *C) {
+	suite.wallpaperFormula.CustomLattice = &formula.LatticeVectorPair{
+		XLatticeVector: complex(1, 0),
+		YLatticeVector: complex(0.3, 1.2),
+	}
+	rectangularFormula := &wavepacket.RectangularWallpaperFormula{Formula: suite.wallpaperFormula, LatticeHeight: 0.5}
+	checker.Assert(rectangularFormula.Validate(), ErrorMatches, "rectangular formulas need rectangular lattice vectors, but the lattice vectors are oblique, which only allow p1, p2")
+}
+
+func (suite *CustomLatticeSuite) TestGenericFormulaAcceptsAnyLattice(checker *C) {
+	suite.wallpaperFormula.CustomLattice = &formula.LatticeVectorPair{
+		XLatticeVector: complex(1, 1),
+		YLatticeVector: complex(0.3, 1.2),
+	}
+	genericFormula := &wavepacket.GenericWallpaperFormula{Formula: suite.wallpaperFormula, VectorWidth: 0, VectorHeight: 1}
+	checker.Assert(genericFormula.Validate(), IsNil)
+	checker.Assert(genericFormula.SetUp(), IsNil)
+	checker.Assert(genericFormula.Formula.Lattice.XLatticeVector, Equals, complex(1, 1))
+}
+
+func (suite *CustomLatticeSuite) TestCustomLatticeMustBeValid(checker *C) {
+	suite.wallpaperFormula.CustomLattice = &formula.LatticeVectorPair{
+		XLatticeVector: complex(1, 1),
+		YLatticeVector: complex(-2, -2),
+	}
+	genericFormula := &wavepacket.GenericWallpaperFormula{Formula: suite.wallpaperFormula, VectorWidth: 0, VectorHeight: 1}
+	checker.Assert(genericFormula.Validate(), ErrorMatches, "vectors cannot be collinear: .*")
+}
+
+func (suite *CustomLatticeSuite) TestHexagonalLatticeFromYAMLWithRotationAndScale(checker *C) {
+	yamlByteStream := []byte(`
+lattice:
+  x_lattice_vector:
+    real: 1
+    imaginary: 0
+  y_lattice_vector:
+    real: -0.5
+    imaginary: 0.8660254037844386
+  rotation_degrees: 30
+  scale: 2
+multiplier:
+ real: 1.0
+ imaginary: 0
+wave_packets:
+ -
+   multiplier:
+     real: 1.0
+     imaginary: 0
+   terms:
+     -
+       power_n: 1
+       power_m: -2
+`)
+	hexFormula, err := wavepacket.NewHexagonalWallpaperFormulaFromYAML(yamlByteStream)
+	checker.Assert(err, IsNil)
+	checker.Assert(hexFormula.SetUp(), IsNil)
+	checker.Assert(real(hexFormula.Formula.Lattice.XLatticeVector), utility.NumericallyCloseEnough{}, math.Sqrt(3), 1e-6)
+	checker.Assert(imag(hexFormula.Formula.Lattice.XLatticeVector), utility.NumericallyCloseEnough{}, 1, 1e-6)
+	checker.Assert(hexFormula.Formula.Lattice.Classify(), Equals, formula.HexagonalLattice)
+}
+
+func (suite *CustomLatticeSuite) TestCompatibleSymmetries(checker *C) {
+	checker.Assert(wavepacket.CompatibleSymmetries(formula.ObliqueLattice), DeepEquals, []wavepacket.Symmetry{wavepacket.P1, wavepacket.P2})
+	checker.Assert(wavepacket.CompatibleSymmetries(formula.HexagonalLattice), HasLen, 9)
+	checker.Assert(wavepacket.CompatibleSymmetries(formula.SquareLattice), HasLen, 12)
+}
+
+func (suite *CustomLatticeSuite) TestHexagonalFormulaTurnsSixtyDegreeVectorsAround(checker *C) {
+	suite.wallpaperFormula.CustomLattice = &formula.LatticeVectorPair{
+		XLatticeVector: complex(1, 0),
+		YLatticeVector: complex(0.5, math.Sqrt(3)/2),
+	}
+	hexFormula := &wavepacket.HexagonalWallpaperFormula{Formula: suite.wallpaperFormula}
+	checker.Assert(hexFormula.Validate(), IsNil)
+	checker.Assert(hexFormula.SetUp(), IsNil)
+	checker.Assert(hexFormula.Formula.Lattice.Classify(), Equals, formula.HexagonalLattice)
+}
+
+func (suite *CustomLatticeSuite) TestSquareFormulaReducesSkewedVectors(checker *C) {
+	suite.wallpaperFormula.CustomLattice = &formula.LatticeVectorPair{
+		XLatticeVector: complex(1, 0),
+		YLatticeVector: complex(2, 1),
+	}
+	squareFormula := &wavepacket.SquareWallpaperFormula{Formula: suite.wallpaperFormula}
+	checker.Assert(squareFormula.SetUp(), IsNil)
+	checker.Assert(squareFormula.Formula.Lattice.Classify(), Equals, formula.SquareLattice)
+
+	total := squareFormula.Calculate(complex(0.25, 0.5)).Total
+	for _, latticeVector := range []complex128{complex(1, 0), complex(2, 1), complex(0, 1)} {
+		shiftedTotal := squareFormula.Calculate(complex(0.25, 0.5) + latticeVector).Total
+		checker.Assert(real(shiftedTotal), utility.NumericallyCloseEnough{}, real(total), 1e-6)
+		checker.Assert(imag(shiftedTotal), utility.NumericallyCloseEnough{}, imag(total), 1e-6)
+	}
+}
+
+func (suite *CustomLatticeSuite) TestDesiredSymmetryUsesTheCustomLatticeWithoutAnotherSetUp(checker *C) {
+	yamlByteStream := []byte(`
+desired_symmetry: p6
+lattice:
+  x_lattice_vector:
+    real: 1
+    imaginary: 0
+  y_lattice_vector:
+    real: -0.5
+    imaginary: 0.8660254037844386
+  rotation_degrees: 30
+  scale: 2
+multiplier:
+ real: 1.0
+ imaginary: 0
+wave_packets:
+ -
+   multiplier:
+     real: 1.0
+     imaginary: 0
+   terms:
+     -
+       power_n: 1
+       power_m: -2
+`)
+	hexFormula, err := wavepacket.NewHexagonalWallpaperFormulaFromYAML(yamlByteStream)
+	checker.Assert(err, IsNil)
+
+	customVectors := []complex128{
+		complex(math.Sqrt(3), 1),
+		complex(-math.Sqrt(3), 1),
+	}
+	total := hexFormula.Calculate(complex(0.2, 0.3)).Total
+	for _, latticeVector := range customVectors {
+		shiftedTotal := hexFormula.Calculate(complex(0.2, 0.3) + latticeVector).Total
+		checker.Assert(real(shiftedTotal), utility.NumericallyCloseEnough{}, real(total), 1e-6)
+		checker.Assert(imag(shiftedTotal), utility.NumericallyCloseEnough{}, imag(total), 1e-6)
+	}
+}
+
+func (suite *CustomLatticeSuite) TestDesiredSymmetryReportsAnIncompatibleCustomLattice(checker *C) {
+	yamlByteStream := []byte(`
+desired_symmetry: p4
+lattice:
+  x_lattice_vector:
+    real: 1
+    imaginary: 0
+  y_lattice_vector:
+    real: 0
+    imaginary: 0.5
+multiplier:
+ real: 1.0
+ imaginary: 0
+wave_packets:
+ -
+   multiplier:
+     real: 1.0
+     imaginary: 0
+   terms:
+     -
+       power_n: 1
+       power_m: -2
+`)
+	squareFormula, err := wavepacket.NewSquareWallpaperFormulaFromYAML(yamlByteStream)
+	checker.Assert(err, ErrorMatches, "square formulas need square lattice vectors, but the lattice vectors are rectangular, .*")
+	checker.Assert(squareFormula, IsNil)
+}
@@ -2,11 +2,10 @@ package wavepacket
 
 import (
 	"encoding/json"
+	"fmt"
 	"gopkg.in/yaml.v2"
 	"wallpaper/entities/formula"
 	"wallpaper/entities/utility"
-
-	//"wallpaper/entities/utility"
 )
 
 // GenericWallpaperFormulaMarshalled can be marshalled into a Generic formula
@@ -18,29 +17,37 @@ type GenericWallpaperFormulaMarshalled struct {
 
 // GenericWallpaperFormula helps transform one point to a 2D wallpaper pattern that uses the Generic lattice.
 //   The underlying lattice has 1 vector that is 1 unit horizontal. The second vector uses VectorWidth and VectorHeight.
-//   VectorHeight cannot be 0.
+//   VectorHeight cannot be 0. Generic lattices can only have p1 or p2 symmetry.
 type GenericWallpaperFormula struct {
 	Formula *WallpaperFormula
 	VectorWidth float64
 	VectorHeight float64
+	// DesiredSymmetry is the symmetry asked for when the formula was made, if any.
+	DesiredSymmetry Symmetry
 }
 
-// SetUp will create the Generic GenericWallpaperFormula using the given LatticeHeight.
+// SetUp will create the Generic GenericWallpaperFormula using the given VectorWidth and VectorHeight,
+//   or the lattice vectors from the config, which can have any shape.
 func (Generic *GenericWallpaperFormula) SetUp() error {
-
-	Generic.Formula.Lattice = &formula.LatticeVectorPair{
-		XLatticeVector: complex(1, 0),
-		YLatticeVector: complex(Generic.VectorWidth, Generic.VectorHeight),
-	}
-
-	err := Generic.Formula.Lattice.Validate()
+	lattice, err := Generic.chooseLattice()
 	if err != nil {
 		return err
 	}
-
+	Generic.Formula.useLattice(lattice)
 	return nil
 }
 
+// chooseLattice returns the lattice vectors from the config, or the ones made with VectorWidth and VectorHeight.
+func (Generic *GenericWallpaperFormula) chooseLattice() (*formula.LatticeVectorPair, error) {
+	return Generic.Formula.chooseLattice(
+		"generic",
+		formula.LatticeVectorPair{
+			XLatticeVector: complex(1, 0),
+			YLatticeVector: complex(Generic.VectorWidth, Generic.VectorHeight),
+		},
+	)
+}
+
 //Calculate applies the formula to the complex number z.
 // It modifies the formula's result to track the contribution per term
 // As well as the final numerical result.
@@ -48,28 +55,39 @@ func (Generic *GenericWallpaperFormula) Calculate(z complex128) *formula.Calcula
 	return Generic.Formula.Calculate(z)
 }
 
-//// HasSymmetry returns true if the WavePackets involved form symmetry.
-//func (Generic *GenericWallpaperFormula) HasSymmetry(desiredSymmetry Symmetry) bool {
-//	return HasSymmetry(Generic.Formula.WavePackets, desiredSymmetry, map[Symmetry][]coefficient.Relationship {
-//		Pm: {coefficient.PlusNMinusM},
-//		Pg: {coefficient.PlusNMinusMNegateMultiplierIfOddPowerN},
-//		Pmm: {
-//			coefficient.PlusNMinusM,
-//			coefficient.MinusNMinusM,
-//			coefficient.MinusNPlusM,
-//		},
-//		Pmg: {
-//			coefficient.MinusNMinusM,
-//			coefficient.PlusNMinusMNegateMultiplierIfOddPowerN,
-//			coefficient.MinusNPlusMNegateMultiplierIfOddPowerN,
-//		},
-//		Pgg: {
-//			coefficient.MinusNMinusM,
-//			coefficient.PlusNMinusMNegateMultiplierIfOddPowerSum,
-//			coefficient.MinusNPlusMNegateMultiplierIfOddPowerSum,
-//		},
-//	})
-//}
+// Validate returns an error if the formula cannot be calculated.
+func (Generic *GenericWallpaperFormula) Validate() error {
+	err := validateGenericSymmetry(Generic.DesiredSymmetry)
+	if err != nil {
+		return err
+	}
+	_, err = Generic.chooseLattice()
+	if err != nil {
+		return err
+	}
+	return Generic.Formula.Validate()
+}
+
+// validateGenericSymmetry returns an error unless the symmetry can be made on a generic lattice.
+//   An empty symmetry means none was asked for.
+func validateGenericSymmetry(desiredSymmetry Symmetry) error {
+	if desiredSymmetry == "" || LatticeTypeForSymmetry(desiredSymmetry) == formula.ObliqueLattice {
+		return nil
+	}
+	return fmt.Errorf("generic lattices only have p1 or p2 symmetry, not %s", desiredSymmetry)
+}
+
+// Symmetries lists the names of the symmetries the formula has.
+//   Every wallpaper has p1 symmetry, since it repeats along the lattice.
+func (Generic *GenericWallpaperFormula) Symmetries() []string {
+	return symmetriesFound(latticeSymmetries(formula.ObliqueLattice), Generic.HasSymmetry)
+}
+
+// HasSymmetry returns true if the WavePackets involved form symmetry.
+//   p1 only needs the lattice, so it is always true.
+func (Generic *GenericWallpaperFormula) HasSymmetry(desiredSymmetry Symmetry) bool {
+	return hasLatticeSymmetry(Generic.Formula.WavePackets, desiredSymmetry, formula.ObliqueLattice)
+}
 
 // NewGenericWallpaperFormulaFromJSON reads the data and returns a formula term from it.
 func NewGenericWallpaperFormulaFromJSON(data []byte) (*GenericWallpaperFormula, error) {
@@ -91,57 +109,73 @@ func newGenericWallpaperFormulaFromDatastream(data []byte, unmarshal utility.Unm
 		return nil, unmarshalError
 	}
 
-	return NewGenericWallpaperFormulaFromMarshalObject(GenericFormulaMarshalled), nil
+	return NewGenericWallpaperFormulaFromMarshalObject(GenericFormulaMarshalled)
 }
 
 // NewGenericWallpaperFormulaFromMarshalObject uses a marshalled object to create a new object.
-func NewGenericWallpaperFormulaFromMarshalObject(marshalObject GenericWallpaperFormulaMarshalled) *GenericWallpaperFormula {
+//   If the desired symmetry cannot be made on a generic lattice, the formula is made without it
+//   and Validate reports the problem.
+func NewGenericWallpaperFormulaFromMarshalObject(marshalObject GenericWallpaperFormulaMarshalled) (*GenericWallpaperFormula, error) {
 	formula := NewWallpaperFormulaFromMarshalObject(*marshalObject.Formula)
 
-	//if marshalObject.Formula.DesiredSymmetry != "" {
-	//	wallpaper, err := NewGenericWallpaperFormulaWithSymmetry(
-	//		formula.WavePackets[0].Terms,
-	//		formula.Multiplier,
-	//		marshalObject.LatticeHeight,
-	//		Symmetry(marshalObject.Formula.DesiredSymmetry),
-	//	)
-	//
-	//	if err != nil {
-	//		return nil
-	//	}
-	//	return wallpaper
-	//}
+	if marshalObject.Formula.DesiredSymmetry != "" && validateGenericSymmetry(Symmetry(marshalObject.Formula.DesiredSymmetry)) == nil {
+		return newGenericWallpaperFormulaWithSymmetryAndLattice(
+			formula.WavePackets[0].Terms,
+			formula.Multiplier,
+			marshalObject.VectorWidth,
+			marshalObject.VectorHeight,
+			Symmetry(marshalObject.Formula.DesiredSymmetry),
+			formula.CustomLattice,
+		)
+	}
 
 	return &GenericWallpaperFormula{
 		Formula:       formula,
 		VectorWidth: marshalObject.VectorWidth,
 		VectorHeight: marshalObject.VectorHeight,
-	}
+		DesiredSymmetry: Symmetry(marshalObject.Formula.DesiredSymmetry),
+	}, nil
+}
+
+// NewGenericWallpaperFormulaWithSymmetry will try to create a new GenericWallpaperFormula WavePacket
+//   with the desired Terms, Multiplier and Symmetry. Only p1 and p2 can be made on a generic lattice.
+func NewGenericWallpaperFormulaWithSymmetry(terms []*formula.EisensteinFormulaTerm, wallpaperMultiplier complex128, vectorWidth, vectorHeight float64, desiredSymmetry Symmetry) (*GenericWallpaperFormula, error) {
+	return newGenericWallpaperFormulaWithSymmetryAndLattice(terms, wallpaperMultiplier, vectorWidth, vectorHeight, desiredSymmetry, nil)
 }
 
-//// NewGenericWallpaperFormulaWithSymmetry will try to create a new GenericWallpaperFormula WavePacket
-////   with the desired Terms, Multiplier and Symmetry.
-//func NewGenericWallpaperFormulaWithSymmetry(terms []*formula.EisensteinFormulaTerm, wallpaperMultiplier complex128, latticeHeight float64, desiredSymmetry Symmetry) (*GenericWallpaperFormula, error) {
-//	newWavePackets := []*WavePacket{}
-//	for _, term := range terms {
-//		newWavePackets = append(
-//			newWavePackets,
-//			&WavePacket{
-//				Terms:      []*formula.EisensteinFormulaTerm{term},
-//				Multiplier: wallpaperMultiplier,
-//			},
-//		)
-//
-//		newWavePackets = addNewWavePacketsBasedOnSymmetry(term, wallpaperMultiplier, desiredSymmetry, newWavePackets)
-//	}
-//
-//	newBaseWallpaper := &GenericWallpaperFormula{
-//		Formula: &WallpaperFormula{
-//			WavePackets: newWavePackets,
-//			Multiplier:  wallpaperMultiplier,
-//		},
-//		LatticeHeight: latticeHeight,
-//	}
-//	newBaseWallpaper.SetUp()
-//	return newBaseWallpaper, nil
-//}
+// newGenericWallpaperFormulaWithSymmetryAndLattice uses the custom lattice, if there is one, when it sets up the formula.
+func newGenericWallpaperFormulaWithSymmetryAndLattice(terms []*formula.EisensteinFormulaTerm, wallpaperMultiplier complex128, vectorWidth, vectorHeight float64, desiredSymmetry Symmetry, customLattice *formula.LatticeVectorPair) (*GenericWallpaperFormula, error) {
+	err := validateGenericSymmetry(desiredSymmetry)
+	if err != nil {
+		return nil, err
+	}
+
+	newWavePackets := []*WavePacket{}
+	for _, term := range terms {
+		newWavePackets = append(
+			newWavePackets,
+			&WavePacket{
+				Terms:      []*formula.EisensteinFormulaTerm{term},
+				Multiplier: wallpaperMultiplier,
+			},
+		)
+
+		newWavePackets = addNewWavePacketsBasedOnSymmetry(term, wallpaperMultiplier, desiredSymmetry, newWavePackets)
+	}
+
+	newBaseWallpaper := &GenericWallpaperFormula{
+		Formula: &WallpaperFormula{
+			WavePackets:   newWavePackets,
+			Multiplier:    wallpaperMultiplier,
+			CustomLattice: customLattice,
+		},
+		VectorWidth: vectorWidth,
+		VectorHeight: vectorHeight,
+		DesiredSymmetry: desiredSymmetry,
+	}
+	err = newBaseWallpaper.SetUp()
+	if err != nil {
+		return nil, err
+	}
+	return newBaseWallpaper, nil
+}
@@ -147,556 +147,211 @@ formula:
 	checker.Assert(GenericFormula.Formula.WavePackets[0].Terms[0].PowerM, Equals, -10)
 }
 
-//type GenericWaveSymmetry struct {
-//	baseWavePacketWithEvenPowerNAndOddPowerSum *wavepacket.WavePacket
-//	baseWavePacketWithOddPowerNAndEvenPowerSum *wavepacket.WavePacket
-//}
-//
-//var _ = Suite(&GenericWaveSymmetry{})
-//
-//func (suite *GenericWaveSymmetry) SetUpTest(checker *C) {
-//	suite.baseWavePacketWithEvenPowerNAndOddPowerSum = &wavepacket.WavePacket{
-//		Terms:[]*formula.EisensteinFormulaTerm{
-//			{
-//				PowerN:         8,
-//				PowerM:         -3,
-//			},
-//		},
-//		Multiplier: complex(1, 0),
-//	}
-//
-//	suite.baseWavePacketWithOddPowerNAndEvenPowerSum = &wavepacket.WavePacket{
-//		Terms:[]*formula.EisensteinFormulaTerm{
-//			{
-//				PowerN:         7,
-//				PowerM:         -3,
-//			},
-//		},
-//		Multiplier: complex(1, 0),
-//	}
-//}
-//
-//func (suite *GenericWaveSymmetry) TestNoSymmetryFound(checker *C) {
-//	GenericFormula := wavepacket.GenericWallpaperFormula{
-//		Formula: &wavepacket.WallpaperFormula{
-//			WavePackets: []*wavepacket.WavePacket{
-//				suite.baseWavePacketWithEvenPowerNAndOddPowerSum,
-//			},
-//			Multiplier: complex(1, 0),
-//		},
-//	}
-//
-//	GenericFormula.SetUp()
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pm), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pg), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmm), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmg), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pgg), Equals, false)
-//}
-//
-//func (suite *GenericWaveSymmetry) TestPm(checker *C) {
-//	GenericFormula := wavepacket.GenericWallpaperFormula{
-//		Formula: &wavepacket.WallpaperFormula{
-//			WavePackets: []*wavepacket.WavePacket{
-//				suite.baseWavePacketWithEvenPowerNAndOddPowerSum,
-//				{
-//					Terms: []*formula.EisensteinFormulaTerm{
-//						{
-//							PowerN:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerN,
-//							PowerM:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerM * -1,
-//						},
-//					},
-//					Multiplier: suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Multiplier,
-//				},
-//			},
-//			Multiplier: complex(1, 0),
-//		},
-//	}
-//
-//	GenericFormula.SetUp()
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pm), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pg), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmm), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmg), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pgg), Equals, false)
-//}
-//
-//func (suite *GenericWaveSymmetry) TestPg(checker *C) {
-//	GenericFormulaWithEvenPowerN := wavepacket.GenericWallpaperFormula{
-//		Formula: &wavepacket.WallpaperFormula{
-//			WavePackets: []*wavepacket.WavePacket{
-//				suite.baseWavePacketWithEvenPowerNAndOddPowerSum,
-//				{
-//					Terms: []*formula.EisensteinFormulaTerm{
-//						{
-//							PowerN:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerN,
-//							PowerM:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerM * -1,
-//						},
-//					},
-//					Multiplier: suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Multiplier,
-//				},
-//			},
-//			Multiplier: complex(1,0),
-//		},
-//	}
-//
-//	GenericFormulaWithEvenPowerN.SetUp()
-//	checker.Assert(GenericFormulaWithEvenPowerN.HasSymmetry(wavepacket.Pm), Equals, true)
-//	checker.Assert(GenericFormulaWithEvenPowerN.HasSymmetry(wavepacket.Pg), Equals, true)
-//	checker.Assert(GenericFormulaWithEvenPowerN.HasSymmetry(wavepacket.Pmm), Equals, false)
-//	checker.Assert(GenericFormulaWithEvenPowerN.HasSymmetry(wavepacket.Pmg), Equals, false)
-//	checker.Assert(GenericFormulaWithEvenPowerN.HasSymmetry(wavepacket.Pgg), Equals, false)
-//
-//	GenericFormulaWithOddPowerN := wavepacket.GenericWallpaperFormula{
-//		Formula: &wavepacket.WallpaperFormula{
-//			WavePackets: []*wavepacket.WavePacket{
-//				suite.baseWavePacketWithOddPowerNAndEvenPowerSum,
-//				{
-//					Terms: []*formula.EisensteinFormulaTerm{
-//						{
-//							PowerN:         suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Terms[0].PowerN,
-//							PowerM:         suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Terms[0].PowerM * -1,
-//						},
-//					},
-//					Multiplier: 	suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Multiplier * -1,
-//				},
-//			},
-//			Multiplier: 	complex(1, 0),
-//		},
-//	}
-//
-//	GenericFormulaWithOddPowerN.SetUp()
-//	checker.Assert(GenericFormulaWithOddPowerN.HasSymmetry(wavepacket.Pm), Equals, false)
-//	checker.Assert(GenericFormulaWithOddPowerN.HasSymmetry(wavepacket.Pg), Equals, true)
-//	checker.Assert(GenericFormulaWithOddPowerN.HasSymmetry(wavepacket.Pmm), Equals, false)
-//	checker.Assert(GenericFormulaWithOddPowerN.HasSymmetry(wavepacket.Pmg), Equals, false)
-//	checker.Assert(GenericFormulaWithOddPowerN.HasSymmetry(wavepacket.Pgg), Equals, false)
-//}
-//
-//func (suite *GenericWaveSymmetry) TestPmmAndPmgWithEvenPowerN(checker *C) {
-//	GenericFormula := wavepacket.GenericWallpaperFormula{
-//		Formula: &wavepacket.WallpaperFormula{
-//			WavePackets: []*wavepacket.WavePacket{
-//				suite.baseWavePacketWithEvenPowerNAndOddPowerSum,
-//				{
-//					Terms:[]*formula.EisensteinFormulaTerm{
-//						{
-//							PowerN:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerN * -1,
-//							PowerM:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerM * -1,
-//						},
-//					},
-//					Multiplier: complex(1, 0),
-//				},
-//				{
-//					Terms:[]*formula.EisensteinFormulaTerm{
-//						{
-//							PowerN:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerN,
-//							PowerM:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerM * -1,
-//						},
-//					},
-//					Multiplier: complex(1, 0),
-//				},
-//				{
-//					Terms:[]*formula.EisensteinFormulaTerm{
-//						{
-//							PowerN:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerN * -1,
-//							PowerM:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerM,
-//						},
-//					},
-//					Multiplier: complex(1, 0),
-//				},
-//			},
-//			Multiplier: complex(1, 0),
-//		},
-//	}
-//
-//	GenericFormula.SetUp()
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pm), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pg), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmm), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmg), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pgg), Equals, false)
-//}
-//
-//func (suite *GenericWaveSymmetry) TestPmgWithOddPowerN(checker *C) {
-//	GenericFormulaWithOddPowerN := wavepacket.GenericWallpaperFormula{
-//		Formula: &wavepacket.WallpaperFormula{
-//			WavePackets: []*wavepacket.WavePacket{
-//				suite.baseWavePacketWithOddPowerNAndEvenPowerSum,
-//				{
-//					Terms:[]*formula.EisensteinFormulaTerm{
-//						{
-//							PowerN:         suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Terms[0].PowerN * -1,
-//							PowerM:         suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Terms[0].PowerM * -1,
-//						},
-//					},
-//					Multiplier: suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Multiplier,
-//				},
-//				{
-//					Terms:[]*formula.EisensteinFormulaTerm{
-//						{
-//							PowerN:         suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Terms[0].PowerN,
-//							PowerM:         suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Terms[0].PowerM * -1,
-//						},
-//					},
-//					Multiplier: suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Multiplier * -1,
-//				},
-//				{
-//					Terms:[]*formula.EisensteinFormulaTerm{
-//						{
-//							PowerN:         suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Terms[0].PowerN * -1,
-//							PowerM:         suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Terms[0].PowerM,
-//						},
-//					},
-//					Multiplier: suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Multiplier * -1,
-//				},
-//			},
-//			Multiplier: complex(1, 0),
-//		},
-//	}
-//
-//	GenericFormulaWithOddPowerN.SetUp()
-//	checker.Assert(GenericFormulaWithOddPowerN.HasSymmetry(wavepacket.Pm), Equals, false)
-//	checker.Assert(GenericFormulaWithOddPowerN.HasSymmetry(wavepacket.Pg), Equals, true)
-//	checker.Assert(GenericFormulaWithOddPowerN.HasSymmetry(wavepacket.Pmm), Equals, false)
-//	checker.Assert(GenericFormulaWithOddPowerN.HasSymmetry(wavepacket.Pmg), Equals, true)
-//	checker.Assert(GenericFormulaWithOddPowerN.HasSymmetry(wavepacket.Pgg), Equals, false)
-//}
-//
-//func (suite *GenericWaveSymmetry) TestPgg(checker *C) {
-//	GenericFormulaWithOddPowerSum := wavepacket.GenericWallpaperFormula{
-//		Formula: &wavepacket.WallpaperFormula{
-//			WavePackets: []*wavepacket.WavePacket{
-//				suite.baseWavePacketWithEvenPowerNAndOddPowerSum,
-//				{
-//					Terms:[]*formula.EisensteinFormulaTerm{
-//						{
-//							PowerN:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerN * -1,
-//							PowerM:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerM * -1,
-//						},
-//					},
-//					Multiplier: suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Multiplier,
-//				},
-//				{
-//					Terms:[]*formula.EisensteinFormulaTerm{
-//						{
-//							PowerN:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerN,
-//							PowerM:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerM * -1,
-//						},
-//					},
-//					Multiplier: -1 * suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Multiplier,
-//				},
-//				{
-//					Terms:[]*formula.EisensteinFormulaTerm{
-//						{
-//							PowerN:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerN * -1,
-//							PowerM:         suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Terms[0].PowerM,
-//						},
-//					},
-//					Multiplier: -1 * suite.baseWavePacketWithEvenPowerNAndOddPowerSum.Multiplier,
-//				},
-//			},
-//			Multiplier: complex(1, 0),
-//		},
-//	}
-//
-//	GenericFormulaWithOddPowerSum.SetUp()
-//	checker.Assert(GenericFormulaWithOddPowerSum.HasSymmetry(wavepacket.Pm), Equals, false)
-//	checker.Assert(GenericFormulaWithOddPowerSum.HasSymmetry(wavepacket.Pg), Equals, false)
-//	checker.Assert(GenericFormulaWithOddPowerSum.HasSymmetry(wavepacket.Pmm), Equals, false)
-//	checker.Assert(GenericFormulaWithOddPowerSum.HasSymmetry(wavepacket.Pmg), Equals, false)
-//	checker.Assert(GenericFormulaWithOddPowerSum.HasSymmetry(wavepacket.Pgg), Equals, true)
-//
-//	GenericFormulaWithEvenPowerSum := wavepacket.GenericWallpaperFormula{
-//		Formula: &wavepacket.WallpaperFormula{
-//			WavePackets: []*wavepacket.WavePacket{
-//				suite.baseWavePacketWithOddPowerNAndEvenPowerSum,
-//				{
-//					Terms:[]*formula.EisensteinFormulaTerm{
-//						{
-//							PowerN:         suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Terms[0].PowerN * -1,
-//							PowerM:         suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Terms[0].PowerM * -1,
-//						},
-//					},
-//					Multiplier: suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Multiplier,
-//				},
-//				{
-//					Terms:[]*formula.EisensteinFormulaTerm{
-//						{
-//							PowerN:         suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Terms[0].PowerN,
-//							PowerM:         suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Terms[0].PowerM * -1,
-//						},
-//					},
-//					Multiplier: suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Multiplier,
-//				},
-//				{
-//					Terms:[]*formula.EisensteinFormulaTerm{
-//						{
-//							PowerN:         suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Terms[0].PowerN * -1,
-//							PowerM:         suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Terms[0].PowerM,
-//						},
-//					},
-//					Multiplier: suite.baseWavePacketWithOddPowerNAndEvenPowerSum.Multiplier,
-//				},
-//			},
-//			Multiplier: complex(1, 0),
-//		},
-//	}
-//
-//	GenericFormulaWithEvenPowerSum.SetUp()
-//	checker.Assert(GenericFormulaWithEvenPowerSum.HasSymmetry(wavepacket.Pm), Equals, true)
-//	checker.Assert(GenericFormulaWithEvenPowerSum.HasSymmetry(wavepacket.Pg), Equals, false)
-//	checker.Assert(GenericFormulaWithEvenPowerSum.HasSymmetry(wavepacket.Pmm), Equals, true)
-//	checker.Assert(GenericFormulaWithEvenPowerSum.HasSymmetry(wavepacket.Pmg), Equals, false)
-//	checker.Assert(GenericFormulaWithEvenPowerSum.HasSymmetry(wavepacket.Pgg), Equals, true)
-//}
-
-//type GenericCreatedWithDesiredSymmetry struct {
-//	eisensteinTermWithEvenPowerNAndOddPowerSum []*formula.EisensteinFormulaTerm
-//	eisensteinTermWithOddPowerNAndEvenPowerSum []*formula.EisensteinFormulaTerm
-//	wallpaperMultiplier complex128
-//	LatticeHeight float64
-//}
-//
-//var _ = Suite(&GenericCreatedWithDesiredSymmetry{})
-//
-//func (suite *GenericCreatedWithDesiredSymmetry) SetUpTest (checker *C) {
-//	suite.eisensteinTermWithEvenPowerNAndOddPowerSum = []*formula.EisensteinFormulaTerm{
-//		{
-//			PowerN:         8,
-//			PowerM:         -3,
-//		},
-//	}
-//	suite.eisensteinTermWithOddPowerNAndEvenPowerSum = []*formula.EisensteinFormulaTerm{
-//			{
-//				PowerN:         7,
-//				PowerM:         -3,
-//			},
-//		}
-//
-//	suite.wallpaperMultiplier = complex(1, 0)
-//	suite.LatticeHeight = 2.0
-//}
-//
-//func (suite *GenericCreatedWithDesiredSymmetry) TestCreateWallpaperWithPm(checker *C) {
-//	GenericFormula, err := wavepacket.NewGenericWallpaperFormulaWithSymmetry(
-//		suite.eisensteinTermWithOddPowerNAndEvenPowerSum,
-//		suite.wallpaperMultiplier,
-//		suite.LatticeHeight,
-//		wavepacket.Pm,
-//	)
-//
-//	checker.Assert(err, IsNil)
-//	checker.Assert(GenericFormula.Formula.WavePackets, HasLen, 2)
-//	checker.Assert(GenericFormula.Formula.WavePackets[0].Terms, HasLen, 1)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerN, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerN)
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerM, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerM * -1)
-//
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pm), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pg), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmm), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmg), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pgg), Equals, false)
-//}
-//
-//func (suite *GenericCreatedWithDesiredSymmetry) TestCreateWallpaperWithPg(checker *C) {
-//	GenericFormula, err := wavepacket.NewGenericWallpaperFormulaWithSymmetry(
-//		suite.eisensteinTermWithOddPowerNAndEvenPowerSum,
-//		suite.wallpaperMultiplier,
-//		suite.LatticeHeight,
-//		wavepacket.Pg,
-//	)
-//
-//	checker.Assert(err, IsNil)
-//	checker.Assert(GenericFormula.Formula.WavePackets, HasLen, 2)
-//	checker.Assert(GenericFormula.Formula.WavePackets[0].Terms, HasLen, 1)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Multiplier, Equals, suite.wallpaperMultiplier * -1)
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerN, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerN)
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerM, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerM * -1)
-//
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pm), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pg), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmm), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmg), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pgg), Equals, false)
-//}
-//
-//func (suite *GenericCreatedWithDesiredSymmetry) TestCreateWallpaperWithPmm(checker *C) {
-//	GenericFormula, err := wavepacket.NewGenericWallpaperFormulaWithSymmetry(
-//		suite.eisensteinTermWithOddPowerNAndEvenPowerSum,
-//		suite.wallpaperMultiplier,
-//		suite.LatticeHeight,
-//		wavepacket.Pmm,
-//	)
-//
-//	checker.Assert(err, IsNil)
-//	checker.Assert(GenericFormula.Formula.WavePackets, HasLen, 4)
-//	checker.Assert(GenericFormula.Formula.WavePackets[0].Terms, HasLen, 1)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Multiplier, Equals, suite.wallpaperMultiplier)
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerN, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerN * -1)
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerM, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerM * -1)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets[2].Multiplier, Equals, suite.wallpaperMultiplier)
-//	checker.Assert(GenericFormula.Formula.WavePackets[2].Terms[0].PowerN, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerN * -1)
-//	checker.Assert(GenericFormula.Formula.WavePackets[2].Terms[0].PowerM, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerM)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets[3].Multiplier, Equals, suite.wallpaperMultiplier)
-//	checker.Assert(GenericFormula.Formula.WavePackets[3].Terms[0].PowerN, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerN)
-//	checker.Assert(GenericFormula.Formula.WavePackets[3].Terms[0].PowerM, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerM * -1)
-//
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pm), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pg), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmm), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmg), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pgg), Equals, true)
-//}
-//
-//func (suite *GenericCreatedWithDesiredSymmetry) TestCreateWallpaperWithPmg(checker *C) {
-//	GenericFormula, err := wavepacket.NewGenericWallpaperFormulaWithSymmetry(
-//		suite.eisensteinTermWithOddPowerNAndEvenPowerSum,
-//		suite.wallpaperMultiplier,
-//		suite.LatticeHeight,
-//		wavepacket.Pmg,
-//	)
-//
-//	checker.Assert(err, IsNil)
-//	checker.Assert(GenericFormula.Formula.WavePackets, HasLen, 4)
-//	checker.Assert(GenericFormula.Formula.WavePackets[0].Terms, HasLen, 1)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Multiplier, Equals, suite.wallpaperMultiplier)
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerN, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerN * -1)
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerM, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerM * -1)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets[2].Multiplier, Equals, suite.wallpaperMultiplier * -1)
-//	checker.Assert(GenericFormula.Formula.WavePackets[2].Terms[0].PowerN, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerN * -1)
-//	checker.Assert(GenericFormula.Formula.WavePackets[2].Terms[0].PowerM, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerM)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets[3].Multiplier, Equals, suite.wallpaperMultiplier * -1)
-//	checker.Assert(GenericFormula.Formula.WavePackets[3].Terms[0].PowerN, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerN)
-//	checker.Assert(GenericFormula.Formula.WavePackets[3].Terms[0].PowerM, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerM * -1)
-//
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pm), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pg), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmm), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmg), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pgg), Equals, false)
-//}
-//
-//func (suite *GenericCreatedWithDesiredSymmetry) TestCreateWallpaperWithPgg(checker *C) {
-//	GenericFormula, err := wavepacket.NewGenericWallpaperFormulaWithSymmetry(
-//		suite.eisensteinTermWithOddPowerNAndEvenPowerSum,
-//		suite.wallpaperMultiplier,
-//		suite.LatticeHeight,
-//		wavepacket.Pgg,
-//	)
-//
-//	checker.Assert(err, IsNil)
-//	checker.Assert(GenericFormula.Formula.WavePackets, HasLen, 4)
-//	checker.Assert(GenericFormula.Formula.WavePackets[0].Terms, HasLen, 1)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Multiplier, Equals, suite.wallpaperMultiplier)
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerN, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerN * -1)
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerM, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerM * -1)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets[2].Multiplier, Equals, suite.wallpaperMultiplier)
-//	checker.Assert(GenericFormula.Formula.WavePackets[2].Terms[0].PowerN, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerN * -1)
-//	checker.Assert(GenericFormula.Formula.WavePackets[2].Terms[0].PowerM, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerM)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets[3].Multiplier, Equals, suite.wallpaperMultiplier)
-//	checker.Assert(GenericFormula.Formula.WavePackets[3].Terms[0].PowerN, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerN)
-//	checker.Assert(GenericFormula.Formula.WavePackets[3].Terms[0].PowerM, Equals, suite.eisensteinTermWithOddPowerNAndEvenPowerSum[0].PowerM * -1)
-//
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pm), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pg), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmm), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmg), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pgg), Equals, true)
-//}
-//
-//func (suite *GenericCreatedWithDesiredSymmetry) TestCreateDesiredSymmetryWithYAML(checker *C) {
-//	yamlByteStream := []byte(`
-//lattice_height: 0.3
-//formula:
-// desired_symmetry: pmm
-// multiplier:
-//   real: -1.0
-//   imaginary: 2e-2
-// wave_packets:
-//   -
-//     multiplier:
-//       real: -1.0
-//       imaginary: 2e-2
-//     terms:
-//       -
-//         power_n: 12
-//         power_m: -9
-//`)
-//
-//	GenericFormula, err := wavepacket.NewGenericWallpaperFormulaFromYAML(yamlByteStream)
-//	checker.Assert(err, IsNil)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets, HasLen, 4)
-//	checker.Assert(GenericFormula.Formula.WavePackets[0].Terms[0].PowerM, Equals, -9)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerN, Equals, -12)
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerM, Equals, 9)
-//
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pm), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pg), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmm), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmg), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pgg), Equals, false)
-//}
-//
-//func (suite *GenericCreatedWithDesiredSymmetry) TestCreateDesiredSymmetryWithJSON(checker *C) {
-//	jsonByteStream := []byte(`{
-//				"lattice_height": 0.3,
-//				"formula": {
-//					"desired_symmetry": "pmg",
-//					"multiplier": {
-//						"real": 1.0,
-//						"imaginary": 0
-//					},
-//					"wave_packets": [
-//						{
-//							"multiplier": {
-//								"real": 1.0,
-//								"imaginary": 0
-//							},
-//							"terms": [
-//								{
-//									"power_n": 1,
-//									"power_m": -2
-//								}
-//							]
-//						}
-//					]
-//				}
-//			}`)
-//	GenericFormula, err := wavepacket.NewGenericWallpaperFormulaFromJSON(jsonByteStream)
-//	checker.Assert(err, IsNil)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets, HasLen, 4)
-//	checker.Assert(GenericFormula.Formula.WavePackets[0].Terms[0].PowerM, Equals, -2)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Multiplier, Equals, complex(1, 0))
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerN, Equals, -1)
-//	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerM, Equals, 2)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets[2].Multiplier, Equals, complex(-1, 0))
-//	checker.Assert(GenericFormula.Formula.WavePackets[2].Terms[0].PowerN, Equals, -1)
-//	checker.Assert(GenericFormula.Formula.WavePackets[2].Terms[0].PowerM, Equals, -2)
-//
-//	checker.Assert(GenericFormula.Formula.WavePackets[3].Multiplier, Equals, complex(-1, 0))
-//	checker.Assert(GenericFormula.Formula.WavePackets[3].Terms[0].PowerN, Equals, 1)
-//	checker.Assert(GenericFormula.Formula.WavePackets[3].Terms[0].PowerM, Equals, 2)
-//
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pm), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pg), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmm), Equals, false)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pmg), Equals, true)
-//	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pgg), Equals, true)
-//}
\ No newline at end of file
+type GenericWaveSymmetry struct {
+	baseWavePacket *wavepacket.WavePacket
+}
+
+var _ = Suite(&GenericWaveSymmetry{})
+
+func (suite *GenericWaveSymmetry) SetUpTest(checker *C) {
+	suite.baseWavePacket = &wavepacket.WavePacket{
+		Terms:[]*formula.EisensteinFormulaTerm{
+			{
+				PowerN: 8,
+				PowerM: -3,
+			},
+		},
+		Multiplier: complex(1, 0),
+	}
+}
+
+func (suite *GenericWaveSymmetry) TestOnlyP1Found(checker *C) {
+	GenericFormula := &wavepacket.GenericWallpaperFormula{
+		Formula: &wavepacket.WallpaperFormula{
+			WavePackets: []*wavepacket.WavePacket{
+				suite.baseWavePacket,
+			},
+			Multiplier: complex(1, 0),
+		},
+		VectorWidth: 0.3,
+		VectorHeight: 1.2,
+	}
+	checker.Assert(GenericFormula.HasSymmetry(wavepacket.P1), Equals, true)
+	checker.Assert(GenericFormula.HasSymmetry(wavepacket.P2), Equals, false)
+	checker.Assert(GenericFormula.Symmetries(), DeepEquals, []string{"p1"})
+}
+
+func (suite *GenericWaveSymmetry) TestP2(checker *C) {
+	GenericFormula := &wavepacket.GenericWallpaperFormula{
+		Formula: &wavepacket.WallpaperFormula{
+			WavePackets: []*wavepacket.WavePacket{
+				suite.baseWavePacket,
+				{
+					Terms: []*formula.EisensteinFormulaTerm{
+						{
+							PowerN: -8,
+							PowerM: 3,
+						},
+					},
+					Multiplier: suite.baseWavePacket.Multiplier,
+				},
+			},
+			Multiplier: complex(1, 0),
+		},
+		VectorWidth: 0.3,
+		VectorHeight: 1.2,
+	}
+	checker.Assert(GenericFormula.HasSymmetry(wavepacket.P1), Equals, true)
+	checker.Assert(GenericFormula.HasSymmetry(wavepacket.P2), Equals, true)
+	checker.Assert(GenericFormula.HasSymmetry(wavepacket.Pm), Equals, false)
+	checker.Assert(GenericFormula.Symmetries(), DeepEquals, []string{"p1", "p2"})
+}
+
+func (suite *GenericWaveSymmetry) TestP2NeedsTheSameMultiplier(checker *C) {
+	GenericFormula := &wavepacket.GenericWallpaperFormula{
+		Formula: &wavepacket.WallpaperFormula{
+			WavePackets: []*wavepacket.WavePacket{
+				suite.baseWavePacket,
+				{
+					Terms: []*formula.EisensteinFormulaTerm{
+						{
+							PowerN: -8,
+							PowerM: 3,
+						},
+					},
+					Multiplier: suite.baseWavePacket.Multiplier * -1,
+				},
+			},
+			Multiplier: complex(1, 0),
+		},
+		VectorWidth: 0.3,
+		VectorHeight: 1.2,
+	}
+	checker.Assert(GenericFormula.HasSymmetry(wavepacket.P2), Equals, false)
+}
+
+type GenericCreatedWithDesiredSymmetry struct {
+	eisensteinTerm []*formula.EisensteinFormulaTerm
+	wallpaperMultiplier complex128
+}
+
+var _ = Suite(&GenericCreatedWithDesiredSymmetry{})
+
+func (suite *GenericCreatedWithDesiredSymmetry) SetUpTest (checker *C) {
+	suite.eisensteinTerm = []*formula.EisensteinFormulaTerm{
+		{
+			PowerN:         7,
+			PowerM:         -3,
+		},
+	}
+	suite.wallpaperMultiplier = complex(1, 0)
+}
+
+func (suite *GenericCreatedWithDesiredSymmetry) TestCreateWallpaperWithP1(checker *C) {
+	GenericFormula, err := wavepacket.NewGenericWallpaperFormulaWithSymmetry(
+		suite.eisensteinTerm,
+		suite.wallpaperMultiplier,
+		0.3,
+		1.2,
+		wavepacket.P1,
+	)
+
+	checker.Assert(err, IsNil)
+	checker.Assert(GenericFormula.Formula.WavePackets, HasLen, 1)
+	checker.Assert(GenericFormula.Validate(), IsNil)
+	checker.Assert(GenericFormula.Symmetries(), DeepEquals, []string{"p1"})
+}
+
+func (suite *GenericCreatedWithDesiredSymmetry) TestCreateWallpaperWithP2(checker *C) {
+	GenericFormula, err := wavepacket.NewGenericWallpaperFormulaWithSymmetry(
+		suite.eisensteinTerm,
+		suite.wallpaperMultiplier,
+		0.3,
+		1.2,
+		wavepacket.P2,
+	)
+
+	checker.Assert(err, IsNil)
+	checker.Assert(GenericFormula.Formula.WavePackets, HasLen, 2)
+	checker.Assert(GenericFormula.Formula.WavePackets[1].Multiplier, Equals, suite.wallpaperMultiplier)
+	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerN, Equals, -7)
+	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerM, Equals, 3)
+	checker.Assert(GenericFormula.Symmetries(), DeepEquals, []string{"p1", "p2"})
+
+	z := complex(0.37, -1.4)
+	rotated := GenericFormula.Calculate(z * -1).Total
+	checker.Assert(real(rotated), utility.NumericallyCloseEnough{}, real(GenericFormula.Calculate(z).Total), 1e-6)
+	checker.Assert(imag(rotated), utility.NumericallyCloseEnough{}, imag(GenericFormula.Calculate(z).Total), 1e-6)
+}
+
+func (suite *GenericCreatedWithDesiredSymmetry) TestOtherSymmetriesAreRejected(checker *C) {
+	_, err := wavepacket.NewGenericWallpaperFormulaWithSymmetry(
+		suite.eisensteinTerm,
+		suite.wallpaperMultiplier,
+		0.3,
+		1.2,
+		wavepacket.Pmm,
+	)
+	checker.Assert(err, ErrorMatches, "generic lattices only have p1 or p2 symmetry, not pmm")
+}
+
+func (suite *GenericCreatedWithDesiredSymmetry) TestCreateDesiredSymmetryWithYAML(checker *C) {
+	yamlByteStream := []byte(`
+vector_width: 0.3
+vector_height: 1.2
+formula:
+  desired_symmetry: p2
+  multiplier:
+    real: -1.0
+    imaginary: 2e-2
+  wave_packets:
+    -
+      multiplier:
+        real: -1.0
+        imaginary: 2e-2
+      terms:
+        -
+          power_n: 12
+          power_m: -9
+`)
+
+	GenericFormula, err := wavepacket.NewGenericWallpaperFormulaFromYAML(yamlByteStream)
+	checker.Assert(err, IsNil)
+	checker.Assert(GenericFormula.Validate(), IsNil)
+
+	checker.Assert(GenericFormula.Formula.WavePackets, HasLen, 2)
+	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerN, Equals, -12)
+	checker.Assert(GenericFormula.Formula.WavePackets[1].Terms[0].PowerM, Equals, 9)
+	checker.Assert(GenericFormula.HasSymmetry(wavepacket.P2), Equals, true)
+}
+
+func (suite *GenericCreatedWithDesiredSymmetry) TestUnsupportedSymmetryFailsValidation(checker *C) {
+	jsonByteStream := []byte(`{
+				"vector_width": 0.3,
+				"vector_height": 1.2,
+				"formula": {
+					"desired_symmetry": "p4",
+					"multiplier": {
+						"real": 1.0,
+						"imaginary": 0
+					},
+					"wave_packets": [
+						{
+							"multiplier": {
+								"real": 1.0,
+								"imaginary": 0
+							},
+							"terms": [
+								{
+									"power_n": 1,
+									"power_m": -2
+								}
+							]
+						}
+					]
+				}
+			}`)
+	GenericFormula, err := wavepacket.NewGenericWallpaperFormulaFromJSON(jsonByteStream)
+	checker.Assert(err, IsNil)
+	checker.Assert(GenericFormula.Validate(), ErrorMatches, "generic lattices only have p1 or p2 symmetry, not p4")
+}
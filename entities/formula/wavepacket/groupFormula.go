@@ -0,0 +1,154 @@
+package wavepacket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"wallpaper/entities/formula"
+	"wallpaper/entities/utility"
+)
+
+// GroupWallpaperFormulaMarshalled describes a wallpaper by its symmetry group.
+//   The group chooses the lattice and the wave packets each term adds, so the terms only need writing once.
+type GroupWallpaperFormulaMarshalled struct {
+	// Group is the wallpaper group in Hermann–Mauguin or orbifold notation, like p4g or 4*2.
+	Group string `json:"group" yaml:"group"`
+	Terms []*formula.EisensteinFormulaTermMarshal `json:"terms" yaml:"terms"`
+	Multiplier utility.ComplexNumberForMarshal `json:"multiplier" yaml:"multiplier"`
+	// LatticeHeight shapes the lattice of rectangular and rhombic groups.
+	LatticeHeight float64 `json:"lattice_height,omitempty" yaml:"lattice_height,omitempty"`
+	// VectorWidth and VectorHeight shape the lattice of p1 and p2.
+	VectorWidth float64 `json:"vector_width,omitempty" yaml:"vector_width,omitempty"`
+	VectorHeight float64 `json:"vector_height,omitempty" yaml:"vector_height,omitempty"`
+	// Lattice gives the lattice vectors instead of the shape parameters.
+	Lattice *formula.LatticeVectorPairMarshal `json:"lattice,omitempty" yaml:"lattice,omitempty"`
+}
+
+// NewWallpaperFormulaForGroupFromJSON reads the data and returns a formula from it.
+func NewWallpaperFormulaForGroupFromJSON(data []byte) (formula.Formula, error) {
+	return newWallpaperFormulaForGroupFromDatastream(data, json.Unmarshal)
+}
+
+// NewWallpaperFormulaForGroupFromYAML reads the data and returns a formula from it.
+func NewWallpaperFormulaForGroupFromYAML(data []byte) (formula.Formula, error) {
+	return newWallpaperFormulaForGroupFromDatastream(data, yaml.Unmarshal)
+}
+
+//newWallpaperFormulaForGroupFromDatastream consumes a given bytestream and tries to create a new object from it.
+func newWallpaperFormulaForGroupFromDatastream(data []byte, unmarshal utility.UnmarshalFunc) (formula.Formula, error) {
+	var unmarshalError error
+	var groupFormulaMarshalled GroupWallpaperFormulaMarshalled
+	unmarshalError = unmarshal(data, &groupFormulaMarshalled)
+
+	if unmarshalError != nil {
+		return nil, unmarshalError
+	}
+
+	return NewWallpaperFormulaForGroupFromMarshalObject(groupFormulaMarshalled)
+}
+
+// NewWallpaperFormulaForGroupFromMarshalObject creates the square, hexagonal, rectangular, rhombic or generic formula
+//   the group needs, with the wave packets that give it the group's symmetry.
+//   It returns an error if the group is unknown or the lattice shape cannot hold the group.
+func NewWallpaperFormulaForGroupFromMarshalObject(marshalObject GroupWallpaperFormulaMarshalled) (formula.Formula, error) {
+	if marshalObject.Group == "" {
+		return nil, errors.New("wallpaper formula needs a group, like p4g or 4*2")
+	}
+	desiredSymmetry, err := ParseSymmetry(marshalObject.Group)
+	if err != nil {
+		return nil, err
+	}
+	if len(marshalObject.Terms) == 0 {
+		return nil, fmt.Errorf("%s wallpaper formula needs at least one term", desiredSymmetry)
+	}
+
+	var customLattice *formula.LatticeVectorPair
+	if marshalObject.Lattice != nil {
+		customLattice = formula.NewLatticeVectorPairFromMarshal(*marshalObject.Lattice)
+	}
+	latticeType := LatticeTypeForSymmetry(desiredSymmetry)
+	err = checkLatticeShapeForGroup(marshalObject, desiredSymmetry, latticeType, customLattice)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := []*formula.EisensteinFormulaTerm{}
+	for _, term := range marshalObject.Terms {
+		terms = append(terms, formula.NewEisensteinFormulaTermFromMarshalObject(*term))
+	}
+	multiplier := complex(marshalObject.Multiplier.Real, marshalObject.Multiplier.Imaginary)
+
+	var wallpaperFormula formula.Formula
+	switch latticeType {
+	case formula.SquareLattice:
+		wallpaperFormula, err = newSquareWallpaperFormulaWithSymmetryAndLattice(terms, multiplier, desiredSymmetry, customLattice)
+	case formula.HexagonalLattice:
+		wallpaperFormula, err = newHexagonalWallpaperFormulaWithSymmetryAndLattice(terms, multiplier, desiredSymmetry, customLattice)
+	case formula.RectangularLattice:
+		wallpaperFormula, err = newRectangularWallpaperFormulaWithSymmetryAndLattice(terms, multiplier, marshalObject.LatticeHeight, desiredSymmetry, customLattice)
+	case formula.RhombicLattice:
+		wallpaperFormula, err = newRhombicWallpaperFormulaWithSymmetryAndLattice(terms, multiplier, marshalObject.LatticeHeight, desiredSymmetry, customLattice)
+	default:
+		wallpaperFormula, err = newGenericWallpaperFormulaWithSymmetryAndLattice(terms, multiplier, marshalObject.VectorWidth, marshalObject.VectorHeight, desiredSymmetry, customLattice)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return wallpaperFormula, nil
+}
+
+// checkLatticeShapeForGroup returns an error if the shape parameters do not belong to the group's lattice,
+//   or the lattice vectors cannot hold the group.
+func checkLatticeShapeForGroup(marshalObject GroupWallpaperFormulaMarshalled, desiredSymmetry Symmetry, latticeType formula.LatticeType, customLattice *formula.LatticeVectorPair) error {
+	usesVectorSize := marshalObject.VectorWidth != 0 || marshalObject.VectorHeight != 0
+	if usesVectorSize && latticeType != formula.ObliqueLattice {
+		return fmt.Errorf("%s has a %s lattice, vector_width and vector_height only shape p1 and p2 lattices", desiredSymmetry, latticeType)
+	}
+
+	if customLattice != nil {
+		if marshalObject.LatticeHeight != 0 || usesVectorSize {
+			return fmt.Errorf("%s lattice was given twice, use lattice vectors or the shape parameters but not both", desiredSymmetry)
+		}
+		err := customLattice.Validate()
+		if err != nil {
+			return err
+		}
+		shape := customLattice.BravaisType(formula.DefaultLatticeTolerance)
+		for _, compatibleSymmetry := range CompatibleSymmetries(shape) {
+			if compatibleSymmetry == desiredSymmetry {
+				return nil
+			}
+		}
+		return fmt.Errorf(
+			"%s needs a %s lattice, but the lattice vectors make a %s lattice, which only allows %s",
+			desiredSymmetry,
+			latticeType,
+			shape,
+			describeSymmetries(CompatibleSymmetries(shape)),
+		)
+	}
+
+	switch latticeType {
+	case formula.SquareLattice:
+		if marshalObject.LatticeHeight != 0 && marshalObject.LatticeHeight != 1 {
+			return fmt.Errorf("%s needs a square lattice, but lattice_height %g makes an unequal rectangle, leave it out or use 1", desiredSymmetry, marshalObject.LatticeHeight)
+		}
+	case formula.HexagonalLattice:
+		if marshalObject.LatticeHeight != 0 {
+			return fmt.Errorf("%s needs a hexagonal lattice, which has no lattice_height, leave it out", desiredSymmetry)
+		}
+	case formula.RectangularLattice, formula.RhombicLattice:
+		if marshalObject.LatticeHeight == 0 {
+			return fmt.Errorf("%s needs a %s lattice, set lattice_height or give the lattice vectors", desiredSymmetry, latticeType)
+		}
+	default:
+		if marshalObject.LatticeHeight != 0 {
+			return fmt.Errorf("%s has an oblique lattice, use vector_width and vector_height instead of lattice_height", desiredSymmetry)
+		}
+		if marshalObject.VectorHeight == 0 {
+			return fmt.Errorf("%s needs an oblique lattice, set vector_height or give the lattice vectors", desiredSymmetry)
+		}
+	}
+	return nil
+}
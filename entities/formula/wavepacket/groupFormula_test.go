@@ -0,0 +1,172 @@
+package wavepacket_test
+
+import (
+	. "gopkg.in/check.v1"
+	"math"
+	"wallpaper/entities/formula"
+	"wallpaper/entities/formula/wavepacket"
+	"wallpaper/entities/utility"
+)
+
+type SymmetryNotationSuite struct {}
+
+var _ = Suite(&SymmetryNotationSuite{})
+
+func (suite *SymmetryNotationSuite) TestParseHermannMauguinAndOrbifoldNames(checker *C) {
+	names := map[string]wavepacket.Symmetry {
+		"p4g": wavepacket.P4g,
+		"4*2": wavepacket.P4g,
+		"p4gm": wavepacket.P4g,
+		"P6M": wavepacket.P6m,
+		"*632": wavepacket.P6m,
+		"o": wavepacket.P1,
+		"22x": wavepacket.Pgg,
+		"22×": wavepacket.Pgg,
+		"*×": wavepacket.Cm,
+		"c2mm": wavepacket.Cmm,
+		"3*3": wavepacket.P31m,
+	}
+	for name, expectedSymmetry := range names {
+		symmetry, err := wavepacket.ParseSymmetry(name)
+		checker.Assert(err, IsNil)
+		checker.Assert(symmetry, Equals, expectedSymmetry, Commentf("%s", name))
+	}
+}
+
+func (suite *SymmetryNotationSuite) TestUnknownNamesListTheKnownOnes(checker *C) {
+	_, err := wavepacket.ParseSymmetry("p5")
+	checker.Assert(err, ErrorMatches, `unknown wallpaper group "p5", expected one of p1 \(o\), p2 \(2222\), .*p6m \(\*632\)`)
+}
+
+type GroupWallpaperFormulaSuite struct {
+	marshalObject wavepacket.GroupWallpaperFormulaMarshalled
+}
+
+var _ = Suite(&GroupWallpaperFormulaSuite{})
+
+func (suite *GroupWallpaperFormulaSuite) SetUpTest(checker *C) {
+	suite.marshalObject = wavepacket.GroupWallpaperFormulaMarshalled{
+		Terms: []*formula.EisensteinFormulaTermMarshal{
+			{
+				PowerN: 1,
+				PowerM: -2,
+			},
+		},
+		Multiplier: utility.ComplexNumberForMarshal{Real: 1},
+	}
+}
+
+func (suite *GroupWallpaperFormulaSuite) TestGroupChoosesTheFormula(checker *C) {
+	suite.marshalObject.Group = "*442"
+	squareFormula, err := wavepacket.NewWallpaperFormulaForGroupFromMarshalObject(suite.marshalObject)
+	checker.Assert(err, IsNil)
+	checker.Assert(squareFormula, FitsTypeOf, &wavepacket.SquareWallpaperFormula{})
+	checker.Assert(squareFormula.Validate(), IsNil)
+	checker.Assert(squareFormula.SetUp(), IsNil)
+	checker.Assert(squareFormula.Symmetries(), DeepEquals, []string{"p4", "p4m"})
+
+	suite.marshalObject.Group = "pgg"
+	suite.marshalObject.LatticeHeight = 0.5
+	rectangularFormula, err := wavepacket.NewWallpaperFormulaForGroupFromMarshalObject(suite.marshalObject)
+	checker.Assert(err, IsNil)
+	checker.Assert(rectangularFormula, FitsTypeOf, &wavepacket.RectangularWallpaperFormula{})
+	checker.Assert(rectangularFormula.(*wavepacket.RectangularWallpaperFormula).HasSymmetry(wavepacket.Pgg), Equals, true)
+
+	suite.marshalObject.Group = "cmm"
+	rhombicFormula, err := wavepacket.NewWallpaperFormulaForGroupFromMarshalObject(suite.marshalObject)
+	checker.Assert(err, IsNil)
+	checker.Assert(rhombicFormula, FitsTypeOf, &wavepacket.RhombicWallpaperFormula{})
+}
+
+func (suite *GroupWallpaperFormulaSuite) TestObliqueGroupsUseTheGenericFormula(checker *C) {
+	suite.marshalObject.Group = "2222"
+	suite.marshalObject.VectorWidth = 0.3
+	suite.marshalObject.VectorHeight = 1.2
+	genericFormula, err := wavepacket.NewWallpaperFormulaForGroupFromMarshalObject(suite.marshalObject)
+	checker.Assert(err, IsNil)
+	checker.Assert(genericFormula, FitsTypeOf, &wavepacket.GenericWallpaperFormula{})
+	checker.Assert(genericFormula.Symmetries(), DeepEquals, []string{"p1", "p2"})
+}
+
+func (suite *GroupWallpaperFormulaSuite) TestLatticeVectorsMustFitTheGroup(checker *C) {
+	suite.marshalObject.Group = "p6"
+	suite.marshalObject.Lattice = &formula.LatticeVectorPairMarshal{
+		XLatticeVector: utility.ComplexNumberForMarshal{Real: 1},
+		YLatticeVector: utility.ComplexNumberForMarshal{Real: 0.5, Imaginary: math.Sqrt(3)/2},
+	}
+	hexFormula, err := wavepacket.NewWallpaperFormulaForGroupFromMarshalObject(suite.marshalObject)
+	checker.Assert(err, IsNil)
+	checker.Assert(hexFormula.SetUp(), IsNil)
+	checker.Assert(hexFormula.(*wavepacket.HexagonalWallpaperFormula).Formula.Lattice.Classify(), Equals, formula.HexagonalLattice)
+
+	suite.marshalObject.Group = "p4"
+	_, err = wavepacket.NewWallpaperFormulaForGroupFromMarshalObject(suite.marshalObject)
+	checker.Assert(err, ErrorMatches, "p4 needs a square lattice, but the lattice vectors make a hexagonal lattice, which only allows p1, p2, cm, cmm, p3, p3m1, p31m, p6, p6m")
+}
+
+func (suite *GroupWallpaperFormulaSuite) TestGroupUsesTheLatticeVectorsWithoutAnotherSetUp(checker *C) {
+	suite.marshalObject.Group = "p4"
+	suite.marshalObject.Lattice = &formula.LatticeVectorPairMarshal{
+		XLatticeVector: utility.ComplexNumberForMarshal{Real: 0, Imaginary: 1.5},
+		YLatticeVector: utility.ComplexNumberForMarshal{Real: -1.5, Imaginary: 0},
+	}
+	squareFormula, err := wavepacket.NewWallpaperFormulaForGroupFromMarshalObject(suite.marshalObject)
+	checker.Assert(err, IsNil)
+
+	total := squareFormula.Calculate(complex(0.2, 0.3)).Total
+	for _, latticeVector := range []complex128{complex(0, 1.5), complex(-1.5, 0)} {
+		shiftedTotal := squareFormula.Calculate(complex(0.2, 0.3) + latticeVector).Total
+		checker.Assert(real(shiftedTotal), utility.NumericallyCloseEnough{}, real(total), 1e-6)
+		checker.Assert(imag(shiftedTotal), utility.NumericallyCloseEnough{}, imag(total), 1e-6)
+	}
+}
+
+func (suite *GroupWallpaperFormulaSuite) TestIncompatibleShapesAreErrors(checker *C) {
+	examples := []struct {
+		group string
+		latticeHeight float64
+		vectorWidth float64
+		vectorHeight float64
+		expectedError string
+	}{
+		{"p4", 0.5, 0, 0, "p4 needs a square lattice, but lattice_height 0.5 makes an unequal rectangle, leave it out or use 1"},
+		{"p6m", 0.5, 0, 0, "p6m needs a hexagonal lattice, which has no lattice_height, leave it out"},
+		{"pmm", 0, 0, 0, "pmm needs a rectangular lattice, set lattice_height or give the lattice vectors"},
+		{"cm", 0, 0.3, 1.2, "cm has a rhombic lattice, vector_width and vector_height only shape p1 and p2 lattices"},
+		{"p1", 0, 0.3, 0, "p1 needs an oblique lattice, set vector_height or give the lattice vectors"},
+	}
+	for _, example := range examples {
+		suite.marshalObject.Group = example.group
+		suite.marshalObject.LatticeHeight = example.latticeHeight
+		suite.marshalObject.VectorWidth = example.vectorWidth
+		suite.marshalObject.VectorHeight = example.vectorHeight
+		_, err := wavepacket.NewWallpaperFormulaForGroupFromMarshalObject(suite.marshalObject)
+		checker.Assert(err, ErrorMatches, example.expectedError)
+	}
+}
+
+func (suite *GroupWallpaperFormulaSuite) TestGroupAndTermsAreNeeded(checker *C) {
+	_, err := wavepacket.NewWallpaperFormulaForGroupFromMarshalObject(suite.marshalObject)
+	checker.Assert(err, ErrorMatches, "wallpaper formula needs a group, like p4g or 4\\*2")
+
+	suite.marshalObject.Group = "p3"
+	suite.marshalObject.Terms = nil
+	_, err = wavepacket.NewWallpaperFormulaForGroupFromMarshalObject(suite.marshalObject)
+	checker.Assert(err, ErrorMatches, "p3 wallpaper formula needs at least one term")
+}
+
+func (suite *GroupWallpaperFormulaSuite) TestCreateFromYAML(checker *C) {
+	yamlByteStream := []byte(`
+group: "3*3"
+multiplier:
+  real: 1
+  imaginary: 0
+terms:
+  -
+    power_n: 1
+    power_m: -2
+`)
+	hexFormula, err := wavepacket.NewWallpaperFormulaForGroupFromYAML(yamlByteStream)
+	checker.Assert(err, IsNil)
+	checker.Assert(hexFormula.(*wavepacket.HexagonalWallpaperFormula).HasSymmetry(wavepacket.P31m), Equals, true)
+}
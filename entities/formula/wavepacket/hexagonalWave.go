@@ -5,7 +5,6 @@ import (
 	"gopkg.in/yaml.v2"
 	"math"
 	"wallpaper/entities/formula"
-	"wallpaper/entities/formula/coefficient"
 	"wallpaper/entities/utility"
 )
 
@@ -15,18 +14,30 @@ type HexagonalWallpaperFormula struct {
 	Formula *WallpaperFormula
 }
 
+// hexagonalLattice is the lattice used unless the config gives its own hexagonal lattice vectors.
+var hexagonalLattice = formula.LatticeVectorPair{
+	XLatticeVector: complex(1, 0),
+	YLatticeVector: complex(-0.5, math.Sqrt(3.0)/2.0),
+}
+
 // SetUp initializes all of the needed wallpaper terms.
-func (hexWaveFormula *HexagonalWallpaperFormula) SetUp() {
-	hexWaveFormula.Formula.Lattice = &formula.LatticeVectorPair{
-		XLatticeVector: complex(1, 0),
-		YLatticeVector: complex(-0.5, math.Sqrt(3.0)/2.0),
+func (hexWaveFormula *HexagonalWallpaperFormula) SetUp() error {
+	lattice, err := hexWaveFormula.Formula.chooseLattice("hexagonal", hexagonalLattice, formula.HexagonalLattice)
+	if err != nil {
+		return err
 	}
-	hexWaveFormula.Formula.SetUp(
-		[]coefficient.Relationship{
-			coefficient.PlusMMinusSumNAndM,
-			coefficient.MinusSumNAndMPlusN,
-		},
-	)
+	hexWaveFormula.Formula.useLattice(lattice)
+	hexWaveFormula.Formula.SetUp(lockedRelationshipsForLattice(formula.HexagonalLattice))
+	return nil
+}
+
+// Validate returns an error if the formula cannot be calculated.
+func (hexWaveFormula *HexagonalWallpaperFormula) Validate() error {
+	_, err := hexWaveFormula.Formula.chooseLattice("hexagonal", hexagonalLattice, formula.HexagonalLattice)
+	if err != nil {
+		return err
+	}
+	return hexWaveFormula.Formula.Validate()
 }
 
 // Calculate applies the formula to the complex number z.
@@ -38,20 +49,12 @@ func (hexWaveFormula *HexagonalWallpaperFormula) Calculate(z complex128) *formul
 
 // HasSymmetry returns true if the WavePackets involved form symmetry.
 func (hexWaveFormula *HexagonalWallpaperFormula) HasSymmetry(desiredSymmetry Symmetry) bool {
-	if desiredSymmetry == P3 {
-		return true
-	}
+	return hasLatticeSymmetry(hexWaveFormula.Formula.WavePackets, desiredSymmetry, formula.HexagonalLattice)
+}
 
-	return HasSymmetry(hexWaveFormula.Formula.WavePackets, desiredSymmetry, map[Symmetry][]coefficient.Relationship {
-		P31m: {coefficient.PlusMPlusN},
-		P3m1: {coefficient.MinusMMinusN},
-		P6: {coefficient.MinusNMinusM},
-		P6m: {
-			coefficient.MinusNMinusM,
-			coefficient.MinusMMinusN,
-			coefficient.PlusMPlusN,
-		},
-	})
+// Symmetries lists the names of the symmetries the formula has.
+func (hexWaveFormula *HexagonalWallpaperFormula) Symmetries() []string {
+	return symmetriesFound(latticeSymmetries(formula.HexagonalLattice), hexWaveFormula.HasSymmetry)
 }
 
 // NewHexagonalWallpaperFormulaFromJSON reads the data and returns a formula term from it.
@@ -74,34 +77,40 @@ func newHexagonalWallpaperFormulaFromDatastream(data []byte, unmarshal utility.U
 		return nil, unmarshalError
 	}
 
-	return NewHexagonalWallpaperFormulaFromMarshalObject(hexFormulaMarshalled), nil
+	return NewHexagonalWallpaperFormulaFromMarshalObject(hexFormulaMarshalled)
 }
 
 // NewHexagonalWallpaperFormulaFromMarshalObject uses a marshalled object to create a new object.
-func NewHexagonalWallpaperFormulaFromMarshalObject(marshalObject WallpaperFormulaMarshalled) *HexagonalWallpaperFormula {
+func NewHexagonalWallpaperFormulaFromMarshalObject(marshalObject WallpaperFormulaMarshalled) (*HexagonalWallpaperFormula, error) {
 	formula := NewWallpaperFormulaFromMarshalObject(marshalObject)
 
 	if marshalObject.DesiredSymmetry != "" {
-		wallpaper, err := NewHexagonalWallpaperFormulaWithSymmetry(
+		wallpaper, err := newHexagonalWallpaperFormulaWithSymmetryAndLattice(
 			formula.WavePackets[0].Terms,
 			formula.Multiplier,
 			Symmetry(marshalObject.DesiredSymmetry),
+			formula.CustomLattice,
 		)
 
 		if err != nil {
-			return nil
+			return nil, err
 		}
-		return wallpaper
+		return wallpaper, nil
 	}
 
 	return &HexagonalWallpaperFormula{
 		Formula:       formula,
-	}
+	}, nil
 }
 
 // NewHexagonalWallpaperFormulaWithSymmetry will try to create a new Hexagonal Wallpaper
 //   with the desired Terms, Multiplier and Symmetry.
 func NewHexagonalWallpaperFormulaWithSymmetry(terms []*formula.EisensteinFormulaTerm, wallpaperMultiplier complex128, desiredSymmetry Symmetry) (*HexagonalWallpaperFormula, error) {
+	return newHexagonalWallpaperFormulaWithSymmetryAndLattice(terms, wallpaperMultiplier, desiredSymmetry, nil)
+}
+
+// newHexagonalWallpaperFormulaWithSymmetryAndLattice uses the custom lattice, if there is one, when it sets up the formula.
+func newHexagonalWallpaperFormulaWithSymmetryAndLattice(terms []*formula.EisensteinFormulaTerm, wallpaperMultiplier complex128, desiredSymmetry Symmetry, customLattice *formula.LatticeVectorPair) (*HexagonalWallpaperFormula, error) {
 	newWavePackets := []*WavePacket{}
 	for _, term := range terms {
 		newWavePackets = append(
@@ -117,10 +126,14 @@ func NewHexagonalWallpaperFormulaWithSymmetry(terms []*formula.EisensteinFormula
 
 	newBaseWallpaper := &HexagonalWallpaperFormula{
 		Formula: &WallpaperFormula{
-			WavePackets: newWavePackets,
-			Multiplier:  wallpaperMultiplier,
+			WavePackets:   newWavePackets,
+			Multiplier:    wallpaperMultiplier,
+			CustomLattice: customLattice,
 		},
 	}
-	newBaseWallpaper.SetUp()
+	err := newBaseWallpaper.SetUp()
+	if err != nil {
+		return nil, err
+	}
 	return newBaseWallpaper, nil
 }
@@ -390,6 +390,7 @@ func (suite *HexagonalCreatedWithDesiredSymmetry) TestCreateWallpaperWithP6m(che
 
 	checker.Assert(hexFormula.HasSymmetry(wavepacket.P3), Equals, true)
 	checker.Assert(hexFormula.HasSymmetry(wavepacket.P6m), Equals, true)
+	checker.Assert(hexFormula.Symmetries(), DeepEquals, []string{"p3", "p3m1", "p31m", "p6", "p6m"})
 }
 
 func (suite *HexagonalCreatedWithDesiredSymmetry) TestCreateDesiredSymmetryFromJSON(checker *C) {
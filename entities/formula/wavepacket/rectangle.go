@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"gopkg.in/yaml.v2"
 	"wallpaper/entities/formula"
-	"wallpaper/entities/formula/coefficient"
 	"wallpaper/entities/utility"
 
 	//"wallpaper/entities/utility"
@@ -25,22 +24,30 @@ type RectangularWallpaperFormula struct {
 	LatticeHeight float64
 }
 
-// SetUp will create the Rectangular RectangularWallpaperFormula using the given LatticeHeight.
+// SetUp will create the Rectangular RectangularWallpaperFormula using the given LatticeHeight,
+//   or the lattice vectors from the config if they are perpendicular.
 func (Rectangular *RectangularWallpaperFormula) SetUp() error {
-
-	Rectangular.Formula.Lattice = &formula.LatticeVectorPair{
-		XLatticeVector: complex(1, 0),
-		YLatticeVector: complex(0, Rectangular.LatticeHeight),
-	}
-
-	err := Rectangular.Formula.Lattice.Validate()
+	lattice, err := Rectangular.chooseLattice()
 	if err != nil {
 		return err
 	}
-
+	Rectangular.Formula.useLattice(lattice)
 	return nil
 }
 
+// chooseLattice returns the lattice vectors from the config, or the rectangle made with LatticeHeight.
+func (Rectangular *RectangularWallpaperFormula) chooseLattice() (*formula.LatticeVectorPair, error) {
+	return Rectangular.Formula.chooseLattice(
+		"rectangular",
+		formula.LatticeVectorPair{
+			XLatticeVector: complex(1, 0),
+			YLatticeVector: complex(0, Rectangular.LatticeHeight),
+		},
+		formula.RectangularLattice,
+		formula.SquareLattice,
+	)
+}
+
 // Calculate applies the formula to the complex number z.
 //  It modifies the formula's result to track the contribution per term
 //  As well as the final numerical result.
@@ -48,27 +55,23 @@ func (Rectangular *RectangularWallpaperFormula) Calculate(z complex128) *formula
 	return Rectangular.Formula.Calculate(z)
 }
 
+// Validate returns an error if the formula cannot be calculated.
+func (Rectangular *RectangularWallpaperFormula) Validate() error {
+	_, err := Rectangular.chooseLattice()
+	if err != nil {
+		return err
+	}
+	return Rectangular.Formula.Validate()
+}
+
+// Symmetries lists the names of the symmetries the formula has.
+func (Rectangular *RectangularWallpaperFormula) Symmetries() []string {
+	return symmetriesFound(latticeSymmetries(formula.RectangularLattice), Rectangular.HasSymmetry)
+}
+
 // HasSymmetry returns true if the WavePackets involved form symmetry.
 func (Rectangular *RectangularWallpaperFormula) HasSymmetry(desiredSymmetry Symmetry) bool {
-	return HasSymmetry(Rectangular.Formula.WavePackets, desiredSymmetry, map[Symmetry][]coefficient.Relationship {
-		Pm: {coefficient.PlusNMinusM},
-		Pg: {coefficient.PlusNMinusMNegateMultiplierIfOddPowerN},
-		Pmm: {
-			coefficient.PlusNMinusM,
-			coefficient.MinusNMinusM,
-			coefficient.MinusNPlusM,
-		},
-		Pmg: {
-			coefficient.MinusNMinusM,
-			coefficient.PlusNMinusMNegateMultiplierIfOddPowerN,
-			coefficient.MinusNPlusMNegateMultiplierIfOddPowerN,
-		},
-		Pgg: {
-			coefficient.MinusNMinusM,
-			coefficient.PlusNMinusMNegateMultiplierIfOddPowerSum,
-			coefficient.MinusNPlusMNegateMultiplierIfOddPowerSum,
-		},
-	})
+	return hasLatticeSymmetry(Rectangular.Formula.WavePackets, desiredSymmetry, formula.RectangularLattice)
 }
 
 // NewRectangularWallpaperFormulaFromJSON reads the data and returns a formula term from it.
@@ -91,36 +94,42 @@ func newRectangularWallpaperFormulaFromDatastream(data []byte, unmarshal utility
 		return nil, unmarshalError
 	}
 
-	return NewRectangularWallpaperFormulaFromMarshalObject(RectangularFormulaMarshalled), nil
+	return NewRectangularWallpaperFormulaFromMarshalObject(RectangularFormulaMarshalled)
 }
 
 // NewRectangularWallpaperFormulaFromMarshalObject uses a marshalled object to create a new object.
-func NewRectangularWallpaperFormulaFromMarshalObject(marshalObject RectangularWallpaperFormulaMarshalled) *RectangularWallpaperFormula {
+func NewRectangularWallpaperFormulaFromMarshalObject(marshalObject RectangularWallpaperFormulaMarshalled) (*RectangularWallpaperFormula, error) {
 	formula := NewWallpaperFormulaFromMarshalObject(*marshalObject.Formula)
 
 	if marshalObject.Formula.DesiredSymmetry != "" {
-		wallpaper, err := NewRectangularWallpaperFormulaWithSymmetry(
+		wallpaper, err := newRectangularWallpaperFormulaWithSymmetryAndLattice(
 			formula.WavePackets[0].Terms,
 			formula.Multiplier,
 			marshalObject.LatticeHeight,
 			Symmetry(marshalObject.Formula.DesiredSymmetry),
+			formula.CustomLattice,
 		)
 
 		if err != nil {
-			return nil
+			return nil, err
 		}
-		return wallpaper
+		return wallpaper, nil
 	}
 
 	return &RectangularWallpaperFormula{
 		Formula:       formula,
 		LatticeHeight: marshalObject.LatticeHeight,
-	}
+	}, nil
 }
 
 // NewRectangularWallpaperFormulaWithSymmetry will try to create a new RectangularWallpaperFormula WavePacket
 //   with the desired Terms, Multiplier and Symmetry.
 func NewRectangularWallpaperFormulaWithSymmetry(terms []*formula.EisensteinFormulaTerm, wallpaperMultiplier complex128, latticeHeight float64, desiredSymmetry Symmetry) (*RectangularWallpaperFormula, error) {
+	return newRectangularWallpaperFormulaWithSymmetryAndLattice(terms, wallpaperMultiplier, latticeHeight, desiredSymmetry, nil)
+}
+
+// newRectangularWallpaperFormulaWithSymmetryAndLattice uses the custom lattice, if there is one, when it sets up the formula.
+func newRectangularWallpaperFormulaWithSymmetryAndLattice(terms []*formula.EisensteinFormulaTerm, wallpaperMultiplier complex128, latticeHeight float64, desiredSymmetry Symmetry, customLattice *formula.LatticeVectorPair) (*RectangularWallpaperFormula, error) {
 	newWavePackets := []*WavePacket{}
 	for _, term := range terms {
 		newWavePackets = append(
@@ -136,11 +145,15 @@ func NewRectangularWallpaperFormulaWithSymmetry(terms []*formula.EisensteinFormu
 
 	newBaseWallpaper := &RectangularWallpaperFormula{
 		Formula: &WallpaperFormula{
-			WavePackets: newWavePackets,
-			Multiplier:  wallpaperMultiplier,
+			WavePackets:   newWavePackets,
+			Multiplier:    wallpaperMultiplier,
+			CustomLattice: customLattice,
 		},
 		LatticeHeight: latticeHeight,
 	}
-	newBaseWallpaper.SetUp()
+	err := newBaseWallpaper.SetUp()
+	if err != nil {
+		return nil, err
+	}
 	return newBaseWallpaper, nil
 }
@@ -65,6 +65,23 @@ func (suite *RectangularWallpaper) TestRaiseErrorIfHeightIsZero (checker *C) {
 	err := RectangularFormulaWithNoHeight.SetUp()
 
 	checker.Assert(err, ErrorMatches, "lattice vectors cannot be \\(0,0\\)")
+	checker.Assert(RectangularFormulaWithNoHeight.Validate(), ErrorMatches, "lattice vectors cannot be \\(0,0\\)")
+}
+
+func (suite *RectangularWallpaper) TestValidateNeedsWavePackets (checker *C) {
+	RectangularFormulaWithNoPackets := &wavepacket.RectangularWallpaperFormula{
+		Formula: &wavepacket.WallpaperFormula{
+			WavePackets: []*wavepacket.WavePacket{},
+			Multiplier: complex(1, 0),
+		},
+		LatticeHeight: 0.5,
+	}
+	checker.Assert(RectangularFormulaWithNoPackets.Validate(), ErrorMatches, "wallpaper formula needs at least one wave packet")
+}
+
+func (suite *RectangularWallpaper) TestValidFormula (checker *C) {
+	checker.Assert(suite.RectangularFormula.Validate(), IsNil)
+	checker.Assert(suite.RectangularFormula.Symmetries(), HasLen, 0)
 }
 
 func (suite *RectangularWallpaper) TestSetupLocksPairs (checker *C) {
@@ -21,18 +21,14 @@ type RhombicWallpaperFormula struct {
 	LatticeHeight float64
 }
 
-// SetUp will create the rhombic RhombicWallpaperFormula using the given LatticeHeight.
+// SetUp will create the rhombic RhombicWallpaperFormula using the given LatticeHeight,
+//   or the lattice vectors from the config if they have the same length.
 func (rhombic *RhombicWallpaperFormula) SetUp() error {
-
-	rhombic.Formula.Lattice = &formula.LatticeVectorPair{
-		XLatticeVector: complex(0.5, rhombic.LatticeHeight),
-		YLatticeVector: complex(0.5, rhombic.LatticeHeight * -1),
-	}
-
-	err := rhombic.Formula.Lattice.Validate()
+	lattice, err := rhombic.chooseLattice()
 	if err != nil {
 		return err
 	}
+	rhombic.Formula.useLattice(lattice)
 
 	rhombic.Formula.SetUp(
 		[]coefficient.Relationship{
@@ -43,6 +39,21 @@ func (rhombic *RhombicWallpaperFormula) SetUp() error {
 	return nil
 }
 
+// chooseLattice returns the lattice vectors from the config, or the rhombus made with LatticeHeight.
+//   Square and hexagonal vectors have the same length too, so they work as well.
+func (rhombic *RhombicWallpaperFormula) chooseLattice() (*formula.LatticeVectorPair, error) {
+	return rhombic.Formula.chooseLattice(
+		"rhombic",
+		formula.LatticeVectorPair{
+			XLatticeVector: complex(0.5, rhombic.LatticeHeight),
+			YLatticeVector: complex(0.5, rhombic.LatticeHeight * -1),
+		},
+		formula.RhombicLattice,
+		formula.SquareLattice,
+		formula.HexagonalLattice,
+	)
+}
+
 // Calculate applies the formula to the complex number z.
 //  It modifies the formula's result to track the contribution per term
 //  As well as the final numerical result.
@@ -50,16 +61,23 @@ func (rhombic *RhombicWallpaperFormula) Calculate(z complex128) *formula.Calcula
 	return rhombic.Formula.Calculate(z)
 }
 
+// Validate returns an error if the formula cannot be calculated.
+func (rhombic *RhombicWallpaperFormula) Validate() error {
+	_, err := rhombic.chooseLattice()
+	if err != nil {
+		return err
+	}
+	return rhombic.Formula.Validate()
+}
+
+// Symmetries lists the names of the symmetries the formula has.
+func (rhombic *RhombicWallpaperFormula) Symmetries() []string {
+	return symmetriesFound(latticeSymmetries(formula.RhombicLattice), rhombic.HasSymmetry)
+}
+
 // HasSymmetry returns true if the WavePackets involved form symmetry.
 func (rhombic *RhombicWallpaperFormula) HasSymmetry(desiredSymmetry Symmetry) bool {
-	return HasSymmetry(rhombic.Formula.WavePackets, desiredSymmetry, map[Symmetry][]coefficient.Relationship {
-		Cm: {coefficient.PlusMPlusN},
-		Cmm: {
-			coefficient.MinusNMinusM,
-			coefficient.MinusMMinusN,
-			coefficient.PlusMPlusN,
-		},
-	})
+	return hasLatticeSymmetry(rhombic.Formula.WavePackets, desiredSymmetry, formula.RhombicLattice)
 }
 
 // NewRhombicWallpaperFormulaFromJSON reads the data and returns a formula term from it.
@@ -82,36 +100,42 @@ func newRhombicWallpaperFormulaFromDatastream(data []byte, unmarshal utility.Unm
 		return nil, unmarshalError
 	}
 
-	return NewRhombicWallpaperFormulaFromMarshalObject(rhombicFormulaMarshalled), nil
+	return NewRhombicWallpaperFormulaFromMarshalObject(rhombicFormulaMarshalled)
 }
 
 // NewRhombicWallpaperFormulaFromMarshalObject uses a marshalled object to create a new object.
-func NewRhombicWallpaperFormulaFromMarshalObject(marshalObject RhombicWallpaperFormulaMarshalled) *RhombicWallpaperFormula {
+func NewRhombicWallpaperFormulaFromMarshalObject(marshalObject RhombicWallpaperFormulaMarshalled) (*RhombicWallpaperFormula, error) {
 	formula := NewWallpaperFormulaFromMarshalObject(*marshalObject.Formula)
 
 	if marshalObject.Formula.DesiredSymmetry != "" {
-		wallpaper, err := NewRhombicWallpaperFormulaWithSymmetry(
+		wallpaper, err := newRhombicWallpaperFormulaWithSymmetryAndLattice(
 			formula.WavePackets[0].Terms,
 			formula.Multiplier,
 			marshalObject.LatticeHeight,
 			Symmetry(marshalObject.Formula.DesiredSymmetry),
+			formula.CustomLattice,
 		)
 
 		if err != nil {
-			return nil
+			return nil, err
 		}
-		return wallpaper
+		return wallpaper, nil
 	}
 
 	return &RhombicWallpaperFormula{
 		Formula:       formula,
 		LatticeHeight: marshalObject.LatticeHeight,
-	}
+	}, nil
 }
 
 // NewRhombicWallpaperFormulaWithSymmetry will try to create a new RhombicWallpaperFormula WavePacket
 //   with the desired Terms, Multiplier and Symmetry.
 func NewRhombicWallpaperFormulaWithSymmetry(terms []*formula.EisensteinFormulaTerm, wallpaperMultiplier complex128, latticeHeight float64, desiredSymmetry Symmetry) (*RhombicWallpaperFormula, error) {
+	return newRhombicWallpaperFormulaWithSymmetryAndLattice(terms, wallpaperMultiplier, latticeHeight, desiredSymmetry, nil)
+}
+
+// newRhombicWallpaperFormulaWithSymmetryAndLattice uses the custom lattice, if there is one, when it sets up the formula.
+func newRhombicWallpaperFormulaWithSymmetryAndLattice(terms []*formula.EisensteinFormulaTerm, wallpaperMultiplier complex128, latticeHeight float64, desiredSymmetry Symmetry, customLattice *formula.LatticeVectorPair) (*RhombicWallpaperFormula, error) {
 	newWavePackets := []*WavePacket{}
 	for _, term := range terms {
 		newWavePackets = append(
@@ -127,11 +151,15 @@ func NewRhombicWallpaperFormulaWithSymmetry(terms []*formula.EisensteinFormulaTe
 
 	newBaseWallpaper := &RhombicWallpaperFormula{
 		Formula: &WallpaperFormula{
-			WavePackets: newWavePackets,
-			Multiplier:  wallpaperMultiplier,
+			WavePackets:   newWavePackets,
+			Multiplier:    wallpaperMultiplier,
+			CustomLattice: customLattice,
 		},
 		LatticeHeight: latticeHeight,
 	}
-	newBaseWallpaper.SetUp()
+	err := newBaseWallpaper.SetUp()
+	if err != nil {
+		return nil, err
+	}
 	return newBaseWallpaper, nil
 }
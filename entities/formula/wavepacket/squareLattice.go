@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"gopkg.in/yaml.v2"
 	"wallpaper/entities/formula"
-	"wallpaper/entities/formula/coefficient"
 	"wallpaper/entities/utility"
 )
 
@@ -15,19 +14,35 @@ type SquareWallpaperFormula struct {
 	Formula *WallpaperFormula
 }
 
+// squareLattice is the lattice used unless the config gives its own square lattice vectors.
+var squareLattice = formula.LatticeVectorPair{
+	XLatticeVector: complex(1, 0),
+	YLatticeVector: complex(0, 1),
+}
+
 // SetUp initializes all of the needed wallpaper terms.
-func (squareWaveFormula *SquareWallpaperFormula) SetUp() {
-	squareWaveFormula.Formula.Lattice = &formula.LatticeVectorPair{
-		XLatticeVector: complex(1, 0),
-		YLatticeVector: complex(0, 1),
+func (squareWaveFormula *SquareWallpaperFormula) SetUp() error {
+	lattice, err := squareWaveFormula.Formula.chooseLattice("square", squareLattice, formula.SquareLattice)
+	if err != nil {
+		return err
 	}
-	squareWaveFormula.Formula.SetUp(
-		[]coefficient.Relationship{
-			coefficient.PlusMMinusN,
-			coefficient.MinusNMinusM,
-			coefficient.MinusMPlusN,
-		},
-	)
+	squareWaveFormula.Formula.useLattice(lattice)
+	squareWaveFormula.Formula.SetUp(lockedRelationshipsForLattice(formula.SquareLattice))
+	return nil
+}
+
+// Validate returns an error if the formula cannot be calculated.
+func (squareWaveFormula *SquareWallpaperFormula) Validate() error {
+	_, err := squareWaveFormula.Formula.chooseLattice("square", squareLattice, formula.SquareLattice)
+	if err != nil {
+		return err
+	}
+	return squareWaveFormula.Formula.Validate()
+}
+
+// Symmetries lists the names of the symmetries the formula has.
+func (squareWaveFormula *SquareWallpaperFormula) Symmetries() []string {
+	return symmetriesFound(latticeSymmetries(formula.SquareLattice), squareWaveFormula.HasSymmetry)
 }
 
 // Calculate applies the formula to the complex number z.
@@ -48,25 +63,26 @@ func NewSquareWallpaperFormulaFromYAML(data []byte) (*SquareWallpaperFormula, er
 }
 
 // NewSquareWallpaperFormulaFromMarshalObject uses a marshalled object to create a new object.
-func NewSquareWallpaperFormulaFromMarshalObject(marshalObject WallpaperFormulaMarshalled) *SquareWallpaperFormula {
+func NewSquareWallpaperFormulaFromMarshalObject(marshalObject WallpaperFormulaMarshalled) (*SquareWallpaperFormula, error) {
 	formula := NewWallpaperFormulaFromMarshalObject(marshalObject)
 
 	if marshalObject.DesiredSymmetry != "" {
-		wallpaper, err := NewSquareWallpaperFormulaWithSymmetry(
+		wallpaper, err := newSquareWallpaperFormulaWithSymmetryAndLattice(
 			formula.WavePackets[0].Terms,
 			formula.Multiplier,
 			Symmetry(marshalObject.DesiredSymmetry),
+			formula.CustomLattice,
 		)
 
 		if err != nil {
-			return nil
+			return nil, err
 		}
-		return wallpaper
+		return wallpaper, nil
 	}
 
 	return &SquareWallpaperFormula{
 		Formula:       formula,
-	}
+	}, nil
 }
 
 //newSquareWallpaperFormulaFromDatastream consumes a given bytestream and tries to create a new object from it.
@@ -79,12 +95,17 @@ func newSquareWallpaperFormulaFromDatastream(data []byte, unmarshal utility.Unma
 		return nil, unmarshalError
 	}
 
-	return NewSquareWallpaperFormulaFromMarshalObject(hexFormulaMarshalled), nil
+	return NewSquareWallpaperFormulaFromMarshalObject(hexFormulaMarshalled)
 }
 
 // NewSquareWallpaperFormulaWithSymmetry will try to create a new Hexagonal RhombicWallpaperFormula WavePacket
 //   with the desired Terms, Multiplier and Symmetry.
 func NewSquareWallpaperFormulaWithSymmetry(terms []*formula.EisensteinFormulaTerm, wallpaperMultiplier complex128, desiredSymmetry Symmetry) (*SquareWallpaperFormula, error) {
+	return newSquareWallpaperFormulaWithSymmetryAndLattice(terms, wallpaperMultiplier, desiredSymmetry, nil)
+}
+
+// newSquareWallpaperFormulaWithSymmetryAndLattice uses the custom lattice, if there is one, when it sets up the formula.
+func newSquareWallpaperFormulaWithSymmetryAndLattice(terms []*formula.EisensteinFormulaTerm, wallpaperMultiplier complex128, desiredSymmetry Symmetry, customLattice *formula.LatticeVectorPair) (*SquareWallpaperFormula, error) {
 	newWavePackets := []*WavePacket{}
 	for _, term := range terms {
 
@@ -101,22 +122,19 @@ func NewSquareWallpaperFormulaWithSymmetry(terms []*formula.EisensteinFormulaTer
 
 	newBaseWallpaper := &SquareWallpaperFormula{
 		Formula: &WallpaperFormula{
-			WavePackets: newWavePackets,
-			Multiplier:  wallpaperMultiplier,
+			WavePackets:   newWavePackets,
+			Multiplier:    wallpaperMultiplier,
+			CustomLattice: customLattice,
 		},
 	}
-	newBaseWallpaper.SetUp()
+	err := newBaseWallpaper.SetUp()
+	if err != nil {
+		return nil, err
+	}
 	return newBaseWallpaper, nil
 }
 
 // HasSymmetry returns true if the WavePackets involved form symmetry.
 func (squareWaveFormula *SquareWallpaperFormula) HasSymmetry(desiredSymmetry Symmetry) bool {
-	if desiredSymmetry == P4 {
-		return true
-	}
-
-	return HasSymmetry(squareWaveFormula.Formula.WavePackets, desiredSymmetry, map[Symmetry][]coefficient.Relationship {
-		P4m: {coefficient.PlusMPlusN},
-		P4g: {coefficient.PlusMPlusNNegateMultiplierIfOddPowerSum},
-	})
+	return hasLatticeSymmetry(squareWaveFormula.Formula.WavePackets, desiredSymmetry, formula.SquareLattice)
 }
@@ -1,208 +1,39 @@
 package wavepacket
 
-import "wallpaper/entities/formula"
+import (
+	"strings"
+	"wallpaper/entities/formula"
+	"wallpaper/entities/formula/catalog"
+	"wallpaper/entities/formula/coefficient"
+)
 
+// addNewWavePacketsBasedOnSymmetry adds a wave packet for each of the desired symmetry's coefficient relationships,
+//   so the term's wave packet has a partner for each one.
 func addNewWavePacketsBasedOnSymmetry(term *formula.EisensteinFormulaTerm, multiplier complex128, desiredSymmetry Symmetry, newWavePackets []*WavePacket) []*WavePacket {
-	powerN := term.PowerN
-	powerM := term.PowerM
-	powerNIsEven := powerN % 2 == 0
-	powerSumIsEven := (powerN + powerM) % 2 == 0
-
-	multiplierMaybeNegatedBasedOnSum := multiplier
-	if !powerSumIsEven {
-		multiplierMaybeNegatedBasedOnSum *= -1
-	}
-
-	multiplierMaybeNegatedBasedOnPowerN := multiplier
-	if !powerNIsEven {
-		multiplierMaybeNegatedBasedOnPowerN *= -1
+	group, err := catalog.FindWallpaper(string(desiredSymmetry))
+	if err != nil {
+		return newWavePackets
 	}
 
-	if desiredSymmetry == P31m || desiredSymmetry == P4m || desiredSymmetry == Cm {
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerN: powerM,
-					PowerM: powerN,
-				},
-			},
-			Multiplier: multiplier,
-		})
-	}
-	if desiredSymmetry == Pm {
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerN: powerN,
-					PowerM: powerM * -1,
-				},
-			},
-			Multiplier: multiplier,
-		})
+	baseCoefficientPairing := coefficient.Pairing{
+		PowerN: term.PowerN,
+		PowerM: term.PowerM,
 	}
-	if desiredSymmetry == Pg {
+	for _, newCoefficientPair := range baseCoefficientPairing.GenerateCoefficientSets(group.Relationships) {
+		newMultiplier := multiplier
+		if newCoefficientPair.NegateMultiplier {
+			newMultiplier *= -1
+		}
 		newWavePackets = append(newWavePackets, &WavePacket{
 			Terms: []*formula.EisensteinFormulaTerm{
 				{
-					PowerN: powerN,
-					PowerM: powerM * -1,
+					PowerN: newCoefficientPair.PowerN,
+					PowerM: newCoefficientPair.PowerM,
 				},
 			},
-			Multiplier: multiplierMaybeNegatedBasedOnPowerN,
+			Multiplier: newMultiplier,
 		})
 	}
-	if desiredSymmetry == Pmm {
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerN: powerN * -1,
-					PowerM: powerM * -1,
-				},
-			},
-			Multiplier: multiplier,
-		})
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerN: powerN * -1,
-					PowerM: powerM,
-				},
-			},
-			Multiplier: multiplier,
-		})
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerN: powerN,
-					PowerM: powerM * -1,
-				},
-			},
-			Multiplier: multiplier,
-		})
-	}
-	if desiredSymmetry == Pmg {
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerN: powerN * -1,
-					PowerM: powerM * -1,
-				},
-			},
-			Multiplier: multiplier,
-		})
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerN: powerN * -1,
-					PowerM: powerM,
-				},
-			},
-			Multiplier: multiplierMaybeNegatedBasedOnPowerN,
-		})
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerN: powerN,
-					PowerM: powerM * -1,
-				},
-			},
-			Multiplier: multiplierMaybeNegatedBasedOnPowerN,
-		})
-	}
-	if desiredSymmetry == Pgg {
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerN: powerN * -1,
-					PowerM: powerM * -1,
-				},
-			},
-			Multiplier: multiplier,
-		})
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerN: powerN * -1,
-					PowerM: powerM,
-				},
-			},
-			Multiplier: multiplierMaybeNegatedBasedOnSum,
-		})
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerN: powerN,
-					PowerM: powerM * -1,
-				},
-			},
-			Multiplier: multiplierMaybeNegatedBasedOnSum,
-		})
-	}
-
-	if desiredSymmetry == P3m1 {
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerM: powerN * -1,
-					PowerN: powerM * -1,
-				},
-			},
-			Multiplier: multiplier,
-		})
-	}
-	if desiredSymmetry == P6 {
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerM: powerM * -1,
-					PowerN: powerN * -1,
-				},
-			},
-			Multiplier: multiplier,
-		})
-	}
-	if desiredSymmetry == P6m || desiredSymmetry == Cmm {
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerN: powerN * -1,
-					PowerM: powerM * -1,
-				},
-			},
-			Multiplier: multiplier,
-		})
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerN: powerM,
-					PowerM: powerN,
-				},
-			},
-			Multiplier: multiplier,
-		})
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerN: powerM * -1,
-					PowerM: powerN * -1,
-				},
-			},
-			Multiplier: multiplier,
-		})
-	}
-
-	if desiredSymmetry == P4g {
-		newWavePackets = append(newWavePackets, &WavePacket{
-			Terms: []*formula.EisensteinFormulaTerm{
-				{
-					PowerM: powerN,
-					PowerN: powerM,
-				},
-			},
-			Multiplier: multiplierMaybeNegatedBasedOnSum,
-		})
-	}
-
 	return newWavePackets
 }
 
@@ -211,6 +42,8 @@ type Symmetry string
 
 // All possible symmetries for wallpaper patterns, based on crystallography.
 const (
+	P1   Symmetry = "p1"
+	P2   Symmetry = "p2"
 	P3   Symmetry = "p3"
 	P3m1 Symmetry = "p3m1"
 	P31m Symmetry = "p31m"
@@ -226,4 +59,40 @@ const (
 	Pgg   Symmetry = "pgg"
 	Pmm   Symmetry = "pmm"
 	Pmg   Symmetry = "pmg"
-)
\ No newline at end of file
+)
+// ParseSymmetry returns the wallpaper symmetry with the given Hermann–Mauguin or orbifold name.
+//   Case does not matter, and the orbifold cross can be written as x or ×.
+func ParseSymmetry(name string) (Symmetry, error) {
+	group, err := catalog.FindWallpaper(strings.TrimSpace(name))
+	if err != nil {
+		return "", err
+	}
+	return Symmetry(group.Name), nil
+}
+
+// hasLatticeSymmetry returns true if the wave packets have the desired symmetry,
+//   which must be one of the groups a latticeType formula is written for.
+//   Groups without coefficient relationships come from the lattice and its locked terms alone.
+func hasLatticeSymmetry(wavePackets []*WavePacket, desiredSymmetry Symmetry, latticeType formula.LatticeType) bool {
+	group, err := catalog.FindWallpaper(string(desiredSymmetry))
+	if err != nil || group.Lattice() != latticeType {
+		return false
+	}
+	if len(group.Relationships) == 0 {
+		return true
+	}
+	return HasSymmetry(wavePackets, desiredSymmetry, map[Symmetry][]coefficient.Relationship{
+		desiredSymmetry: group.Relationships,
+	})
+}
+
+// latticeSymmetries returns the groups a latticeType formula is written for.
+func latticeSymmetries(latticeType formula.LatticeType) []Symmetry {
+	symmetries := []Symmetry{}
+	for _, group := range catalog.Wallpapers() {
+		if group.Lattice() == latticeType {
+			symmetries = append(symmetries, Symmetry(group.Name))
+		}
+	}
+	return symmetries
+}
@@ -19,6 +19,8 @@ type Marshal struct {
 type WavePacket struct {
 	Terms 			[]*formula.EisensteinFormulaTerm
 	Multiplier 		complex128
+	// lockedTerms counts the terms at the end of Terms that SetUp added.
+	lockedTerms		int
 }
 
 // Calculate takes the complex number zInLatticeCoordinates and processes it using the mathematical terms.
@@ -1,6 +1,8 @@
 package wavepacket
 
 import (
+	"errors"
+	"fmt"
 	"wallpaper/entities/formula"
 	"wallpaper/entities/formula/coefficient"
 	"wallpaper/entities/utility"
@@ -19,14 +21,19 @@ type WallpaperFormula struct {
 	WavePackets []*WavePacket
 	Multiplier complex128
 	Lattice *formula.LatticeVectorPair
+	// CustomLattice holds the lattice vectors given in the config. SetUp uses them instead of the formula's own.
+	CustomLattice *formula.LatticeVectorPair
+	latticeConverter *formula.LatticeCoordinateConverter
 }
 
 // SetUp adds locked Eisenstein terms to the formula based on the relationships.
 //  Note there is NO way to change the multipliers.
+//  Calling it again replaces the terms the last call added, so each wave packet is only locked once.
 func (wallpaperFormula *WallpaperFormula) SetUp(
 	lockedRelationships []coefficient.Relationship,
 	) {
 	for _, wavePacket := range wallpaperFormula.WavePackets {
+		wavePacket.Terms = wavePacket.Terms[:len(wavePacket.Terms)-wavePacket.lockedTerms]
 		baseCoefficientPairing := coefficient.Pairing{
 			PowerN: wavePacket.Terms[0].PowerN,
 			PowerM: wavePacket.Terms[0].PowerM,
@@ -41,9 +48,37 @@ func (wallpaperFormula *WallpaperFormula) SetUp(
 			}
 			wavePacket.Terms = append(wavePacket.Terms, newEisenstein)
 		}
+		wavePacket.lockedTerms = len(newPairings)
 	}
 }
 
+// Validate returns an error if the wave packets cannot be calculated.
+func (wallpaperFormula *WallpaperFormula) Validate() error {
+	if wallpaperFormula == nil {
+		return errors.New("wallpaper formula is missing")
+	}
+	if len(wallpaperFormula.WavePackets) == 0 {
+		return errors.New("wallpaper formula needs at least one wave packet")
+	}
+	for index, wavePacket := range wallpaperFormula.WavePackets {
+		if len(wavePacket.Terms) == 0 {
+			return fmt.Errorf("wave packet %d needs at least one term", index)
+		}
+	}
+	return nil
+}
+
+// symmetriesFound returns the names of the candidate symmetries that hasSymmetry accepts.
+func symmetriesFound(candidates []Symmetry, hasSymmetry func(Symmetry) bool) []string {
+	symmetryNames := []string{}
+	for _, candidate := range candidates {
+		if hasSymmetry(candidate) {
+			symmetryNames = append(symmetryNames, string(candidate))
+		}
+	}
+	return symmetryNames
+}
+
 // Calculate takes the complex number z and processes it using the mathematical terms.
 func (wallpaperFormula *WallpaperFormula) Calculate(z complex128) *formula.CalculationResultForFormula {
 
@@ -52,7 +87,12 @@ func (wallpaperFormula *WallpaperFormula) Calculate(z complex128) *formula.Calcu
 		ContributionByTerm: []complex128{},
 	}
 
-	zInLatticeCoordinates := wallpaperFormula.Lattice.ConvertToLatticeCoordinates(z)
+	var zInLatticeCoordinates complex128
+	if wallpaperFormula.latticeConverter != nil {
+		zInLatticeCoordinates = wallpaperFormula.latticeConverter.Convert(z)
+	} else {
+		zInLatticeCoordinates = wallpaperFormula.Lattice.ConvertToLatticeCoordinates(z)
+	}
 
 	for _, wavePacket := range wallpaperFormula.WavePackets {
 		termContribution := wavePacket.Calculate(zInLatticeCoordinates)
@@ -72,8 +112,14 @@ func NewWallpaperFormulaFromMarshalObject(marshalObject WallpaperFormulaMarshall
 		wavePackets = append(wavePackets, newWavePacket)
 	}
 
+	var customLattice *formula.LatticeVectorPair
+	if marshalObject.Lattice != nil {
+		customLattice = formula.NewLatticeVectorPairFromMarshal(*marshalObject.Lattice)
+	}
+
 	return &WallpaperFormula{
 		WavePackets: wavePackets,
 		Multiplier:  complex(marshalObject.Multiplier.Real, marshalObject.Multiplier.Imaginary),
+		CustomLattice: customLattice,
 	}
 }
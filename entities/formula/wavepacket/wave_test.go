@@ -173,6 +173,42 @@ func (suite *WaveFormulaTests) TestSetUpUsesMultipliers(checker *C) {
 	checker.Assert(wallPaperWithOddSumTerms.WavePackets[0].Terms[3].PowerM, Equals, -1 * (baseTerm.PowerN + baseTerm.PowerM))
 }
 
+func (suite *WaveFormulaTests) TestSetUpTwiceOnlyLocksTheTermsOnce(checker *C) {
+	wallpaperFormula := &wavepacket.WallpaperFormula{
+		WavePackets: []*wavepacket.WavePacket{
+			{
+				Terms: []*formula.EisensteinFormulaTerm{
+					{
+						PowerN: -3,
+						PowerM: 4,
+					},
+					{
+						PowerN: 2,
+						PowerM: 0,
+					},
+				},
+				Multiplier: complex(1, 0),
+			},
+		},
+		Multiplier:  complex(1, 0),
+	}
+	lockedRelationships := []coefficient.Relationship{
+		coefficient.MinusNMinusM,
+		coefficient.PlusMPlusN,
+	}
+
+	wallpaperFormula.SetUp(lockedRelationships)
+	wallpaperFormula.SetUp(lockedRelationships)
+
+	terms := wallpaperFormula.WavePackets[0].Terms
+	checker.Assert(terms, HasLen, 4)
+	checker.Assert(terms[1].PowerN, Equals, 2)
+	checker.Assert(terms[2].PowerN, Equals, 3)
+	checker.Assert(terms[2].PowerM, Equals, -4)
+	checker.Assert(terms[3].PowerN, Equals, 4)
+	checker.Assert(terms[3].PowerM, Equals, -3)
+}
+
 type WavePacketRelationshipTest struct {
 	aPlusNPlusMOddWavePacket *wavepacket.WavePacket
 	aPlusMMinusNOddWavePacket *wavepacket.WavePacket
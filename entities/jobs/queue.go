@@ -0,0 +1,259 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"wallpaper/entities/render"
+)
+
+// State is where a job is in its life.
+type State string
+
+// Jobs start queued, run, and end done, failed or cancelled.
+const (
+	Queued    State = "queued"
+	Running   State = "running"
+	Done      State = "done"
+	Failed    State = "failed"
+	Cancelled State = "cancelled"
+)
+
+// Finished returns true if the job has stopped for good.
+func (state State) Finished() bool {
+	return state == Done || state == Failed || state == Cancelled
+}
+
+// DefaultFinishedJobsKept is how many finished jobs a Queue remembers. Older finished jobs are forgotten.
+const DefaultFinishedJobsKept = 100
+
+// RunFunc does a job's work. It should stop promptly when ctx is cancelled,
+//   and call reportProgress as rows are rendered.
+type RunFunc func(ctx context.Context, reportProgress func(render.Progress)) error
+
+// Status is a snapshot of a job.
+type Status struct {
+	ID       int             `json:"id"`
+	Name     string          `json:"name"`
+	State    State           `json:"state"`
+	Progress render.Progress `json:"progress"`
+	// Error is why the job failed, if it did.
+	Error string `json:"error,omitempty"`
+	// Started and Finished are zero until the job starts and finishes.
+	Submitted time.Time `json:"submitted"`
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished"`
+}
+
+// job is a submitted RunFunc and what is known about it.
+type job struct {
+	status Status
+	run    RunFunc
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Queue runs submitted jobs in the order they were submitted, a few at a time.
+type Queue struct {
+	// FinishedJobsKept is how many finished jobs are remembered for Status and List.
+	FinishedJobsKept int
+
+	ctx        context.Context
+	onChange   func(Status)
+	lock       sync.Mutex
+	jobAdded   *sync.Cond
+	nextID     int
+	jobs       map[int]*job
+	waiting    []*job
+	finished   []int
+	unfinished sync.WaitGroup
+}
+
+// NewQueue starts workers goroutines that run jobs until ctx is cancelled. Cancelling ctx cancels every job.
+//   onChange, if it is not nil, is called with the new status every time a job changes state or reports progress.
+//   Calls are not made at the same time, so onChange does not need to lock anything, but it must not call the queue.
+func NewQueue(ctx context.Context, workers int, onChange func(Status)) *Queue {
+	if workers < 1 {
+		workers = 1
+	}
+	queue := &Queue{
+		ctx:              ctx,
+		onChange:         onChange,
+		nextID:           1,
+		jobs:             map[int]*job{},
+		FinishedJobsKept: DefaultFinishedJobsKept,
+	}
+	queue.jobAdded = sync.NewCond(&queue.lock)
+	for worker := 0; worker < workers; worker++ {
+		go queue.work()
+	}
+	go func() {
+		<-ctx.Done()
+		queue.lock.Lock()
+		defer queue.lock.Unlock()
+		queue.jobAdded.Broadcast()
+	}()
+	return queue
+}
+
+// Submit queues a job and returns its ID. If the queue's context is already cancelled, the job is cancelled at once.
+func (queue *Queue) Submit(name string, run RunFunc) int {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	jobCtx, cancel := context.WithCancel(queue.ctx)
+	newJob := &job{
+		status: Status{
+			ID:        queue.nextID,
+			Name:      name,
+			State:     Queued,
+			Submitted: time.Now(),
+		},
+		run:    run,
+		ctx:    jobCtx,
+		cancel: cancel,
+	}
+	queue.nextID++
+	queue.jobs[newJob.status.ID] = newJob
+	queue.unfinished.Add(1)
+	queue.changed(newJob)
+
+	if queue.ctx.Err() != nil {
+		queue.finish(newJob, queue.ctx.Err())
+		return newJob.status.ID
+	}
+	queue.waiting = append(queue.waiting, newJob)
+	queue.jobAdded.Signal()
+	return newJob.status.ID
+}
+
+// Cancel stops a job. A queued job never runs, and a running job's context is cancelled.
+//   Cancelling a finished job does nothing.
+func (queue *Queue) Cancel(id int) error {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	cancelled, ok := queue.jobs[id]
+	if !ok {
+		return fmt.Errorf("no job %d", id)
+	}
+	switch cancelled.status.State {
+	case Queued:
+		for index, waiting := range queue.waiting {
+			if waiting == cancelled {
+				queue.waiting = append(queue.waiting[:index], queue.waiting[index+1:]...)
+				break
+			}
+		}
+		queue.finish(cancelled, context.Canceled)
+	case Running:
+		cancelled.cancel()
+	}
+	return nil
+}
+
+// Status returns a snapshot of the job, or false if there is no such job or it was forgotten.
+func (queue *Queue) Status(id int) (Status, bool) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	found, ok := queue.jobs[id]
+	if !ok {
+		return Status{}, false
+	}
+	return found.status, true
+}
+
+// List returns a snapshot of every remembered job, oldest first.
+func (queue *Queue) List() []Status {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	statuses := []Status{}
+	for id := 1; id < queue.nextID; id++ {
+		if found, ok := queue.jobs[id]; ok {
+			statuses = append(statuses, found.status)
+		}
+	}
+	return statuses
+}
+
+// Wait returns once every submitted job has finished.
+func (queue *Queue) Wait() {
+	queue.unfinished.Wait()
+}
+
+// work runs the oldest waiting job, over and over, until the queue's context is cancelled.
+func (queue *Queue) work() {
+	for {
+		queue.lock.Lock()
+		for len(queue.waiting) == 0 && queue.ctx.Err() == nil {
+			queue.jobAdded.Wait()
+		}
+		if queue.ctx.Err() != nil {
+			queue.cancelWaiting()
+			queue.lock.Unlock()
+			return
+		}
+		next := queue.waiting[0]
+		queue.waiting = queue.waiting[1:]
+		next.status.State = Running
+		next.status.Started = time.Now()
+		queue.changed(next)
+		queue.lock.Unlock()
+
+		queue.run(next)
+	}
+}
+
+// run runs the job and records how it ended.
+func (queue *Queue) run(running *job) {
+	err := running.run(running.ctx, func(progress render.Progress) {
+		queue.lock.Lock()
+		defer queue.lock.Unlock()
+		running.status.Progress = progress
+		queue.changed(running)
+	})
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	queue.finish(running, err)
+}
+
+// cancelWaiting cancels every job that has not started. The lock must be held.
+func (queue *Queue) cancelWaiting() {
+	for _, waiting := range queue.waiting {
+		queue.finish(waiting, context.Canceled)
+	}
+	queue.waiting = nil
+}
+
+// finish records how the job ended and forgets the oldest finished jobs past FinishedJobsKept. The lock must be held.
+func (queue *Queue) finish(finished *job, err error) {
+	switch {
+	case err == nil:
+		finished.status.State = Done
+	case err == context.Canceled || err == context.DeadlineExceeded:
+		finished.status.State = Cancelled
+	default:
+		finished.status.State = Failed
+		finished.status.Error = err.Error()
+	}
+	finished.status.Finished = time.Now()
+	finished.cancel()
+	queue.changed(finished)
+	queue.unfinished.Done()
+
+	queue.finished = append(queue.finished, finished.status.ID)
+	for len(queue.finished) > queue.FinishedJobsKept {
+		delete(queue.jobs, queue.finished[0])
+		queue.finished = queue.finished[1:]
+	}
+}
+
+// changed tells onChange about the job's new status. The lock must be held.
+func (queue *Queue) changed(changed *job) {
+	if queue.onChange != nil {
+		queue.onChange(changed.status)
+	}
+}
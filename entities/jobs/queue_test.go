@@ -0,0 +1,185 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	. "gopkg.in/check.v1"
+	"sync"
+	"testing"
+	"time"
+	"wallpaper/entities/jobs"
+	"wallpaper/entities/render"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type QueueSuite struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	lock    sync.Mutex
+	changes []jobs.Status
+}
+
+var _ = Suite(&QueueSuite{})
+
+func (suite *QueueSuite) SetUpTest(checker *C) {
+	suite.ctx, suite.cancel = context.WithCancel(context.Background())
+	suite.changes = nil
+}
+
+func (suite *QueueSuite) TearDownTest(checker *C) {
+	suite.cancel()
+}
+
+func (suite *QueueSuite) newQueue(workers int) *jobs.Queue {
+	return jobs.NewQueue(suite.ctx, workers, func(status jobs.Status) {
+		suite.lock.Lock()
+		defer suite.lock.Unlock()
+		suite.changes = append(suite.changes, status)
+	})
+}
+
+// statesOf returns every state the job went through, in order, without repeats.
+func (suite *QueueSuite) statesOf(id int) []jobs.State {
+	suite.lock.Lock()
+	defer suite.lock.Unlock()
+	states := []jobs.State{}
+	for _, change := range suite.changes {
+		if change.ID == id && (len(states) == 0 || states[len(states)-1] != change.State) {
+			states = append(states, change.State)
+		}
+	}
+	return states
+}
+
+// blockUntilCancelled is a job that runs until its context is cancelled. started is closed once it runs.
+func blockUntilCancelled(started chan struct{}) jobs.RunFunc {
+	return func(ctx context.Context, reportProgress func(render.Progress)) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+}
+
+func (suite *QueueSuite) TestJobsRunInOrderAndReportProgress(checker *C) {
+	queue := suite.newQueue(1)
+	order := []string{}
+	for _, name := range []string{"first", "second", "third"} {
+		name := name
+		queue.Submit(name, func(ctx context.Context, reportProgress func(render.Progress)) error {
+			order = append(order, name)
+			reportProgress(render.Progress{RowsDone: 1, TotalRows: 2})
+			reportProgress(render.Progress{RowsDone: 2, TotalRows: 2})
+			return nil
+		})
+	}
+	queue.Wait()
+
+	checker.Assert(order, DeepEquals, []string{"first", "second", "third"})
+	statuses := queue.List()
+	checker.Assert(statuses, HasLen, 3)
+	for index, status := range statuses {
+		checker.Assert(status.ID, Equals, index+1)
+		checker.Assert(status.State, Equals, jobs.Done)
+		checker.Assert(status.Progress.Fraction(), Equals, 1.0)
+		checker.Assert(status.Finished.Before(status.Started), Equals, false)
+	}
+	checker.Assert(suite.statesOf(2), DeepEquals, []jobs.State{jobs.Queued, jobs.Running, jobs.Done})
+}
+
+func (suite *QueueSuite) TestFailedJobsKeepTheirError(checker *C) {
+	queue := suite.newQueue(2)
+	id := queue.Submit("broken", func(ctx context.Context, reportProgress func(render.Progress)) error {
+		return errors.New("cannot open source.png")
+	})
+	queue.Wait()
+
+	status, ok := queue.Status(id)
+	checker.Assert(ok, Equals, true)
+	checker.Assert(status.State, Equals, jobs.Failed)
+	checker.Assert(status.Error, Equals, "cannot open source.png")
+}
+
+func (suite *QueueSuite) TestCancellingARunningJobCancelsItsContext(checker *C) {
+	queue := suite.newQueue(1)
+	started := make(chan struct{})
+	id := queue.Submit("slow", blockUntilCancelled(started))
+	<-started
+
+	checker.Assert(queue.Cancel(id), IsNil)
+	queue.Wait()
+	status, _ := queue.Status(id)
+	checker.Assert(status.State, Equals, jobs.Cancelled)
+	checker.Assert(status.Error, Equals, "")
+}
+
+func (suite *QueueSuite) TestCancellingAQueuedJobMeansItNeverRuns(checker *C) {
+	queue := suite.newQueue(1)
+	started := make(chan struct{})
+	blocker := queue.Submit("blocker", blockUntilCancelled(started))
+	<-started
+	ran := false
+	waiting := queue.Submit("waiting", func(ctx context.Context, reportProgress func(render.Progress)) error {
+		ran = true
+		return nil
+	})
+
+	checker.Assert(queue.Cancel(waiting), IsNil)
+	checker.Assert(queue.Cancel(blocker), IsNil)
+	queue.Wait()
+	checker.Assert(ran, Equals, false)
+	checker.Assert(suite.statesOf(waiting), DeepEquals, []jobs.State{jobs.Queued, jobs.Cancelled})
+}
+
+func (suite *QueueSuite) TestCancellingTheQueueCancelsEveryJob(checker *C) {
+	queue := suite.newQueue(1)
+	started := make(chan struct{})
+	running := queue.Submit("running", blockUntilCancelled(started))
+	<-started
+	waiting := queue.Submit("waiting", func(ctx context.Context, reportProgress func(render.Progress)) error {
+		return nil
+	})
+
+	suite.cancel()
+	queue.Wait()
+	late := queue.Submit("late", func(ctx context.Context, reportProgress func(render.Progress)) error {
+		return nil
+	})
+	queue.Wait()
+
+	for _, id := range []int{running, waiting, late} {
+		status, _ := queue.Status(id)
+		checker.Assert(status.State, Equals, jobs.Cancelled, Commentf("job %d", id))
+	}
+}
+
+func (suite *QueueSuite) TestOnlyTheNewestFinishedJobsAreKept(checker *C) {
+	queue := suite.newQueue(1)
+	queue.FinishedJobsKept = 2
+	for index := 0; index < 4; index++ {
+		queue.Submit("job", func(ctx context.Context, reportProgress func(render.Progress)) error {
+			return nil
+		})
+		queue.Wait()
+	}
+
+	statuses := queue.List()
+	checker.Assert(statuses, HasLen, 2)
+	checker.Assert(statuses[0].ID, Equals, 3)
+	_, ok := queue.Status(1)
+	checker.Assert(ok, Equals, false)
+	checker.Assert(queue.Cancel(1), ErrorMatches, "no job 1")
+}
+
+func (suite *QueueSuite) TestWaitingWithNothingSubmittedReturnsAtOnce(checker *C) {
+	finished := make(chan struct{})
+	go func() {
+		suite.newQueue(1).Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		checker.Fatal("Wait did not return")
+	}
+}
@@ -1,7 +1,5 @@
 package mathutility
 
-import "math/cmplx"
-
 // ScaleValueBetweenTwoRanges translates value (exists between min1 and max2)
 //   to somewhere between min2 and max2. Linear scale is assumed.
 //   So if value is 25% between min1 and max1, then this will return
@@ -22,30 +20,12 @@ func ScaleValueBetweenTwoRanges(value, oldRangeMin, oldRangeMax, newRangeMin, ne
 	return (ratioAcrossRange * distanceAcrossNewRange) + newRangeMin
 }
 
-// GetBoundingBox returns two complex numbers that contain all of the non-infinity numbers inside.
+// GetBoundingBox returns two complex numbers that contain all of the finite numbers inside.
 //   The first number is the minimum (all numbers have a real & imaginary component greater than or equal to it)
 //   The second number is the maximum (all numbers have a real & imaginary component less than or equal to it)
-//   If numbers is an empty slice, returns (0+0i, 0+0i)
+//   Infinite and NaN numbers are ignored.
+//   If there are no finite numbers, returns (0+0i, 0+0i)
 func GetBoundingBox(numbers []complex128) (complex128, complex128) {
-	minX, minY, maxX, maxY := 0.0, 0.0, 0.0, 0.0
-	for _, number := range numbers {
-		if cmplx.IsInf(number) {
-			continue
-		}
-
-		if real(number) < minX {
-			minX = real(number)
-		}
-		if real(number) > maxX {
-			maxX = real(number)
-		}
-
-		if imag(number) < minY {
-			minY = imag(number)
-		}
-		if imag(number) > maxY {
-			maxY = imag(number)
-		}
-	}
-	return complex(minX, minY), complex(maxX, maxY)
-}
\ No newline at end of file
+	statistics := NewStatistics(numbers)
+	return statistics.Min, statistics.Max
+}
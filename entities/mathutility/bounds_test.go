@@ -107,3 +107,17 @@ func (suite *BoundsTestSuite) TestBoundingBoxIgnoresInfinity(checker *C) {
 	checker.Assert(real(max)- 10 < 0.01, Equals, true)
 	checker.Assert(imag(max)- 10 < 0.01, Equals, true)
 }
+
+func (suite *BoundsTestSuite) TestBoundingBoxDoesNotIncludeZero(checker *C) {
+	min, max := mathutility.GetBoundingBox([]complex128{complex(5, 6), complex(7, 8)})
+
+	checker.Assert(min, Equals, complex(5, 6))
+	checker.Assert(max, Equals, complex(7, 8))
+}
+
+func (suite *BoundsTestSuite) TestBoundingBoxIgnoresNaN(checker *C) {
+	min, max := mathutility.GetBoundingBox([]complex128{cmplx.NaN(), complex(-1, 2), complex(3, -4)})
+
+	checker.Assert(min, Equals, complex(-1, -4))
+	checker.Assert(max, Equals, complex(3, 2))
+}
@@ -0,0 +1,182 @@
+package mathutility
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// Statistics summarizes a set of complex numbers.
+//   Infinite and NaN numbers are counted, but they do not affect the bounds or the mean.
+//   Numbers can be added one at a time, so huge sets never have to be held in memory.
+type Statistics struct {
+	// Count is the number of finite numbers.
+	Count    int
+	NaNCount int
+	InfCount int
+	// Min and Max are the corners of the smallest box holding every finite number.
+	//   Both are 0+0i if there are no finite numbers.
+	Min complex128
+	Max complex128
+	Sum complex128
+}
+
+// NewStatistics summarizes numbers.
+func NewStatistics(numbers []complex128) *Statistics {
+	statistics := &Statistics{}
+	for _, number := range numbers {
+		statistics.Add(number)
+	}
+	return statistics
+}
+
+// Add includes number in the statistics.
+func (statistics *Statistics) Add(number complex128) {
+	if cmplx.IsInf(number) {
+		statistics.InfCount++
+		return
+	}
+	if cmplx.IsNaN(number) {
+		statistics.NaNCount++
+		return
+	}
+
+	if statistics.Count == 0 {
+		statistics.Min = number
+		statistics.Max = number
+	} else {
+		statistics.Min = complex(math.Min(real(statistics.Min), real(number)), math.Min(imag(statistics.Min), imag(number)))
+		statistics.Max = complex(math.Max(real(statistics.Max), real(number)), math.Max(imag(statistics.Max), imag(number)))
+	}
+	statistics.Count++
+	statistics.Sum += number
+}
+
+// Merge includes every number summarized by other.
+func (statistics *Statistics) Merge(other *Statistics) {
+	if other.Count > 0 {
+		if statistics.Count == 0 {
+			statistics.Min = other.Min
+			statistics.Max = other.Max
+		} else {
+			statistics.Min = complex(math.Min(real(statistics.Min), real(other.Min)), math.Min(imag(statistics.Min), imag(other.Min)))
+			statistics.Max = complex(math.Max(real(statistics.Max), real(other.Max)), math.Max(imag(statistics.Max), imag(other.Max)))
+		}
+	}
+	statistics.Count += other.Count
+	statistics.NaNCount += other.NaNCount
+	statistics.InfCount += other.InfCount
+	statistics.Sum += other.Sum
+}
+
+// Mean returns the average of the finite numbers, or 0+0i if there are none.
+func (statistics *Statistics) Mean() complex128 {
+	if statistics.Count == 0 {
+		return 0
+	}
+	return statistics.Sum / complex(float64(statistics.Count), 0)
+}
+
+// Distribution holds the sorted real and imaginary parts of every finite number,
+//   so percentiles and histograms can be read from it.
+type Distribution struct {
+	Real      []float64
+	Imaginary []float64
+}
+
+// NewDistribution sorts the parts of the finite numbers.
+func NewDistribution(numbers []complex128) *Distribution {
+	realParts, imaginaryParts := SortedFiniteParts(numbers)
+	return &Distribution{
+		Real:      realParts,
+		Imaginary: imaginaryParts,
+	}
+}
+
+// Percentile returns the percentile of the real parts and the imaginary parts, combined into one number.
+//   percentile runs from 0 to 100. If there are no finite numbers, returns NaN.
+func (distribution *Distribution) Percentile(percentile float64) complex128 {
+	return complex(Percentile(distribution.Real, percentile), Percentile(distribution.Imaginary, percentile))
+}
+
+// Histogram counts how many real parts and how many imaginary parts fall into each of the given number of bins.
+//   The bins evenly divide the space between the smallest and largest part.
+func (distribution *Distribution) Histogram(bins int) (*Histogram, *Histogram) {
+	return NewHistogram(distribution.Real, bins), NewHistogram(distribution.Imaginary, bins)
+}
+
+// Histogram counts values in evenly sized bins between Min and Max.
+type Histogram struct {
+	Min    float64
+	Max    float64
+	Counts []int
+}
+
+// NewHistogram sorts sortedValues into the given number of bins.
+//   The last bin includes Max. If every value is the same, they all go in the first bin.
+func NewHistogram(sortedValues []float64, bins int) *Histogram {
+	if bins < 1 {
+		bins = 1
+	}
+	histogram := &Histogram{Counts: make([]int, bins)}
+	if len(sortedValues) == 0 {
+		return histogram
+	}
+
+	histogram.Min = sortedValues[0]
+	histogram.Max = sortedValues[len(sortedValues)-1]
+	binWidth := (histogram.Max - histogram.Min) / float64(bins)
+	for _, value := range sortedValues {
+		bin := 0
+		if binWidth > 0 {
+			bin = int((value - histogram.Min) / binWidth)
+		}
+		if bin >= bins {
+			bin = bins - 1
+		}
+		histogram.Counts[bin]++
+	}
+	return histogram
+}
+
+// SortedFiniteParts splits numbers into their real and imaginary parts, sorted from least to greatest.
+//   Numbers with an infinite or NaN part are left out entirely.
+func SortedFiniteParts(numbers []complex128) ([]float64, []float64) {
+	realParts := make([]float64, 0, len(numbers))
+	imaginaryParts := make([]float64, 0, len(numbers))
+	for _, number := range numbers {
+		if !isFinite(real(number)) || !isFinite(imag(number)) {
+			continue
+		}
+		realParts = append(realParts, real(number))
+		imaginaryParts = append(imaginaryParts, imag(number))
+	}
+	sort.Float64s(realParts)
+	sort.Float64s(imaginaryParts)
+	return realParts, imaginaryParts
+}
+
+// Percentile returns the value that percentile percent of sortedValues are less than or equal to.
+//   percentile runs from 0 (the smallest value) to 100 (the largest value).
+//   Values in between are linearly interpolated. If sortedValues is empty, returns NaN.
+func Percentile(sortedValues []float64, percentile float64) float64 {
+	if len(sortedValues) == 0 {
+		return math.NaN()
+	}
+	if percentile <= 0 {
+		return sortedValues[0]
+	}
+	if percentile >= 100 {
+		return sortedValues[len(sortedValues)-1]
+	}
+
+	position := percentile / 100 * float64(len(sortedValues)-1)
+	lowerIndex := int(math.Floor(position))
+	upperIndex := int(math.Ceil(position))
+	fraction := position - float64(lowerIndex)
+	return sortedValues[lowerIndex] + (sortedValues[upperIndex]-sortedValues[lowerIndex])*fraction
+}
+
+func isFinite(value float64) bool {
+	return !math.IsInf(value, 0) && !math.IsNaN(value)
+}
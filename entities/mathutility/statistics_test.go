@@ -0,0 +1,106 @@
+package mathutility_test
+
+import (
+	. "gopkg.in/check.v1"
+	"math"
+	"math/cmplx"
+	"wallpaper/entities/mathutility"
+	"wallpaper/entities/utility"
+)
+
+type StatisticsSuite struct {
+}
+
+var _ = Suite(&StatisticsSuite{})
+
+func (suite *StatisticsSuite) TestStatisticsCountsAndBoundsFiniteNumbers(checker *C) {
+	statistics := mathutility.NewStatistics([]complex128{
+		complex(2, 3),
+		cmplx.Inf(),
+		complex(4, -1),
+		cmplx.NaN(),
+		complex(6, 1),
+		complex(math.Inf(-1), 0),
+	})
+
+	checker.Assert(statistics.Count, Equals, 3)
+	checker.Assert(statistics.InfCount, Equals, 2)
+	checker.Assert(statistics.NaNCount, Equals, 1)
+	checker.Assert(statistics.Min, Equals, complex(2, -1))
+	checker.Assert(statistics.Max, Equals, complex(6, 3))
+	checker.Assert(statistics.Mean(), Equals, complex(4, 1))
+}
+
+func (suite *StatisticsSuite) TestStatisticsWithoutNumbers(checker *C) {
+	statistics := mathutility.NewStatistics([]complex128{cmplx.NaN()})
+
+	checker.Assert(statistics.Count, Equals, 0)
+	checker.Assert(statistics.Min, Equals, complex128(0))
+	checker.Assert(statistics.Max, Equals, complex128(0))
+	checker.Assert(statistics.Mean(), Equals, complex128(0))
+}
+
+func (suite *StatisticsSuite) TestMergeMatchesAddingEverything(checker *C) {
+	first := mathutility.NewStatistics([]complex128{complex(1, 1), cmplx.Inf()})
+	second := mathutility.NewStatistics([]complex128{complex(-3, 5), cmplx.NaN()})
+	empty := mathutility.NewStatistics([]complex128{})
+
+	merged := &mathutility.Statistics{}
+	merged.Merge(empty)
+	merged.Merge(first)
+	merged.Merge(second)
+
+	checker.Assert(*merged, Equals, *mathutility.NewStatistics([]complex128{complex(1, 1), cmplx.Inf(), complex(-3, 5), cmplx.NaN()}))
+}
+
+func (suite *StatisticsSuite) TestDistributionPercentiles(checker *C) {
+	numbers := []complex128{cmplx.Inf()}
+	for index := 0; index <= 100; index++ {
+		numbers = append(numbers, complex(float64(100-index), float64(index)*2))
+	}
+	distribution := mathutility.NewDistribution(numbers)
+
+	checker.Assert(distribution.Real, HasLen, 101)
+	checker.Assert(distribution.Percentile(0), Equals, complex(0, 0))
+	checker.Assert(distribution.Percentile(50), Equals, complex(50, 100))
+	checker.Assert(distribution.Percentile(100), Equals, complex(100, 200))
+}
+
+func (suite *StatisticsSuite) TestHistogramCountsValuesPerBin(checker *C) {
+	distribution := mathutility.NewDistribution([]complex128{
+		complex(0, 5), complex(1, 5), complex(2, 5), complex(9, 5), complex(10, 5),
+	})
+	realHistogram, imaginaryHistogram := distribution.Histogram(5)
+
+	checker.Assert(realHistogram.Min, Equals, 0.0)
+	checker.Assert(realHistogram.Max, Equals, 10.0)
+	checker.Assert(realHistogram.Counts, DeepEquals, []int{2, 1, 0, 0, 2})
+	checker.Assert(imaginaryHistogram.Counts, DeepEquals, []int{5, 0, 0, 0, 0})
+}
+
+func (suite *StatisticsSuite) TestSortedFinitePartsSkipsInfinityAndNaN(checker *C) {
+	realParts, imaginaryParts := mathutility.SortedFiniteParts([]complex128{
+		complex(3, -1),
+		cmplx.Inf(),
+		complex(-2, 5),
+		cmplx.NaN(),
+		complex(1, math.NaN()),
+	})
+
+	checker.Assert(realParts, DeepEquals, []float64{-2, 3})
+	checker.Assert(imaginaryParts, DeepEquals, []float64{-1, 5})
+}
+
+func (suite *StatisticsSuite) TestPercentileInterpolatesBetweenValues(checker *C) {
+	sortedValues := []float64{0, 10, 20, 30, 40}
+
+	checker.Assert(mathutility.Percentile(sortedValues, 0), Equals, 0.0)
+	checker.Assert(mathutility.Percentile(sortedValues, 100), Equals, 40.0)
+	checker.Assert(mathutility.Percentile(sortedValues, 50), Equals, 20.0)
+	checker.Assert(mathutility.Percentile(sortedValues, 10), utility.NumericallyCloseEnough{}, 4.0, 1e-9)
+	checker.Assert(mathutility.Percentile(sortedValues, 99), utility.NumericallyCloseEnough{}, 39.6, 1e-9)
+}
+
+func (suite *StatisticsSuite) TestPercentileOfNothingIsNaN(checker *C) {
+	checker.Assert(math.IsNaN(mathutility.Percentile([]float64{}, 50)), Equals, true)
+}
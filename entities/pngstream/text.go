@@ -0,0 +1,212 @@
+package pngstream
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+)
+
+// maximumKeywordLength is the longest keyword a PNG text chunk allows.
+const maximumKeywordLength = 79
+
+// iendChunkSize is the length of the IEND chunk that ends every PNG: length, type and CRC with no data.
+const iendChunkSize = 12
+
+// TextChunk is a keyword and its text, stored in a PNG's metadata.
+type TextChunk struct {
+	Keyword string
+	Text    string
+}
+
+// AddText stores chunks in the PNG when the Writer is closed.
+func (writer *Writer) AddText(chunks ...TextChunk) error {
+	for _, chunk := range chunks {
+		err := validateKeyword(chunk.Keyword)
+		if err != nil {
+			return err
+		}
+	}
+	writer.text = append(writer.text, chunks...)
+	return nil
+}
+
+// EncodeWithText writes outputImage as a PNG with the text chunks just before its end.
+func EncodeWithText(output io.Writer, outputImage image.Image, chunks []TextChunk) error {
+	for _, chunk := range chunks {
+		err := validateKeyword(chunk.Keyword)
+		if err != nil {
+			return err
+		}
+	}
+
+	var encoded bytes.Buffer
+	err := png.Encode(&encoded, outputImage)
+	if err != nil {
+		return err
+	}
+	pngData := encoded.Bytes()
+	end := len(pngData) - iendChunkSize
+
+	bufferedOutput := bufio.NewWriter(output)
+	bufferedOutput.Write(pngData[:end])
+	err = writeTextChunks(bufferedOutput, chunks)
+	if err != nil {
+		return err
+	}
+	bufferedOutput.Write(pngData[end:])
+	return bufferedOutput.Flush()
+}
+
+// writeTextChunks writes plain ASCII text as tEXt chunks and anything else as uncompressed UTF-8 iTXt chunks.
+func writeTextChunks(output io.Writer, chunks []TextChunk) error {
+	for _, chunk := range chunks {
+		var err error
+		if isASCII(chunk.Text) {
+			err = writeChunk(output, "tEXt", []byte(chunk.Keyword+"\x00"+chunk.Text))
+		} else {
+			// Keyword, compression flag and method, then empty language tag and translated keyword.
+			err = writeChunk(output, "iTXt", []byte(chunk.Keyword+"\x00\x00\x00\x00\x00"+chunk.Text))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadText returns every tEXt, zTXt and iTXt chunk in the PNG, in order.
+func ReadText(input io.Reader) ([]TextChunk, error) {
+	bufferedInput := bufio.NewReader(input)
+	signature := make([]byte, len(pngSignature))
+	_, err := io.ReadFull(bufferedInput, signature)
+	if err != nil || !bytes.Equal(signature, pngSignature) {
+		return nil, errors.New("not a png file")
+	}
+
+	chunks := []TextChunk{}
+	lengthAndType := make([]byte, 8)
+	for {
+		_, err = io.ReadFull(bufferedInput, lengthAndType)
+		if err != nil {
+			return nil, fmt.Errorf("png ends before its IEND chunk: %v", err)
+		}
+		length := binary.BigEndian.Uint32(lengthAndType[0:4])
+		chunkType := string(lengthAndType[4:8])
+		if chunkType == "IEND" {
+			return chunks, nil
+		}
+
+		if chunkType != "tEXt" && chunkType != "zTXt" && chunkType != "iTXt" {
+			_, err = io.CopyN(ioutil.Discard, bufferedInput, int64(length)+4)
+			if err != nil {
+				return nil, fmt.Errorf("png ends inside a %s chunk: %v", chunkType, err)
+			}
+			continue
+		}
+
+		data := make([]byte, length+4)
+		_, err = io.ReadFull(bufferedInput, data)
+		if err != nil {
+			return nil, fmt.Errorf("png ends inside a %s chunk: %v", chunkType, err)
+		}
+		chunk, err := parseTextChunk(chunkType, data[:length])
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+// parseTextChunk reads the keyword and text out of a text chunk's data.
+func parseTextChunk(chunkType string, data []byte) (TextChunk, error) {
+	keywordEnd := bytes.IndexByte(data, 0)
+	if keywordEnd < 1 {
+		return TextChunk{}, fmt.Errorf("%s chunk has no keyword", chunkType)
+	}
+	chunk := TextChunk{Keyword: string(data[:keywordEnd])}
+	rest := data[keywordEnd+1:]
+
+	switch chunkType {
+	case "tEXt":
+		chunk.Text = latin1ToUTF8(rest)
+		return chunk, nil
+	case "zTXt":
+		if len(rest) < 1 {
+			return TextChunk{}, errors.New("zTXt chunk has no compression method")
+		}
+		text, err := inflate(rest[1:])
+		if err != nil {
+			return TextChunk{}, fmt.Errorf("cannot decompress zTXt chunk %q: %v", chunk.Keyword, err)
+		}
+		chunk.Text = latin1ToUTF8(text)
+		return chunk, nil
+	}
+
+	if len(rest) < 2 {
+		return TextChunk{}, fmt.Errorf("iTXt chunk %q is too short", chunk.Keyword)
+	}
+	compressed := rest[0] == 1
+	rest = rest[2:]
+	for field := 0; field < 2; field++ {
+		fieldEnd := bytes.IndexByte(rest, 0)
+		if fieldEnd < 0 {
+			return TextChunk{}, fmt.Errorf("iTXt chunk %q is too short", chunk.Keyword)
+		}
+		rest = rest[fieldEnd+1:]
+	}
+	if compressed {
+		text, err := inflate(rest)
+		if err != nil {
+			return TextChunk{}, fmt.Errorf("cannot decompress iTXt chunk %q: %v", chunk.Keyword, err)
+		}
+		rest = text
+	}
+	chunk.Text = string(rest)
+	return chunk, nil
+}
+
+func inflate(compressed []byte) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+// validateKeyword makes sure keyword can be stored: 1 to 79 printable Latin-1 characters.
+func validateKeyword(keyword string) error {
+	if len(keyword) < 1 || len(keyword) > maximumKeywordLength {
+		return fmt.Errorf("png text keyword must be 1 to %d characters long, got %q", maximumKeywordLength, keyword)
+	}
+	for _, character := range []byte(keyword) {
+		if character < 32 || character > 126 {
+			return fmt.Errorf("png text keyword %q must be printable ASCII", keyword)
+		}
+	}
+	return nil
+}
+
+func isASCII(text string) bool {
+	for _, character := range []byte(text) {
+		if character > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+func latin1ToUTF8(latin1 []byte) string {
+	runes := make([]rune, len(latin1))
+	for index, character := range latin1 {
+		runes[index] = rune(character)
+	}
+	return string(runes)
+}
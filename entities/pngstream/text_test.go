@@ -0,0 +1,81 @@
+package pngstream_test
+
+import (
+	"bytes"
+	. "gopkg.in/check.v1"
+	"image"
+	"image/png"
+	"wallpaper/entities/pngstream"
+)
+
+type TextSuite struct {
+	original *image.NRGBA
+	chunks   []pngstream.TextChunk
+}
+
+var _ = Suite(&TextSuite{})
+
+func (suite *TextSuite) SetUpTest(checker *C) {
+	suite.original = image.NewNRGBA(image.Rect(0, 0, 7, 5))
+	for index := range suite.original.Pix {
+		suite.original.Pix[index] = uint8(index * 3)
+	}
+	suite.chunks = []pngstream.TextChunk{
+		{Keyword: "wallpaper:command", Text: "sample_space:\n  minx: -1\n"},
+		{Keyword: "Comment", Text: "colors from the café"},
+	}
+}
+
+func (suite *TextSuite) TestEncodeWithTextStoresTheChunks(checker *C) {
+	var encoded bytes.Buffer
+	err := pngstream.EncodeWithText(&encoded, suite.original, suite.chunks)
+	checker.Assert(err, IsNil)
+
+	chunks, err := pngstream.ReadText(bytes.NewReader(encoded.Bytes()))
+	checker.Assert(err, IsNil)
+	checker.Assert(chunks, DeepEquals, suite.chunks)
+
+	decoded, err := png.Decode(bytes.NewReader(encoded.Bytes()))
+	checker.Assert(err, IsNil)
+	checker.Assert(decoded.(*image.NRGBA).Pix, DeepEquals, suite.original.Pix)
+}
+
+func (suite *TextSuite) TestWriterStoresTheChunks(checker *C) {
+	var encoded bytes.Buffer
+	writer, err := pngstream.NewWriter(&encoded, 7, 5)
+	checker.Assert(err, IsNil)
+	checker.Assert(writer.AddText(suite.chunks...), IsNil)
+	checker.Assert(writer.WriteRows(suite.original), IsNil)
+	checker.Assert(writer.Close(), IsNil)
+
+	chunks, err := pngstream.ReadText(bytes.NewReader(encoded.Bytes()))
+	checker.Assert(err, IsNil)
+	checker.Assert(chunks, DeepEquals, suite.chunks)
+
+	_, err = png.Decode(bytes.NewReader(encoded.Bytes()))
+	checker.Assert(err, IsNil)
+}
+
+func (suite *TextSuite) TestImagesWithoutTextHaveNoChunks(checker *C) {
+	var encoded bytes.Buffer
+	checker.Assert(png.Encode(&encoded, suite.original), IsNil)
+
+	chunks, err := pngstream.ReadText(bytes.NewReader(encoded.Bytes()))
+	checker.Assert(err, IsNil)
+	checker.Assert(chunks, HasLen, 0)
+}
+
+func (suite *TextSuite) TestInvalidKeywordsAreRejected(checker *C) {
+	var encoded bytes.Buffer
+	err := pngstream.EncodeWithText(&encoded, suite.original, []pngstream.TextChunk{{Keyword: "", Text: "x"}})
+	checker.Assert(err, ErrorMatches, ".*1 to 79 characters.*")
+
+	writer, _ := pngstream.NewWriter(&encoded, 7, 5)
+	err = writer.AddText(pngstream.TextChunk{Keyword: "café", Text: "x"})
+	checker.Assert(err, ErrorMatches, ".*printable ASCII")
+}
+
+func (suite *TextSuite) TestReadTextRejectsOtherFiles(checker *C) {
+	_, err := pngstream.ReadText(bytes.NewReader([]byte("GIF89a")))
+	checker.Assert(err, ErrorMatches, "not a png file")
+}
@@ -0,0 +1,289 @@
+package pngstream
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"io"
+)
+
+// maximumIDATSize is the most compressed bytes held before they are flushed as an IDAT chunk.
+const maximumIDATSize = 1 << 16
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// PNG row filter types.
+const (
+	filterNone    = 0
+	filterSub     = 1
+	filterUp      = 2
+	filterAverage = 3
+	filterPaeth   = 4
+)
+
+// Writer encodes an 8 or 16 bit RGBA PNG one strip of rows at a time,
+//   so the whole image never has to be in memory.
+type Writer struct {
+	output        io.Writer
+	width         int
+	height        int
+	bytesPerPixel int
+	rowsWritten   int
+	idat          *idatWriter
+	compressor    *zlib.Writer
+	previousRow   []byte
+	currentRow    []byte
+	filteredRows  [5][]byte
+	text          []TextChunk
+	closed        bool
+}
+
+// NewWriter writes the PNG header for an 8 bit image of the given size and returns a Writer for its rows.
+func NewWriter(output io.Writer, width, height int) (*Writer, error) {
+	return NewWriterWithBitDepth(output, width, height, 8)
+}
+
+// NewWriterWithBitDepth writes the PNG header for an image with 8 or 16 bits per channel
+//   and returns a Writer for its rows.
+func NewWriterWithBitDepth(output io.Writer, width, height, bitDepth int) (*Writer, error) {
+	if width < 1 || height < 1 {
+		return nil, fmt.Errorf("png size must be positive, got %dx%d", width, height)
+	}
+	if bitDepth != 8 && bitDepth != 16 {
+		return nil, fmt.Errorf("png bit depth must be 8 or 16, got %d", bitDepth)
+	}
+
+	_, err := output.Write(pngSignature)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 13)
+	binary.BigEndian.PutUint32(header[0:4], uint32(width))
+	binary.BigEndian.PutUint32(header[4:8], uint32(height))
+	header[8] = byte(bitDepth)
+	header[9] = 6  // color type: truecolor with alpha
+	header[10] = 0 // compression method
+	header[11] = 0 // filter method
+	header[12] = 0 // no interlace
+	err = writeChunk(output, "IHDR", header)
+	if err != nil {
+		return nil, err
+	}
+
+	bytesPerPixel := bitDepth / 2
+	rowLength := 1 + width*bytesPerPixel
+	writer := &Writer{
+		output:        output,
+		width:         width,
+		height:        height,
+		bytesPerPixel: bytesPerPixel,
+		idat:          &idatWriter{output: output},
+		previousRow:   make([]byte, rowLength),
+		currentRow:    make([]byte, rowLength),
+	}
+	for filter := range writer.filteredRows {
+		writer.filteredRows[filter] = make([]byte, rowLength)
+	}
+	writer.compressor = zlib.NewWriter(writer.idat)
+	return writer, nil
+}
+
+// WriteRows compresses every row of strip. Strips must be written top to bottom
+//   and be exactly as wide as the image. 8 bit PNGs take image.NRGBA strips and 16 bit PNGs take image.NRGBA64 strips.
+func (writer *Writer) WriteRows(strip image.Image) error {
+	if writer.closed {
+		return errors.New("png writer is already closed")
+	}
+	var pixels []byte
+	var stride int
+	matchesBitDepth := false
+	switch stripImage := strip.(type) {
+	case *image.NRGBA:
+		pixels, stride, matchesBitDepth = stripImage.Pix, stripImage.Stride, writer.bytesPerPixel == 4
+	case *image.NRGBA64:
+		pixels, stride, matchesBitDepth = stripImage.Pix, stripImage.Stride, writer.bytesPerPixel == 8
+	}
+	if !matchesBitDepth {
+		return fmt.Errorf("a %d bit png cannot write %T strips", writer.bytesPerPixel*2, strip)
+	}
+	bounds := strip.Bounds()
+	if bounds.Dx() != writer.width {
+		return fmt.Errorf("strip is %d pixels wide, png is %d pixels wide", bounds.Dx(), writer.width)
+	}
+	if writer.rowsWritten+bounds.Dy() > writer.height {
+		return fmt.Errorf("png only has %d rows, cannot write %d more after %d", writer.height, bounds.Dy(), writer.rowsWritten)
+	}
+
+	rowLength := writer.width * writer.bytesPerPixel
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		rowStart := (y - bounds.Min.Y) * stride
+		copy(writer.currentRow[1:], pixels[rowStart:rowStart+rowLength])
+
+		_, err := writer.compressor.Write(writer.filterCurrentRow())
+		if err != nil {
+			return err
+		}
+		writer.previousRow, writer.currentRow = writer.currentRow, writer.previousRow
+		writer.rowsWritten++
+	}
+	return nil
+}
+
+// Close finishes the compressed stream and writes any text chunks and the end of the PNG.
+//   Every row must have been written.
+func (writer *Writer) Close() error {
+	if writer.closed {
+		return nil
+	}
+	writer.closed = true
+	if writer.rowsWritten != writer.height {
+		return fmt.Errorf("png has %d rows but only %d were written", writer.height, writer.rowsWritten)
+	}
+
+	err := writer.compressor.Close()
+	if err != nil {
+		return err
+	}
+	err = writer.idat.flush()
+	if err != nil {
+		return err
+	}
+	err = writeTextChunks(writer.output, writer.text)
+	if err != nil {
+		return err
+	}
+	return writeChunk(writer.output, "IEND", nil)
+}
+
+// filterCurrentRow tries every filter and picks the one with the smallest sum of absolute values,
+//   the same heuristic the standard library's encoder uses.
+func (writer *Writer) filterCurrentRow() []byte {
+	bytesPerPixel := writer.bytesPerPixel
+	current := writer.currentRow[1:]
+	previous := writer.previousRow[1:]
+
+	bestFilter := filterNone
+	bestSum := -1
+	for filter := range writer.filteredRows {
+		filtered := writer.filteredRows[filter]
+		filtered[0] = byte(filter)
+		output := filtered[1:]
+		sum := 0
+		for index := range current {
+			var left, above, upperLeft byte
+			if index >= bytesPerPixel {
+				left = current[index-bytesPerPixel]
+				upperLeft = previous[index-bytesPerPixel]
+			}
+			above = previous[index]
+
+			var predictor byte
+			switch filter {
+			case filterSub:
+				predictor = left
+			case filterUp:
+				predictor = above
+			case filterAverage:
+				predictor = byte((int(left) + int(above)) / 2)
+			case filterPaeth:
+				predictor = paeth(left, above, upperLeft)
+			}
+			output[index] = current[index] - predictor
+			sum += absoluteSignedByte(output[index])
+		}
+		if bestSum < 0 || sum < bestSum {
+			bestSum = sum
+			bestFilter = filter
+		}
+	}
+	return writer.filteredRows[bestFilter]
+}
+
+func absoluteSignedByte(value byte) int {
+	signed := int(int8(value))
+	if signed < 0 {
+		return -signed
+	}
+	return signed
+}
+
+func paeth(left, above, upperLeft byte) byte {
+	estimate := int(left) + int(above) - int(upperLeft)
+	distanceLeft := absoluteInt(estimate - int(left))
+	distanceAbove := absoluteInt(estimate - int(above))
+	distanceUpperLeft := absoluteInt(estimate - int(upperLeft))
+	if distanceLeft <= distanceAbove && distanceLeft <= distanceUpperLeft {
+		return left
+	}
+	if distanceAbove <= distanceUpperLeft {
+		return above
+	}
+	return upperLeft
+}
+
+func absoluteInt(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// idatWriter collects compressed bytes and writes them out as IDAT chunks.
+type idatWriter struct {
+	output  io.Writer
+	pending []byte
+}
+
+func (idat *idatWriter) Write(data []byte) (int, error) {
+	idat.pending = append(idat.pending, data...)
+	for len(idat.pending) >= maximumIDATSize {
+		err := writeChunk(idat.output, "IDAT", idat.pending[:maximumIDATSize])
+		if err != nil {
+			return 0, err
+		}
+		idat.pending = append(idat.pending[:0], idat.pending[maximumIDATSize:]...)
+	}
+	return len(data), nil
+}
+
+func (idat *idatWriter) flush() error {
+	if len(idat.pending) == 0 {
+		return nil
+	}
+	err := writeChunk(idat.output, "IDAT", idat.pending)
+	idat.pending = idat.pending[:0]
+	return err
+}
+
+// writeChunk writes a PNG chunk: length, type, data and CRC.
+func writeChunk(output io.Writer, chunkType string, data []byte) error {
+	buffered := bufio.NewWriter(output)
+	lengthAndType := make([]byte, 8)
+	binary.BigEndian.PutUint32(lengthAndType[0:4], uint32(len(data)))
+	copy(lengthAndType[4:8], chunkType)
+
+	checksum := crc32.NewIEEE()
+	checksum.Write(lengthAndType[4:8])
+	checksum.Write(data)
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, checksum.Sum32())
+
+	buffered.Write(lengthAndType)
+	buffered.Write(data)
+	buffered.Write(crc)
+	return buffered.Flush()
+}
+
+// zlibWriterMemory is roughly how much memory a zlib writer uses at the default compression level.
+const zlibWriterMemory = 1 << 19
+
+// WriterMemory estimates how many bytes a Writer for an image this wide and deep holds at once.
+func WriterMemory(width, bitDepth int) int64 {
+	rowLength := int64(1 + width*bitDepth/2)
+	return rowLength*7 + maximumIDATSize + zlibWriterMemory
+}
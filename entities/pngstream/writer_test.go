@@ -0,0 +1,119 @@
+package pngstream_test
+
+import (
+	"bytes"
+	. "gopkg.in/check.v1"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"wallpaper/entities/pngstream"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type WriterSuite struct {
+	original *image.NRGBA
+}
+
+var _ = Suite(&WriterSuite{})
+
+func (suite *WriterSuite) SetUpTest(checker *C) {
+	suite.original = image.NewNRGBA(image.Rect(0, 0, 45, 31))
+	for y := 0; y < 31; y++ {
+		for x := 0; x < 45; x++ {
+			suite.original.Set(x, y, color.NRGBA{R: uint8(x * 5), G: uint8(y * 7), B: uint8(x * y), A: uint8(255 - x)})
+		}
+	}
+}
+
+func (suite *WriterSuite) TestStripsDecodeToTheOriginalImage(checker *C) {
+	var output bytes.Buffer
+	writer, err := pngstream.NewWriter(&output, 45, 31)
+	checker.Assert(err, IsNil)
+
+	for stripStart := 0; stripStart < 31; stripStart += 8 {
+		stripEnd := stripStart + 8
+		if stripEnd > 31 {
+			stripEnd = 31
+		}
+		strip := suite.original.SubImage(image.Rect(0, stripStart, 45, stripEnd)).(*image.NRGBA)
+		checker.Assert(writer.WriteRows(strip), IsNil)
+	}
+	checker.Assert(writer.Close(), IsNil)
+
+	decoded, err := png.Decode(&output)
+	checker.Assert(err, IsNil)
+	checker.Assert(decoded.Bounds(), Equals, suite.original.Bounds())
+	for y := 0; y < 31; y++ {
+		for x := 0; x < 45; x++ {
+			checker.Assert(color.NRGBAModel.Convert(decoded.At(x, y)), Equals, suite.original.NRGBAAt(x, y))
+		}
+	}
+}
+
+func (suite *WriterSuite) TestCloseFailsIfRowsAreMissing(checker *C) {
+	var output bytes.Buffer
+	writer, err := pngstream.NewWriter(&output, 45, 31)
+	checker.Assert(err, IsNil)
+
+	checker.Assert(writer.WriteRows(suite.original.SubImage(image.Rect(0, 0, 45, 10)).(*image.NRGBA)), IsNil)
+	checker.Assert(writer.Close(), ErrorMatches, "png has 31 rows but only 10 were written")
+}
+
+func (suite *WriterSuite) TestStripMustMatchImageWidth(checker *C) {
+	var output bytes.Buffer
+	writer, err := pngstream.NewWriter(&output, 20, 31)
+	checker.Assert(err, IsNil)
+
+	err = writer.WriteRows(suite.original)
+	checker.Assert(err, ErrorMatches, "strip is 45 pixels wide, png is 20 pixels wide")
+}
+
+func (suite *WriterSuite) TestCannotWriteMoreRowsThanTheImageHas(checker *C) {
+	var output bytes.Buffer
+	writer, err := pngstream.NewWriter(&output, 45, 5)
+	checker.Assert(err, IsNil)
+
+	err = writer.WriteRows(suite.original)
+	checker.Assert(err, ErrorMatches, "png only has 5 rows, cannot write 31 more after 0")
+}
+
+func (suite *WriterSuite) TestSixteenBitStripsDecodeToTheOriginalImage(checker *C) {
+	original := image.NewNRGBA64(image.Rect(0, 0, 45, 31))
+	for y := 0; y < 31; y++ {
+		for x := 0; x < 45; x++ {
+			original.SetNRGBA64(x, y, color.NRGBA64{R: uint16(x * 1013), G: uint16(y * 2003), B: uint16(x*y + 1), A: uint16(65535 - x*7)})
+		}
+	}
+
+	var output bytes.Buffer
+	writer, err := pngstream.NewWriterWithBitDepth(&output, 45, 31, 16)
+	checker.Assert(err, IsNil)
+	for stripStart := 0; stripStart < 31; stripStart += 8 {
+		stripEnd := stripStart + 8
+		if stripEnd > 31 {
+			stripEnd = 31
+		}
+		checker.Assert(writer.WriteRows(original.SubImage(image.Rect(0, stripStart, 45, stripEnd))), IsNil)
+	}
+	checker.Assert(writer.Close(), IsNil)
+
+	decoded, err := png.Decode(&output)
+	checker.Assert(err, IsNil)
+	decodedImage, ok := decoded.(*image.NRGBA64)
+	checker.Assert(ok, Equals, true)
+	checker.Assert(decodedImage.Pix, DeepEquals, original.Pix)
+}
+
+func (suite *WriterSuite) TestStripMustMatchBitDepth(checker *C) {
+	var output bytes.Buffer
+	writer, err := pngstream.NewWriterWithBitDepth(&output, 45, 31, 16)
+	checker.Assert(err, IsNil)
+
+	err = writer.WriteRows(suite.original)
+	checker.Assert(err, ErrorMatches, `a 16 bit png cannot write \*image.NRGBA strips`)
+
+	_, err = pngstream.NewWriterWithBitDepth(&output, 45, 31, 4)
+	checker.Assert(err, ErrorMatches, "png bit depth must be 8 or 16, got 4")
+}
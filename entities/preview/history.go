@@ -0,0 +1,84 @@
+package preview
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultDepth is how many previews a History keeps when no depth is given.
+const DefaultDepth = 5
+
+// History keeps the most recent previews of an output image under numbered filenames,
+//   so old previews can be compared with new ones. Preview 1 is the newest.
+type History struct {
+	// Base is the output filename without its extension.
+	Base string
+	// Depth is how many previews are kept. Older previews are deleted.
+	Depth int
+}
+
+// NewHistory returns the preview history for the output filename.
+func NewHistory(outputFilename string, depth int) (*History, error) {
+	if depth < 1 {
+		return nil, fmt.Errorf("preview history must keep at least 1 preview, got %d", depth)
+	}
+	return &History{
+		Base:  strings.TrimSuffix(outputFilename, filepath.Ext(outputFilename)),
+		Depth: depth,
+	}, nil
+}
+
+// Filename returns the name of the preview at the given place in the history: <Base>.preview.<N>.png.
+func (history *History) Filename(place int) string {
+	return fmt.Sprintf("%s.preview.%d.png", history.Base, place)
+}
+
+// PendingFilename is where a new preview is rendered before it is added to the history,
+//   so a failed render leaves the history alone.
+func (history *History) PendingFilename() string {
+	return history.Base + ".preview.new.png"
+}
+
+// IndexFilename is the HTML page that shows the previews.
+func (history *History) IndexFilename() string {
+	return history.Base + ".previews.html"
+}
+
+// Add makes the rendered file the newest preview. Every older preview moves back one place,
+//   and previews that fall past Depth are deleted.
+func (history *History) Add(renderedFilename string) error {
+	for place := history.Depth; fileExists(history.Filename(place)); place++ {
+		err := os.Remove(history.Filename(place))
+		if err != nil {
+			return err
+		}
+	}
+	for place := history.Depth - 1; place >= 1; place-- {
+		if !fileExists(history.Filename(place)) {
+			continue
+		}
+		err := os.Rename(history.Filename(place), history.Filename(place+1))
+		if err != nil {
+			return err
+		}
+	}
+	return os.Rename(renderedFilename, history.Filename(1))
+}
+
+// Previews returns the filenames of the previews that exist, newest first.
+func (history *History) Previews() []string {
+	previews := []string{}
+	for place := 1; place <= history.Depth; place++ {
+		if fileExists(history.Filename(place)) {
+			previews = append(previews, history.Filename(place))
+		}
+	}
+	return previews
+}
+
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
@@ -0,0 +1,103 @@
+package preview_test
+
+import (
+	"bytes"
+	. "gopkg.in/check.v1"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"wallpaper/entities/command"
+	"wallpaper/entities/pngstream"
+	"wallpaper/entities/preview"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type HistorySuite struct {
+	directory string
+	history   *preview.History
+}
+
+var _ = Suite(&HistorySuite{})
+
+func (suite *HistorySuite) SetUpTest(checker *C) {
+	suite.directory = checker.MkDir()
+	var err error
+	suite.history, err = preview.NewHistory(filepath.Join(suite.directory, "poster.jpg"), 3)
+	checker.Assert(err, IsNil)
+}
+
+// addPreview renders a fake preview whose contents are its name.
+func (suite *HistorySuite) addPreview(checker *C, contents string) {
+	checker.Assert(ioutil.WriteFile(suite.history.PendingFilename(), []byte(contents), 0644), IsNil)
+	checker.Assert(suite.history.Add(suite.history.PendingFilename()), IsNil)
+}
+
+func (suite *HistorySuite) contents(checker *C, place int) string {
+	contents, err := ioutil.ReadFile(suite.history.Filename(place))
+	checker.Assert(err, IsNil)
+	return string(contents)
+}
+
+func (suite *HistorySuite) TestFilenamesAreNumberedPNGsNextToTheOutput(checker *C) {
+	checker.Assert(suite.history.Filename(2), Equals, filepath.Join(suite.directory, "poster.preview.2.png"))
+	checker.Assert(suite.history.IndexFilename(), Equals, filepath.Join(suite.directory, "poster.previews.html"))
+}
+
+func (suite *HistorySuite) TestDepthMustBePositive(checker *C) {
+	_, err := preview.NewHistory("poster.png", 0)
+	checker.Assert(err, ErrorMatches, "preview history must keep at least 1 preview, got 0")
+}
+
+func (suite *HistorySuite) TestNewestPreviewIsFirstAndTheOldestAreDropped(checker *C) {
+	for _, name := range []string{"a", "b", "c", "d"} {
+		suite.addPreview(checker, name)
+	}
+
+	checker.Assert(suite.contents(checker, 1), Equals, "d")
+	checker.Assert(suite.contents(checker, 2), Equals, "c")
+	checker.Assert(suite.contents(checker, 3), Equals, "b")
+	_, err := os.Stat(suite.history.Filename(4))
+	checker.Assert(os.IsNotExist(err), Equals, true)
+	_, err = os.Stat(suite.history.PendingFilename())
+	checker.Assert(os.IsNotExist(err), Equals, true)
+	checker.Assert(suite.history.Previews(), HasLen, 3)
+}
+
+func (suite *HistorySuite) TestShrinkingTheDepthDeletesTheExtraPreviews(checker *C) {
+	for _, name := range []string{"a", "b", "c"} {
+		suite.addPreview(checker, name)
+	}
+	suite.history.Depth = 1
+	suite.addPreview(checker, "d")
+
+	checker.Assert(suite.history.Previews(), DeepEquals, []string{suite.history.Filename(1)})
+	checker.Assert(suite.contents(checker, 1), Equals, "d")
+	_, err := os.Stat(suite.history.Filename(2))
+	checker.Assert(os.IsNotExist(err), Equals, true)
+}
+
+func (suite *HistorySuite) TestIndexShowsEveryPreviewWithItsCommand(checker *C) {
+	var encoded bytes.Buffer
+	err := pngstream.EncodeWithText(&encoded, image.NewNRGBA(image.Rect(0, 0, 2, 2)), []pngstream.TextChunk{
+		{Keyword: command.RecipeKeywordCommand, Text: "output_filename: <poster>.png\n"},
+	})
+	checker.Assert(err, IsNil)
+	checker.Assert(ioutil.WriteFile(suite.history.PendingFilename(), encoded.Bytes(), 0644), IsNil)
+	checker.Assert(suite.history.Add(suite.history.PendingFilename()), IsNil)
+	suite.addPreview(checker, "not a png")
+
+	checker.Assert(suite.history.WriteIndex(), IsNil)
+	index, err := ioutil.ReadFile(suite.history.IndexFilename())
+	checker.Assert(err, IsNil)
+	page := string(index)
+
+	checker.Assert(strings.Contains(page, `<img src="poster.preview.1.png?`), Equals, true)
+	checker.Assert(strings.Contains(page, `<img src="poster.preview.2.png?`), Equals, true)
+	checker.Assert(strings.Index(page, "poster.preview.1.png") < strings.Index(page, "poster.preview.2.png"), Equals, true)
+	checker.Assert(strings.Contains(page, "output_filename: &lt;poster&gt;.png"), Equals, true)
+	checker.Assert(strings.Count(page, "<details>"), Equals, 1)
+}
@@ -0,0 +1,108 @@
+package preview
+
+import (
+	"bufio"
+	"html/template"
+	"os"
+	"path/filepath"
+	"time"
+	"wallpaper/entities/command"
+	"wallpaper/entities/pngstream"
+)
+
+// indexEntry describes one preview on the index page.
+type indexEntry struct {
+	Place    int
+	Filename string
+	// Source links to the image. It changes whenever the file does, so browsers do not show a cached older preview.
+	Source   string
+	Rendered string
+	// Command is the YAML recipe stored in the preview, if it has one.
+	Command string
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Previews of {{.Title}}</title>
+<style>
+body { font-family: sans-serif; background: #202020; color: #e0e0e0; }
+.previews { display: flex; flex-wrap: wrap; gap: 16px; }
+figure { margin: 0; background: #303030; padding: 8px; }
+img { display: block; max-width: 200px; max-height: 200px; image-rendering: pixelated; }
+pre { max-width: 400px; max-height: 300px; overflow: auto; font-size: 11px; }
+</style>
+</head>
+<body>
+<h1>Previews of {{.Title}}</h1>
+<div class="previews">
+{{range .Entries}}<figure>
+<a href="{{.Source}}"><img src="{{.Source}}" alt="{{.Filename}}"></a>
+<figcaption>#{{.Place}} {{.Filename}}<br>{{.Rendered}}</figcaption>
+{{if .Command}}<details><summary>command</summary><pre>{{.Command}}</pre></details>{{end}}
+</figure>
+{{end}}</div>
+</body>
+</html>
+`))
+
+// WriteIndex writes an HTML page that shows every preview in the history, newest first, with the command that rendered it.
+//   The page links to the images by relative path, so it works offline as long as it stays next to them.
+func (history *History) WriteIndex() error {
+	entries := []indexEntry{}
+	for place := 1; place <= history.Depth; place++ {
+		filename := history.Filename(place)
+		info, err := os.Stat(filename)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, indexEntry{
+			Place:    place,
+			Filename: filepath.Base(filename),
+			Source:   filepath.Base(filename) + "?" + info.ModTime().Format("20060102150405.000000000"),
+			Rendered: info.ModTime().Format(time.RFC1123),
+			Command:  storedCommand(filename),
+		})
+	}
+
+	indexFile, err := os.Create(history.IndexFilename())
+	if err != nil {
+		return err
+	}
+	bufferedOutput := bufio.NewWriter(indexFile)
+	err = indexTemplate.Execute(bufferedOutput, struct {
+		Title   string
+		Entries []indexEntry
+	}{
+		Title:   filepath.Base(history.Base),
+		Entries: entries,
+	})
+	if err == nil {
+		err = bufferedOutput.Flush()
+	}
+	if err != nil {
+		indexFile.Close()
+		return err
+	}
+	return indexFile.Close()
+}
+
+// storedCommand returns the command recorded in the preview's recipe, or an empty string if it cannot be read.
+func storedCommand(filename string) string {
+	previewFile, err := os.Open(filename)
+	if err != nil {
+		return ""
+	}
+	defer previewFile.Close()
+	chunks, err := pngstream.ReadText(previewFile)
+	if err != nil {
+		return ""
+	}
+	for _, chunk := range chunks {
+		if chunk.Keyword == command.RecipeKeywordCommand {
+			return chunk.Text
+		}
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"wallpaper/entities/field"
+	"wallpaper/entities/formula"
+)
+
+// CalculateField calculates the formula for every pixel in settings.OutputBounds and keeps the results,
+//   so the image can be colored later without calculating the formula again.
+//   If ctx is cancelled, ctx's error is returned instead of an unfinished field.
+func CalculateField(ctx context.Context, settings Settings, hash field.Hash) (*field.Field, *formula.ResultStatistics, error) {
+	bounds := settings.OutputBounds
+	valueField := field.New(bounds.Dx(), bounds.Dy(), settings.SamplesPerPixel(), hash)
+	statistics, err := settings.processRows(ctx, bounds, settings.newProgressTracker(bounds.Dy()), func(x, y int, transformedValues []complex128) {
+		copy(valueField.PixelValues(x-bounds.Min.X, y-bounds.Min.Y), transformedValues)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return valueField, statistics, nil
+}
+
+// ColorizeField colors every pixel in destination using the values in valueField. The formula is not used.
+//   valueField must be the same size as settings.OutputBounds. Its sub-samples are used instead of settings.Supersample.
+//   If ctx is cancelled, ctx's error is returned with an unfinished image.
+func ColorizeField(ctx context.Context, settings Settings, valueField *field.Field, destination draw.Image) error {
+	bounds := settings.OutputBounds
+	if valueField.Width != bounds.Dx() || valueField.Height != bounds.Dy() {
+		return fmt.Errorf("field is %dx%d but the output is %dx%d", valueField.Width, valueField.Height, bounds.Dx(), bounds.Dy())
+	}
+
+	region := destination.Bounds()
+	return settings.forEachRow(ctx, region, settings.newProgressTracker(region.Dy()), func(row image.Rectangle) {
+		for x := row.Min.X; x < row.Max.X; x++ {
+			settings.colorPixel(destination, x, row.Min.Y, valueField.PixelValues(x-bounds.Min.X, row.Min.Y-bounds.Min.Y))
+		}
+	})
+}
+
+// SampleValues calculates the formula at every stride-th pixel across and down settings.OutputBounds.
+//   It shows which values the formula produces without holding the whole field in memory.
+//   If ctx is cancelled, ctx's error is returned instead of the unfinished values.
+func SampleValues(ctx context.Context, settings Settings, stride int) ([]complex128, error) {
+	if stride < 1 {
+		stride = 1
+	}
+	bounds := settings.OutputBounds
+	columns := (bounds.Dx() + stride - 1) / stride
+	rows := (bounds.Dy() + stride - 1) / stride
+
+	values := make([]complex128, columns*rows)
+	err := settings.forEachRow(ctx, image.Rect(0, 0, columns, rows), settings.newProgressTracker(rows), func(chunk image.Rectangle) {
+		row := chunk.Min.Y
+		for column := chunk.Min.X; column < chunk.Max.X; column++ {
+			samplePoint := settings.SamplePoint(bounds.Min.X+column*stride, bounds.Min.Y+row*stride)
+			values[row*columns+column] = settings.transformedValue(settings.Formula.Calculate(samplePoint))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
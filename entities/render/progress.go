@@ -0,0 +1,67 @@
+package render
+
+import (
+	"sync"
+	"time"
+)
+
+// Progress reports how many rows of a render are done.
+type Progress struct {
+	RowsDone  int `json:"rows_done"`
+	TotalRows int `json:"total_rows"`
+	// Elapsed is the time since the render started.
+	Elapsed time.Duration `json:"elapsed_nanoseconds"`
+	// Remaining estimates the time left from the speed so far. It is 0 until the first rows are done.
+	Remaining time.Duration `json:"remaining_nanoseconds"`
+}
+
+// Fraction returns how much of the render is done, from 0 to 1.
+func (progress Progress) Fraction() float64 {
+	if progress.TotalRows < 1 {
+		return 0
+	}
+	return float64(progress.RowsDone) / float64(progress.TotalRows)
+}
+
+// progressTracker counts finished rows and reports them to Settings.Progress.
+type progressTracker struct {
+	report    func(Progress)
+	lock      sync.Mutex
+	started   time.Time
+	rowsDone  int
+	totalRows int
+}
+
+// newProgressTracker starts counting rows for a render of totalRows rows.
+//   It returns nil if nobody is listening, and a nil tracker ignores every row.
+func (settings Settings) newProgressTracker(totalRows int) *progressTracker {
+	if settings.Progress == nil {
+		return nil
+	}
+	return &progressTracker{
+		report:    settings.Progress,
+		started:   time.Now(),
+		totalRows: totalRows,
+	}
+}
+
+// addRows counts finished rows and reports the new progress. Reports are made one at a time, in order.
+func (tracker *progressTracker) addRows(rows int) {
+	if tracker == nil || rows < 1 {
+		return
+	}
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	tracker.rowsDone += rows
+	progress := Progress{
+		RowsDone:  tracker.rowsDone,
+		TotalRows: tracker.totalRows,
+		Elapsed:   time.Since(tracker.started),
+	}
+	if progress.RowsDone < progress.TotalRows {
+		rowsLeft := progress.TotalRows - progress.RowsDone
+		progress.Remaining = time.Duration(float64(progress.Elapsed) * float64(rowsLeft) / float64(progress.RowsDone))
+	}
+	tracker.report(progress)
+}
@@ -0,0 +1,275 @@
+package render
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/cmplx"
+	"runtime"
+	"sync"
+	"wallpaper/entities/colorizer"
+	"wallpaper/entities/formula"
+	"wallpaper/entities/mathutility"
+)
+
+// DefaultRowsPerChunk is how many rows a worker renders at a time when Settings.RowsPerChunk is not set.
+const DefaultRowsPerChunk = 16
+
+// Settings describes how to turn output pixels into colors.
+type Settings struct {
+	// Formula transforms sample points. It must already be SetUp.
+	Formula formula.Formula
+	// Colorizer turns transformed values into colors.
+	Colorizer colorizer.Colorizer
+	// OutputBounds covers the entire output image. Pixels are scaled from here into the sample space.
+	OutputBounds   image.Rectangle
+	SampleSpaceMin complex128
+	SampleSpaceMax complex128
+	// Workers is the number of goroutines to use. 0 or less uses every CPU.
+	Workers int
+	// RowsPerChunk is the number of rows each worker renders at a time. 0 or less uses DefaultRowsPerChunk.
+	RowsPerChunk int
+	// Supersample is the number of sub-samples across and down each pixel. 0 or 1 takes a single sample.
+	Supersample int
+	// Jitter moves each sub-sample to a random spot in its cell instead of the cell's center.
+	//   A pixel's spots are the same every time it is rendered.
+	Jitter bool
+	// ValueTerm colors this term's contribution instead of the formula's total. nil colors the total.
+	//   Pixels whose formula has no such term are NaN.
+	ValueTerm *int
+	// Progress is called as rows finish, one call at a time. It must return quickly. nil reports nothing.
+	Progress func(Progress)
+}
+
+// SamplePoint scales the output pixel at (x, y) into the sample space.
+func (settings Settings) SamplePoint(x, y int) complex128 {
+	sampleX := mathutility.ScaleValueBetweenTwoRanges(
+		float64(x),
+		float64(settings.OutputBounds.Min.X),
+		float64(settings.OutputBounds.Max.X),
+		real(settings.SampleSpaceMin),
+		real(settings.SampleSpaceMax),
+	)
+	sampleY := mathutility.ScaleValueBetweenTwoRanges(
+		float64(y),
+		float64(settings.OutputBounds.Min.Y),
+		float64(settings.OutputBounds.Max.Y),
+		imag(settings.SampleSpaceMin),
+		imag(settings.SampleSpaceMax),
+	)
+	return complex(sampleX, sampleY)
+}
+
+// SamplesPerPixel returns how many sample points each output pixel uses.
+func (settings Settings) SamplesPerPixel() int {
+	if settings.Supersample < 2 {
+		return 1
+	}
+	return settings.Supersample * settings.Supersample
+}
+
+// PixelSamplePoints fills samplePoints with the sample points for the output pixel at (x, y).
+//   samplePoints must hold SamplesPerPixel points. Without supersampling the only point is SamplePoint(x, y),
+//   otherwise the points cover a grid of cells centered on it.
+func (settings Settings) PixelSamplePoints(x, y int, samplePoints []complex128) {
+	if settings.Supersample < 2 {
+		samplePoints[0] = settings.SamplePoint(x, y)
+		return
+	}
+
+	gridSize := settings.Supersample
+	for row := 0; row < gridSize; row++ {
+		for column := 0; column < gridSize; column++ {
+			subsample := row*gridSize + column
+			offsetX, offsetY := 0.5, 0.5
+			if settings.Jitter {
+				offsetX, offsetY = jitterOffsets(x, y, subsample)
+			}
+			samplePoints[subsample] = settings.subpixelSamplePoint(
+				float64(x)+(float64(column)+offsetX)/float64(gridSize)-0.5,
+				float64(y)+(float64(row)+offsetY)/float64(gridSize)-0.5,
+			)
+		}
+	}
+}
+
+// subpixelSamplePoint scales a point that may be between output pixels into the sample space.
+func (settings Settings) subpixelSamplePoint(x, y float64) complex128 {
+	bounds := settings.OutputBounds
+	sampleX := real(settings.SampleSpaceMin) +
+		(x-float64(bounds.Min.X))/float64(bounds.Dx())*(real(settings.SampleSpaceMax)-real(settings.SampleSpaceMin))
+	sampleY := imag(settings.SampleSpaceMin) +
+		(y-float64(bounds.Min.Y))/float64(bounds.Dy())*(imag(settings.SampleSpaceMax)-imag(settings.SampleSpaceMin))
+	return complex(sampleX, sampleY)
+}
+
+// jitterOffsets returns a repeatable pseudo random spot from [0, 1) x [0, 1) for one of a pixel's sub-samples.
+func jitterOffsets(x, y, subsample int) (float64, float64) {
+	seed := uint64(uint32(x))<<32 | uint64(uint32(y))
+	first := splitMix64(seed ^ uint64(subsample)*0x9e3779b97f4a7c15)
+	second := splitMix64(first)
+	return float64(first>>11) / (1 << 53), float64(second>>11) / (1 << 53)
+}
+
+// splitMix64 scrambles value into a well distributed pseudo random number.
+func splitMix64(value uint64) uint64 {
+	value += 0x9e3779b97f4a7c15
+	value = (value ^ (value >> 30)) * 0xbf58476d1ce4e5b9
+	value = (value ^ (value >> 27)) * 0x94d049bb133111eb
+	return value ^ (value >> 31)
+}
+
+// Render colors every pixel in destination. destination's bounds must be inside settings.OutputBounds,
+//   so a strip of rows can be rendered by passing an image that only covers those rows.
+//   image.NRGBA and image.NRGBA64 destinations are written directly, others through their Set method.
+//   If ctx is cancelled the workers stop after their current row, and ctx's error is returned with an unfinished image.
+func Render(ctx context.Context, settings Settings, destination draw.Image) (*formula.ResultStatistics, error) {
+	return settings.render(ctx, destination, settings.newProgressTracker(destination.Bounds().Dy()))
+}
+
+func (settings Settings) render(ctx context.Context, destination draw.Image, tracker *progressTracker) (*formula.ResultStatistics, error) {
+	return settings.processRows(ctx, destination.Bounds(), tracker, func(x, y int, transformedValues []complex128) {
+		settings.colorPixel(destination, x, y, transformedValues)
+	})
+}
+
+// colorPixel sets the destination pixel at (x, y) to the color of its transformed values.
+func (settings Settings) colorPixel(destination draw.Image, x, y int, transformedValues []complex128) {
+	pixelColor := settings.pixelColor(transformedValues)
+	switch destinationImage := destination.(type) {
+	case *image.NRGBA:
+		offset := destinationImage.PixOffset(x, y)
+		destinationImage.Pix[offset+0] = uint8(pixelColor.R >> 8)
+		destinationImage.Pix[offset+1] = uint8(pixelColor.G >> 8)
+		destinationImage.Pix[offset+2] = uint8(pixelColor.B >> 8)
+		destinationImage.Pix[offset+3] = uint8(pixelColor.A >> 8)
+	case *image.NRGBA64:
+		offset := destinationImage.PixOffset(x, y)
+		for channel, value := range [4]uint16{pixelColor.R, pixelColor.G, pixelColor.B, pixelColor.A} {
+			destinationImage.Pix[offset+channel*2] = uint8(value >> 8)
+			destinationImage.Pix[offset+channel*2+1] = uint8(value)
+		}
+	default:
+		destination.Set(x, y, pixelColor)
+	}
+}
+
+// NewImage returns an image to render into with 8 or 16 bits per channel.
+func NewImage(bounds image.Rectangle, bitDepth int) draw.Image {
+	if bitDepth == 16 {
+		return image.NewNRGBA64(bounds)
+	}
+	return image.NewNRGBA(bounds)
+}
+
+// pixelColor colors a pixel's transformed values. Several values are averaged in linear light.
+func (settings Settings) pixelColor(transformedValues []complex128) color.NRGBA64 {
+	if len(transformedValues) == 1 {
+		return settings.Colorizer.ColorAt(transformedValues[0])
+	}
+	average := colorizer.LinearColorAverage{}
+	for _, transformedValue := range transformedValues {
+		average.Add(settings.Colorizer.ColorAt(transformedValue))
+	}
+	return average.Color()
+}
+
+// Analyze calculates the formula over all of settings.OutputBounds without coloring anything.
+func Analyze(ctx context.Context, settings Settings) (*formula.ResultStatistics, error) {
+	bounds := settings.OutputBounds
+	return settings.processRows(ctx, bounds, settings.newProgressTracker(bounds.Dy()), func(x, y int, transformedValues []complex128) {})
+}
+
+// processRows splits region into chunks of rows and calculates each chunk on a worker goroutine.
+//   usePixel is called once per pixel with the formula's total at each of the pixel's sample points.
+//   usePixel must not keep transformedValues, it is reused for the next pixel.
+func (settings Settings) processRows(ctx context.Context, region image.Rectangle, tracker *progressTracker, usePixel func(x, y int, transformedValues []complex128)) (*formula.ResultStatistics, error) {
+	statistics := &formula.ResultStatistics{}
+	var statisticsLock sync.Mutex
+	err := settings.forEachRow(ctx, region, tracker, func(row image.Rectangle) {
+		rowStatistics := settings.processChunk(row, usePixel)
+
+		statisticsLock.Lock()
+		statistics.Merge(rowStatistics)
+		statisticsLock.Unlock()
+	})
+	return statistics, err
+}
+
+// forEachRow splits region into chunks of rows and hands each chunk to a worker goroutine,
+//   which processes the chunk one row at a time and reports each finished row to tracker.
+//   It returns once every row is processed. If ctx is cancelled, the workers stop after their current row
+//   and ctx's error is returned.
+func (settings Settings) forEachRow(ctx context.Context, region image.Rectangle, tracker *progressTracker, processRow func(row image.Rectangle)) error {
+	rowsPerChunk := settings.RowsPerChunk
+	if rowsPerChunk < 1 {
+		rowsPerChunk = DefaultRowsPerChunk
+	}
+	workers := settings.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	chunkStarts := make(chan int)
+	go func() {
+		defer close(chunkStarts)
+		for chunkStart := region.Min.Y; chunkStart < region.Max.Y; chunkStart += rowsPerChunk {
+			select {
+			case chunkStarts <- chunkStart:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var waitForWorkers sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		waitForWorkers.Add(1)
+		go func() {
+			defer waitForWorkers.Done()
+			for chunkStart := range chunkStarts {
+				chunkEnd := chunkStart + rowsPerChunk
+				if chunkEnd > region.Max.Y {
+					chunkEnd = region.Max.Y
+				}
+				for y := chunkStart; y < chunkEnd && ctx.Err() == nil; y++ {
+					processRow(image.Rect(region.Min.X, y, region.Max.X, y+1))
+					tracker.addRows(1)
+				}
+			}
+		}()
+	}
+	waitForWorkers.Wait()
+	return ctx.Err()
+}
+
+// processChunk goes from pixel to sample point to formula value for every pixel in the chunk.
+func (settings Settings) processChunk(chunk image.Rectangle, usePixel func(x, y int, transformedValues []complex128)) *formula.ResultStatistics {
+	chunkStatistics := &formula.ResultStatistics{}
+	samplePoints := make([]complex128, settings.SamplesPerPixel())
+	transformedValues := make([]complex128, len(samplePoints))
+	for y := chunk.Min.Y; y < chunk.Max.Y; y++ {
+		for x := chunk.Min.X; x < chunk.Max.X; x++ {
+			settings.PixelSamplePoints(x, y, samplePoints)
+			for index, samplePoint := range samplePoints {
+				formulaResult := settings.Formula.Calculate(samplePoint)
+				chunkStatistics.Add(formulaResult)
+				transformedValues[index] = settings.transformedValue(formulaResult)
+			}
+			usePixel(x, y, transformedValues)
+		}
+	}
+	return chunkStatistics
+}
+
+// transformedValue picks the part of the formula's result that gets colored.
+func (settings Settings) transformedValue(formulaResult *formula.CalculationResultForFormula) complex128 {
+	if settings.ValueTerm == nil {
+		return formulaResult.Total
+	}
+	if *settings.ValueTerm < len(formulaResult.ContributionByTerm) {
+		return formulaResult.ContributionByTerm[*settings.ValueTerm]
+	}
+	return cmplx.NaN()
+}
@@ -0,0 +1,418 @@
+package render_test
+
+import (
+	"context"
+	. "gopkg.in/check.v1"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/cmplx"
+	"testing"
+	"time"
+	"wallpaper/entities/colorizer"
+	"wallpaper/entities/field"
+	"wallpaper/entities/formula"
+	"wallpaper/entities/formula/exponential"
+	"wallpaper/entities/formula/rosette"
+	"wallpaper/entities/mathutility"
+	"wallpaper/entities/render"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type RenderSuite struct {
+	settings render.Settings
+}
+
+var _ = Suite(&RenderSuite{})
+
+func (suite *RenderSuite) SetUpTest(checker *C) {
+	source := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			source.Set(x, y, color.NRGBA{R: uint8(x * 16), G: uint8(y * 16), B: uint8(x * y), A: 255})
+		}
+	}
+
+	suite.settings = render.Settings{
+		Formula: &rosette.Formula{
+			Terms: []*exponential.RosetteFriezeTerm{
+				{
+					Multiplier: complex(1, 0),
+					PowerN:     3,
+					PowerM:     0,
+				},
+				{
+					Multiplier: complex(0.5, 0.5),
+					PowerN:     -1,
+					PowerM:     2,
+				},
+			},
+		},
+		Colorizer: &colorizer.SourceImage{
+			Source:        source,
+			ValueSpaceMin: complex(-2, -2),
+			ValueSpaceMax: complex(2, 2),
+		},
+		OutputBounds:   image.Rect(0, 0, 37, 23),
+		SampleSpaceMin: complex(-1.5, -1),
+		SampleSpaceMax: complex(1.5, 1),
+	}
+}
+
+// renderSerially is the reference: every pixel in order, one goroutine.
+//   It repeats the original scale, calculate and color steps by hand so it does not lean on
+//   Settings.SamplePoint or the colorizer it is checking.
+func (suite *RenderSuite) renderSerially() *image.NRGBA {
+	outputBounds := suite.settings.OutputBounds
+	sampleSpaceMin := suite.settings.SampleSpaceMin
+	sampleSpaceMax := suite.settings.SampleSpaceMax
+	sourceImage := suite.settings.Colorizer.(*colorizer.SourceImage)
+	colorValueBoundMin := sourceImage.ValueSpaceMin
+	colorValueBoundMax := sourceImage.ValueSpaceMax
+	sourceImageBounds := sourceImage.Source.Bounds()
+
+	destination := image.NewNRGBA(outputBounds)
+	for y := outputBounds.Min.Y; y < outputBounds.Max.Y; y++ {
+		for x := outputBounds.Min.X; x < outputBounds.Max.X; x++ {
+			scaledCoordinate := complex(
+				mathutility.ScaleValueBetweenTwoRanges(
+					float64(x),
+					float64(outputBounds.Min.X),
+					float64(outputBounds.Max.X),
+					real(sampleSpaceMin),
+					real(sampleSpaceMax),
+				),
+				mathutility.ScaleValueBetweenTwoRanges(
+					float64(y),
+					float64(outputBounds.Min.Y),
+					float64(outputBounds.Max.Y),
+					imag(sampleSpaceMin),
+					imag(sampleSpaceMax),
+				),
+			)
+			transformedCoordinate := suite.settings.Formula.Calculate(scaledCoordinate).Total
+
+			var sourceColorR, sourceColorG, sourceColorB, sourceColorA uint32
+			if real(transformedCoordinate) < real(colorValueBoundMin) ||
+				imag(transformedCoordinate) < imag(colorValueBoundMin) ||
+				real(transformedCoordinate) > real(colorValueBoundMax) ||
+				imag(transformedCoordinate) > imag(colorValueBoundMax) {
+				sourceColorR, sourceColorG, sourceColorB, sourceColorA = 0, 0, 0, 0
+			} else {
+				sourceImagePixelX := int(mathutility.ScaleValueBetweenTwoRanges(
+					real(transformedCoordinate),
+					real(colorValueBoundMin),
+					real(colorValueBoundMax),
+					float64(sourceImageBounds.Min.X),
+					float64(sourceImageBounds.Max.X),
+				))
+				sourceImagePixelY := int(mathutility.ScaleValueBetweenTwoRanges(
+					imag(transformedCoordinate),
+					imag(colorValueBoundMin),
+					imag(colorValueBoundMax),
+					float64(sourceImageBounds.Min.Y),
+					float64(sourceImageBounds.Max.Y),
+				))
+				sourceColorR, sourceColorG, sourceColorB, sourceColorA = sourceImage.Source.At(sourceImagePixelX, sourceImagePixelY).RGBA()
+			}
+
+			destination.Set(x, y, color.NRGBA{
+				R: uint8(sourceColorR >> 8),
+				G: uint8(sourceColorG >> 8),
+				B: uint8(sourceColorB >> 8),
+				A: uint8(sourceColorA >> 8),
+			})
+		}
+	}
+	return destination
+}
+
+func (suite *RenderSuite) TestSamplePointScalesCorners(checker *C) {
+	checker.Assert(suite.settings.SamplePoint(0, 0), Equals, complex(-1.5, -1))
+	checker.Assert(suite.settings.SamplePoint(37, 23), Equals, complex(1.5, 1))
+}
+
+func (suite *RenderSuite) TestParallelRenderMatchesSerialRender(checker *C) {
+	expected := suite.renderSerially()
+
+	for _, workers := range []int{1, 2, 7} {
+		for _, rowsPerChunk := range []int{1, 4, 100} {
+			suite.settings.Workers = workers
+			suite.settings.RowsPerChunk = rowsPerChunk
+			destination := image.NewNRGBA(suite.settings.OutputBounds)
+			_, err := render.Render(context.Background(), suite.settings, destination)
+			checker.Assert(err, IsNil)
+			checker.Assert(destination.Pix, DeepEquals, expected.Pix)
+		}
+	}
+}
+
+func (suite *RenderSuite) TestRenderStripOfRows(checker *C) {
+	expected := suite.renderSerially()
+
+	strip := image.NewNRGBA(image.Rect(0, 10, 37, 15))
+	_, err := render.Render(context.Background(), suite.settings, strip)
+	checker.Assert(err, IsNil)
+
+	for y := 10; y < 15; y++ {
+		for x := 0; x < 37; x++ {
+			checker.Assert(strip.NRGBAAt(x, y), Equals, expected.NRGBAAt(x, y))
+		}
+	}
+}
+
+func (suite *RenderSuite) TestAnalyzeReportsStatisticsForEachTerm(checker *C) {
+	results := []*formula.CalculationResultForFormula{}
+	for y := 0; y < 23; y++ {
+		for x := 0; x < 37; x++ {
+			results = append(results, suite.settings.Formula.Calculate(suite.settings.SamplePoint(x, y)))
+		}
+	}
+	expected := formula.NewResultStatistics(results)
+
+	suite.settings.Workers = 3
+	statistics, err := render.Analyze(context.Background(), suite.settings)
+	checker.Assert(err, IsNil)
+
+	checker.Assert(statistics.ByTerm, HasLen, 2)
+	checker.Assert(statistics.Total.Count, Equals, 37*23)
+	checker.Assert(statistics.Total.Min, Equals, expected.Total.Min)
+	checker.Assert(statistics.Total.Max, Equals, expected.Total.Max)
+	checker.Assert(statistics.ByTerm[1].Min, Equals, expected.ByTerm[1].Min)
+	checker.Assert(statistics.ByTerm[1].Max, Equals, expected.ByTerm[1].Max)
+}
+
+func (suite *RenderSuite) TestStreamMatchesRender(checker *C) {
+	expected := suite.renderSerially()
+
+	streamed := image.NewNRGBA(suite.settings.OutputBounds)
+	stripsWritten := 0
+	_, err := render.Stream(context.Background(), suite.settings, 6, 8, func(strip draw.Image) error {
+		checker.Assert(strip.Bounds().Dy() <= 6, Equals, true)
+		stripImage := strip.(*image.NRGBA)
+		for y := strip.Bounds().Min.Y; y < strip.Bounds().Max.Y; y++ {
+			for x := strip.Bounds().Min.X; x < strip.Bounds().Max.X; x++ {
+				streamed.SetNRGBA(x, y, stripImage.NRGBAAt(x, y))
+			}
+		}
+		stripsWritten++
+		return nil
+	})
+
+	checker.Assert(err, IsNil)
+	checker.Assert(stripsWritten, Equals, 4)
+	checker.Assert(streamed.Pix, DeepEquals, expected.Pix)
+}
+
+func (suite *RenderSuite) TestRowsPerStripForBudget(checker *C) {
+	checker.Assert(render.RowsPerStripForBudget(100, 8, 4000), Equals, 10)
+	checker.Assert(render.RowsPerStripForBudget(100, 16, 4000), Equals, 5)
+	checker.Assert(render.RowsPerStripForBudget(100, 8, 10), Equals, 1)
+	checker.Assert(render.RowsPerStripForBudget(100, 8, -10), Equals, 1)
+}
+
+func (suite *RenderSuite) TestSixteenBitRenderKeepsTheExtraPrecision(checker *C) {
+	expected := suite.renderSerially()
+
+	sixteenBit := render.NewImage(suite.settings.OutputBounds, 16).(*image.NRGBA64)
+	_, err := render.Render(context.Background(), suite.settings, sixteenBit)
+	checker.Assert(err, IsNil)
+	for y := 0; y < 23; y++ {
+		for x := 0; x < 37; x++ {
+			pixel := sixteenBit.NRGBA64At(x, y)
+			checker.Assert(uint8(pixel.R>>8), Equals, expected.NRGBAAt(x, y).R)
+			checker.Assert(uint8(pixel.A>>8), Equals, expected.NRGBAAt(x, y).A)
+		}
+	}
+
+	streamed := image.NewNRGBA64(suite.settings.OutputBounds)
+	_, err = render.Stream(context.Background(), suite.settings, 5, 16, func(strip draw.Image) error {
+		draw.Draw(streamed, strip.Bounds(), strip, strip.Bounds().Min, draw.Src)
+		return nil
+	})
+	checker.Assert(err, IsNil)
+	checker.Assert(streamed.Pix, DeepEquals, sixteenBit.Pix)
+}
+
+func (suite *RenderSuite) TestColorizeFieldMatchesRender(checker *C) {
+	expected := suite.renderSerially()
+
+	valueField, statistics, err := render.CalculateField(context.Background(), suite.settings, field.Hash{1})
+	checker.Assert(err, IsNil)
+	checker.Assert(statistics.ByTerm, HasLen, 2)
+	checker.Assert(valueField.Width, Equals, 37)
+	checker.Assert(valueField.Height, Equals, 23)
+
+	suite.settings.Formula = nil
+	colorized := image.NewNRGBA(suite.settings.OutputBounds)
+	err = render.ColorizeField(context.Background(), suite.settings, valueField, colorized)
+	checker.Assert(err, IsNil)
+	checker.Assert(colorized.Pix, DeepEquals, expected.Pix)
+}
+
+func (suite *RenderSuite) TestColorizeFieldRejectsTheWrongSize(checker *C) {
+	valueField := field.New(10, 10, 1, field.Hash{})
+	err := render.ColorizeField(context.Background(), suite.settings, valueField, image.NewNRGBA(suite.settings.OutputBounds))
+	checker.Assert(err, ErrorMatches, "field is 10x10 but the output is 37x23")
+}
+
+func (suite *RenderSuite) TestSampleValuesSkipsPixelsByStride(checker *C) {
+	values, err := render.SampleValues(context.Background(), suite.settings, 10)
+	checker.Assert(err, IsNil)
+	checker.Assert(values, HasLen, 4*3)
+	checker.Assert(values[0], Equals, suite.settings.Formula.Calculate(suite.settings.SamplePoint(0, 0)).Total)
+	checker.Assert(values[4+2], Equals, suite.settings.Formula.Calculate(suite.settings.SamplePoint(20, 10)).Total)
+}
+
+func (suite *RenderSuite) TestValueTermUsesThatTermsContribution(checker *C) {
+	secondTerm := 1
+	suite.settings.ValueTerm = &secondTerm
+	values, _ := render.SampleValues(context.Background(), suite.settings, 10)
+	checker.Assert(values[4+2], Equals, suite.settings.Formula.Calculate(suite.settings.SamplePoint(20, 10)).ContributionByTerm[1])
+
+	missingTerm := 2
+	suite.settings.ValueTerm = &missingTerm
+	values, _ = render.SampleValues(context.Background(), suite.settings, 10)
+	checker.Assert(cmplx.IsNaN(values[0]), Equals, true)
+}
+
+func (suite *RenderSuite) TestSampleStrideForBudget(checker *C) {
+	checker.Assert(render.SampleStrideForBudget(image.Rect(0, 0, 100, 100), 32*10000), Equals, 1)
+	checker.Assert(render.SampleStrideForBudget(image.Rect(0, 0, 100, 100), 32*2500), Equals, 2)
+	checker.Assert(render.SampleStrideForBudget(image.Rect(0, 0, 100, 100), 0), Equals, 100)
+}
+
+func (suite *RenderSuite) TestWithoutSupersamplingTheOnlySampleIsThePixel(checker *C) {
+	samplePoints := make([]complex128, suite.settings.SamplesPerPixel())
+	suite.settings.PixelSamplePoints(5, 7, samplePoints)
+	checker.Assert(samplePoints, DeepEquals, []complex128{suite.settings.SamplePoint(5, 7)})
+}
+
+func (suite *RenderSuite) TestSupersampleGridSurroundsThePixel(checker *C) {
+	suite.settings.OutputBounds = image.Rect(0, 0, 10, 10)
+	suite.settings.SampleSpaceMin = complex(0, 0)
+	suite.settings.SampleSpaceMax = complex(10, 20)
+	suite.settings.Supersample = 2
+	checker.Assert(suite.settings.SamplesPerPixel(), Equals, 4)
+
+	samplePoints := make([]complex128, 4)
+	suite.settings.PixelSamplePoints(3, 4, samplePoints)
+	checker.Assert(samplePoints, DeepEquals, []complex128{
+		complex(2.75, 7.5), complex(3.25, 7.5),
+		complex(2.75, 8.5), complex(3.25, 8.5),
+	})
+}
+
+func (suite *RenderSuite) TestJitteredSubsamplesStayInTheirCellAndRepeat(checker *C) {
+	suite.settings.OutputBounds = image.Rect(0, 0, 10, 10)
+	suite.settings.SampleSpaceMin = complex(0, 0)
+	suite.settings.SampleSpaceMax = complex(10, 10)
+	suite.settings.Supersample = 3
+	suite.settings.Jitter = true
+
+	samplePoints := make([]complex128, 9)
+	repeatedPoints := make([]complex128, 9)
+	suite.settings.PixelSamplePoints(6, 2, samplePoints)
+	suite.settings.PixelSamplePoints(6, 2, repeatedPoints)
+	checker.Assert(repeatedPoints, DeepEquals, samplePoints)
+
+	for index, samplePoint := range samplePoints {
+		cellLeft := 5.5 + float64(index%3)/3
+		cellTop := 1.5 + float64(index/3)/3
+		checker.Assert(real(samplePoint) >= cellLeft && real(samplePoint) < cellLeft+1.0/3, Equals, true)
+		checker.Assert(imag(samplePoint) >= cellTop && imag(samplePoint) < cellTop+1.0/3, Equals, true)
+	}
+}
+
+func (suite *RenderSuite) TestSupersampledRenderAveragesEverySubsample(checker *C) {
+	suite.settings.Supersample = 2
+	destination := image.NewNRGBA(suite.settings.OutputBounds)
+	statistics, err := render.Render(context.Background(), suite.settings, destination)
+	checker.Assert(err, IsNil)
+	checker.Assert(statistics.Total.Count+statistics.Total.InfCount+statistics.Total.NaNCount, Equals, 37*23*4)
+
+	samplePoints := make([]complex128, 4)
+	suite.settings.PixelSamplePoints(11, 9, samplePoints)
+	average := colorizer.LinearColorAverage{}
+	for _, samplePoint := range samplePoints {
+		average.Add(suite.settings.Colorizer.ColorAt(suite.settings.Formula.Calculate(samplePoint).Total))
+	}
+	expected := average.Color()
+	checker.Assert(destination.NRGBAAt(11, 9), Equals, color.NRGBA{
+		R: uint8(expected.R >> 8),
+		G: uint8(expected.G >> 8),
+		B: uint8(expected.B >> 8),
+		A: uint8(expected.A >> 8),
+	})
+
+	valueField, _, err := render.CalculateField(context.Background(), suite.settings, field.Hash{})
+	checker.Assert(err, IsNil)
+	checker.Assert(valueField.SamplesPerPixel, Equals, 4)
+	colorized := image.NewNRGBA(suite.settings.OutputBounds)
+	checker.Assert(render.ColorizeField(context.Background(), suite.settings, valueField, colorized), IsNil)
+	checker.Assert(colorized.Pix, DeepEquals, destination.Pix)
+}
+
+func (suite *RenderSuite) TestCancelledRenderStopsWithoutColoringAnything(checker *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cancelled := image.NewNRGBA(suite.settings.OutputBounds)
+	statistics, err := render.Render(ctx, suite.settings, cancelled)
+	checker.Assert(err, Equals, context.Canceled)
+	checker.Assert(statistics.Total.Count, Equals, 0)
+	checker.Assert(cancelled.Pix, DeepEquals, image.NewNRGBA(suite.settings.OutputBounds).Pix)
+
+	stripsWritten := 0
+	_, err = render.Stream(ctx, suite.settings, 5, 8, func(strip draw.Image) error {
+		stripsWritten++
+		return nil
+	})
+	checker.Assert(err, Equals, context.Canceled)
+	checker.Assert(stripsWritten, Equals, 0)
+
+	_, _, err = render.CalculateField(ctx, suite.settings, field.Hash{})
+	checker.Assert(err, Equals, context.Canceled)
+}
+
+func (suite *RenderSuite) TestCancellingPartWayStopsAfterTheCurrentRow(checker *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	suite.settings.Workers = 1
+	suite.settings.RowsPerChunk = 100
+	reports := 0
+	suite.settings.Progress = func(progress render.Progress) {
+		reports++
+		cancel()
+	}
+
+	destination := image.NewNRGBA(suite.settings.OutputBounds)
+	_, err := render.Render(ctx, suite.settings, destination)
+	checker.Assert(err, Equals, context.Canceled)
+	checker.Assert(reports, Equals, 1)
+	secondRow := destination.Pix[destination.PixOffset(0, 1):destination.PixOffset(0, 2)]
+	checker.Assert(secondRow, DeepEquals, make([]uint8, len(secondRow)))
+}
+
+func (suite *RenderSuite) TestProgressCountsEveryRowOnce(checker *C) {
+	reports := []render.Progress{}
+	suite.settings.Workers = 3
+	suite.settings.RowsPerChunk = 4
+	suite.settings.Progress = func(progress render.Progress) {
+		reports = append(reports, progress)
+	}
+
+	_, err := render.Render(context.Background(), suite.settings, image.NewNRGBA(suite.settings.OutputBounds))
+	checker.Assert(err, IsNil)
+	checker.Assert(reports, HasLen, 23)
+	for index, progress := range reports {
+		checker.Assert(progress.TotalRows, Equals, 23)
+		if index > 0 {
+			checker.Assert(progress.RowsDone > reports[index-1].RowsDone, Equals, true)
+		}
+	}
+	last := reports[len(reports)-1]
+	checker.Assert(last.RowsDone, Equals, 23)
+	checker.Assert(last.Fraction(), Equals, 1.0)
+	checker.Assert(last.Remaining, Equals, time.Duration(0))
+}
@@ -0,0 +1,89 @@
+package render
+
+import (
+	"context"
+	"image"
+	"image/draw"
+	"wallpaper/entities/formula"
+)
+
+// BytesPerPixel is the size of one pixel in an image from NewImage with the given bit depth.
+func BytesPerPixel(bitDepth int) int {
+	if bitDepth == 16 {
+		return 8
+	}
+	return 4
+}
+
+// RowsPerStripForBudget returns how many rows of an image this wide and deep fit in memoryBudget bytes.
+//   At least 1 row is always returned, even if the budget is too small.
+func RowsPerStripForBudget(width, bitDepth int, memoryBudget int64) int {
+	if width < 1 {
+		return 1
+	}
+	rows := memoryBudget / int64(width*BytesPerPixel(bitDepth))
+	if rows < 1 {
+		return 1
+	}
+	return int(rows)
+}
+
+// Stream renders settings.OutputBounds one strip of rows at a time, top to bottom.
+//   Each strip is handed to writeStrip before the next one is rendered, and the strip's memory is reused,
+//   so only rowsPerStrip rows are ever held at once. Strips are image.NRGBA, or image.NRGBA64 if bitDepth is 16.
+//   If ctx is cancelled, ctx's error is returned without writing the unfinished strip.
+func Stream(ctx context.Context, settings Settings, rowsPerStrip, bitDepth int, writeStrip func(strip draw.Image) error) (*formula.ResultStatistics, error) {
+	if rowsPerStrip < 1 {
+		rowsPerStrip = 1
+	}
+	bounds := settings.OutputBounds
+	if rowsPerStrip > bounds.Dy() {
+		rowsPerStrip = bounds.Dy()
+	}
+
+	statistics := &formula.ResultStatistics{}
+	tracker := settings.newProgressTracker(bounds.Dy())
+	bytesPerPixel := BytesPerPixel(bitDepth)
+	stripPixels := make([]uint8, rowsPerStrip*bounds.Dx()*bytesPerPixel)
+	for stripStart := bounds.Min.Y; stripStart < bounds.Max.Y; stripStart += rowsPerStrip {
+		stripEnd := stripStart + rowsPerStrip
+		if stripEnd > bounds.Max.Y {
+			stripEnd = bounds.Max.Y
+		}
+		stripBounds := image.Rect(bounds.Min.X, stripStart, bounds.Max.X, stripEnd)
+		pixels := stripPixels[:stripBounds.Dx()*stripBounds.Dy()*bytesPerPixel]
+		var strip draw.Image = &image.NRGBA{Pix: pixels, Stride: stripBounds.Dx() * bytesPerPixel, Rect: stripBounds}
+		if bitDepth == 16 {
+			strip = &image.NRGBA64{Pix: pixels, Stride: stripBounds.Dx() * bytesPerPixel, Rect: stripBounds}
+		}
+
+		stripStatistics, err := settings.render(ctx, strip, tracker)
+		statistics.Merge(stripStatistics)
+		if err != nil {
+			return statistics, err
+		}
+		err = writeStrip(strip)
+		if err != nil {
+			return statistics, err
+		}
+	}
+	return statistics, nil
+}
+
+// bytesPerSampledValue is the memory each value from SampleValues needs, counting the sorted copies of its parts.
+const bytesPerSampledValue = 32
+
+// SampleStrideForBudget returns the smallest stride for SampleValues whose values roughly fit in memoryBudget bytes.
+//   At least 1 value is always sampled.
+func SampleStrideForBudget(bounds image.Rectangle, memoryBudget int64) int {
+	maximumValues := memoryBudget / bytesPerSampledValue
+	if maximumValues < 1 {
+		maximumValues = 1
+	}
+	pixels := int64(bounds.Dx()) * int64(bounds.Dy())
+	stride := int64(1)
+	for pixels > maximumValues*stride*stride {
+		stride++
+	}
+	return int(stride)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"wallpaper/entities/formula"
+	"wallpaper/entities/formula/catalog"
+	"wallpaper/entities/formula/coefficient"
+)
+
+// printGroups lists every symmetry group in the catalog, or describes the named groups in detail.
+//   The names were checked when the command line was parsed.
+func printGroups(output io.Writer, groupNames []string) error {
+	if len(groupNames) == 0 {
+		return listGroups(output)
+	}
+	described := 0
+	for _, name := range groupNames {
+		groups, err := catalog.Find(name)
+		if err != nil {
+			return err
+		}
+		for _, group := range groups {
+			if described > 0 {
+				fmt.Fprintln(output, "")
+			}
+			describeGroup(output, group)
+			described++
+		}
+	}
+	return nil
+}
+
+// listGroups writes a table of each family of groups.
+func listGroups(output io.Writer) error {
+	table := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "Wallpaper groups:")
+	fmt.Fprintln(table, "  name\tHermann–Mauguin\torbifold\tlattices")
+	for _, group := range catalog.Wallpapers() {
+		fmt.Fprintf(table, "  %s\t%s\t%s\t%s\n", group.Name, group.HermannMauguin, group.Orbifold, describeLattices(group.Lattices))
+	}
+	fmt.Fprintln(table, "")
+	fmt.Fprintln(table, "Frieze groups:")
+	fmt.Fprintln(table, "  name\tIUC\torbifold\tConway")
+	for _, group := range catalog.Friezes() {
+		fmt.Fprintf(table, "  %s\t%s\t%s\t%s\n", group.Name, group.IUC, group.Orbifold, group.Conway)
+	}
+	fmt.Fprintln(table, "")
+	fmt.Fprintln(table, "Rosette groups:")
+	fmt.Fprintln(table, "  name\tHermann–Mauguin\torbifold\tsymmetry")
+	fmt.Fprintln(table, "  Cn\tn\tn•\tturns by 360/n degrees")
+	fmt.Fprintln(table, "  Dn\tnm or nmm\t*n•\tturns by 360/n degrees and has a mirror")
+	err := table.Flush()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(output, "")
+	fmt.Fprintln(output, "Run 'wallpaper groups <name>' with any of these names to see a group's generators and coefficient relationships.")
+	return nil
+}
+
+// describeGroup writes everything the catalog knows about the group.
+func describeGroup(output io.Writer, group *catalog.Group) {
+	fmt.Fprintf(output, "%s (%s group)\n", group.Name, group.Family)
+	fmt.Fprintf(output, "  Hermann–Mauguin: %s\n", group.HermannMauguin)
+	fmt.Fprintf(output, "  IUC: %s\n", group.IUC)
+	fmt.Fprintf(output, "  orbifold: %s\n", group.Orbifold)
+	if group.Conway != "" {
+		fmt.Fprintf(output, "  Conway: %s\n", group.Conway)
+	}
+	if len(group.Lattices) > 0 {
+		fmt.Fprintf(output, "  lattices: %s\n", describeLattices(group.Lattices))
+	}
+	if len(group.LockedRelationships) > 0 {
+		fmt.Fprintf(output, "  locked relationships: %s\n", describeRelationships(group.LockedRelationships))
+	}
+	if len(group.Relationships) > 0 {
+		fmt.Fprintf(output, "  coefficient relationships: %s\n", describeRelationships(group.Relationships))
+	}
+	generators := group.DescribeGenerators()
+	if len(generators) > 0 {
+		fmt.Fprintln(output, "  generators, in lattice coordinates:")
+		for _, generator := range generators {
+			fmt.Fprintf(output, "    %s\n", generator)
+		}
+	}
+}
+
+// describeLattices joins the lattice shapes with commas.
+func describeLattices(lattices []formula.LatticeType) string {
+	names := []string{}
+	for _, lattice := range lattices {
+		names = append(names, string(lattice))
+	}
+	return strings.Join(names, ", ")
+}
+
+// describeRelationships joins the coefficient relationships with commas.
+func describeRelationships(relationships []coefficient.Relationship) string {
+	names := []string{}
+	for _, relationship := range relationships {
+		names = append(names, string(relationship))
+	}
+	return strings.Join(names, ", ")
+}
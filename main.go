@@ -1,485 +1,691 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"image"
 	"image/color"
-	"image/png"
+	"image/draw"
 	"io/ioutil"
-	"log"
+	"strings"
+	"wallpaper/entities/colorizer"
 	"wallpaper/entities/command"
-	"wallpaper/entities/formula/frieze"
-	"wallpaper/entities/formula/rosette"
-	"wallpaper/entities/formula/wavepacket"
+	"wallpaper/entities/encoder"
+	"wallpaper/entities/field"
+	"wallpaper/entities/formula"
+	"wallpaper/entities/mathutility"
+	"wallpaper/entities/pngstream"
+	"wallpaper/entities/render"
 
 	_ "image/png"
 	"os"
-	"wallpaper/entities/mathutility"
+	"os/signal"
 )
 
 func main() {
-	createWallpaperYAML, err := ioutil.ReadFile("data/formula.yml")
-	if err != nil {
-		log.Fatal(err)
-	}
-	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML(createWallpaperYAML)
-	if err != nil {
-		log.Fatal(err)
-	}
-	sampleSpaceMin := complex(wallpaperCommand.SampleSpace.MinX, wallpaperCommand.SampleSpace.MinY)
-	sampleSpaceMax := complex(wallpaperCommand.SampleSpace.MaxX, wallpaperCommand.SampleSpace.MaxY)
-	outputWidth := wallpaperCommand.OutputImageSize.Width
-	outputHeight := wallpaperCommand.OutputImageSize.Height
-	colorSourceFilename := wallpaperCommand.SampleSourceFilename
-	outputFilename := wallpaperCommand.OutputFilename
-	colorValueBoundMin := complex(wallpaperCommand.ColorValueSpace.MinX, wallpaperCommand.ColorValueSpace.MinY)
-	colorValueBoundMax := complex(wallpaperCommand.ColorValueSpace.MaxX, wallpaperCommand.ColorValueSpace.MaxY)
+	os.Exit(run(os.Args[1:]))
+}
 
-	//sampleSpaceMin := complex(-1e0, -1e0)
-	//sampleSpaceMax := complex(1e0, 1e0)
-	////outputWidth := 800
-	////outputHeight := 450
-	//outputWidth := 3840
-	//outputHeight := 2160
-	//colorSourceFilename := "exampleImage/brownie.png"
-	//outputFilename := "exampleImage/newBrownie.png"
-	//colorValueBoundMin := complex(-2e5, -2e5)
-	//colorValueBoundMax := complex(5e5, 5e5)
+// exitError is an error that ends the program with a specific exit code.
+type exitError struct {
+	exitCode int
+	err      error
+}
 
-	reader, err := os.Open(colorSourceFilename)
-	if err != nil {
-	  log.Fatal(err)
-	}
-	defer reader.Close()
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
 
-	colorSourceImage, _, err := image.Decode(reader)
-	if err != nil {
-		log.Fatal(err)
+// invalidConfigError marks err as a problem with the wallpaper's settings.
+func invalidConfigError(err error) error {
+	if err == nil {
+		return nil
 	}
-
-	destinationBounds := image.Rect(0,0, outputWidth, outputHeight)
-	destinationCoordinates := flattenCoordinates(destinationBounds)
-
-	scaledCoordinates := scaleDestinationPixels(
-		destinationBounds,
-		destinationCoordinates,
-		sampleSpaceMin,
-		sampleSpaceMax,
-	)
-
-	transformedCoordinates := transformCoordinatesForFormula(wallpaperCommand, scaledCoordinates)
-	minz, maxz := mathutility.GetBoundingBox(transformedCoordinates)
-	println(minz)
-	println(maxz)
-
-	// Consider how to give a preview image? What's the picture ration
-	outputImage := image.NewNRGBA(image.Rect(0, 0, outputWidth, outputHeight))
-	colorDestinationImage(outputImage, colorSourceImage, destinationCoordinates, transformedCoordinates, colorValueBoundMin, colorValueBoundMax)
-
-	outputToFile(outputFilename, outputImage)
+	return &exitError{exitCode: exitCodeInvalidConfig, err: err}
 }
 
-func outputToFile(outputFilename string, outputImage image.Image) {
-	outputImageFile, err := os.Create(outputFilename)
-	if err != nil {
-		panic(err)
+// fileError marks err as a problem reading or writing a file. A nil err stays nil.
+func fileError(err error) error {
+	if err == nil {
+		return nil
 	}
-	defer outputImageFile.Close()
-	png.Encode(outputImageFile, outputImage)
+	return &exitError{exitCode: exitCodeFileError, err: err}
 }
 
-func transformCoordinatesForFormula(command *command.CreateWallpaperCommand, scaledCoordinates []complex128) []complex128 {
-	if command.FriezeFormula != nil {
-		return transformCoordinatesForFriezeFormula(command.FriezeFormula, scaledCoordinates)
+// run executes the command line and returns the process exit code.
+func run(args []string) int {
+	options, err := parseCommandLine(args, os.Stderr)
+	if err == errUsage {
+		return exitCodeSuccess
 	}
-	if command.RosetteFormula != nil {
-		return transformCoordinatesForRosetteFormula(command.RosetteFormula, scaledCoordinates)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeUsage
 	}
-	if command.HexagonalWallpaperFormula != nil {
-		return transformCoordinatesForHexagonalWallpaperFormula(command.HexagonalWallpaperFormula, scaledCoordinates)
+
+	ctx, cancel := cancelOnInterrupt()
+	defer cancel()
+	progress := newProgressLine()
+	options.progress = progress.reporter(options.subcommand)
+
+	err = runSubcommand(ctx, options, progress)
+	progress.end()
+	if err == nil {
+		return exitCodeSuccess
 	}
-	if command.SquareWallpaperFormula != nil {
-		return transformCoordinatesForSquareWallpaperFormula(command.SquareWallpaperFormula, scaledCoordinates)
+	if err == context.Canceled {
+		fmt.Fprintln(os.Stderr, "interrupted")
+		return exitCodeFailure
 	}
-	if command.RhombicWallpaperFormula != nil {
-		return transformCoordinatesForRhombicWallpaperFormula(command.RhombicWallpaperFormula, scaledCoordinates)
+	fmt.Fprintln(os.Stderr, err)
+	if exitErr, ok := err.(*exitError); ok {
+		return exitErr.exitCode
 	}
-	if command.RectangularWallpaperFormula != nil {
-		return transformCoordinatesForRectangularWallpaperFormula(command.RectangularWallpaperFormula, scaledCoordinates)
+	return exitCodeFailure
+}
+
+// cancelOnInterrupt returns a context that is cancelled by the first Ctrl-C, so renders stop and clean up.
+//   A second Ctrl-C kills the program as usual.
+func cancelOnInterrupt() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		select {
+		case <-interrupts:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(interrupts)
+	}()
+	return ctx, cancel
+}
+
+// runSubcommand loads the config file, or the recipe to reproduce, and runs the chosen subcommand.
+//   watch loads the config file itself, every time it changes, serve renders the configs posted to it,
+//   batch loads every config it is given and groups needs no config. Cancelling ctx stops the render in progress.
+func runSubcommand(ctx context.Context, options *commandLineOptions, progress *progressLine) error {
+	switch options.subcommand {
+	case subcommandWatch:
+		return watchWallpaper(ctx, options)
+	case subcommandServe:
+		return serveWallpapers(ctx, options)
+	case subcommandBatch:
+		return batchWallpapers(ctx, options, progress)
+	case subcommandGroups:
+		return printGroups(os.Stdout, options.groupNames)
+	}
+	wallpaperCommand, err := loadCommand(options)
+	if err != nil {
+		return err
 	}
-	if command.GenericWallpaperFormula != nil {
-		return transformCoordinatesForGenericWallpaperFormula(command.GenericWallpaperFormula, scaledCoordinates)
+	wallpaperCommand.ApplyOverrides(options.overrides)
+
+	switch options.subcommand {
+	case subcommandAnalyze:
+		return analyzeWallpaper(ctx, wallpaperCommand, options)
+	case subcommandColorize:
+		return colorizeWallpaper(ctx, wallpaperCommand, options)
+	case subcommandPreview:
+		return previewWallpaper(ctx, wallpaperCommand, options)
+	default:
+		return renderWallpaper(ctx, wallpaperCommand, options)
 	}
-	log.Fatal(errors.New("no formula found"))
-	return []complex128{}
 }
 
-func transformCoordinatesForFriezeFormula(friezeFormula *frieze.Formula, scaledCoordinates []complex128) []complex128 {
-	symmetryAnalysis := friezeFormula.AnalyzeForSymmetry()
-	if symmetryAnalysis.P111 {
-		println("Has these symmetries: p111")
+// loadCommand reads the command from the config file, or from the recipe in the image being reproduced.
+func loadCommand(options *commandLineOptions) (*command.CreateWallpaperCommand, error) {
+	if options.subcommand == subcommandReproduce {
+		wallpaperCommand, err := loadRecipeCommand(options.recipeFilename)
+		if err != nil {
+			return nil, err
+		}
+		wallpaperCommand.OutputFilename = reproducedFilename(options.recipeFilename, wallpaperCommand)
+		return wallpaperCommand, nil
 	}
-	if symmetryAnalysis.P211 {
-		println("  P211")
+
+	createWallpaperYAML, err := ioutil.ReadFile(options.configFilename)
+	if err != nil {
+		return nil, fileError(err)
 	}
-	if symmetryAnalysis.P1m1 {
-		println("  P1m1")
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML(createWallpaperYAML)
+	if err != nil {
+		return nil, invalidConfigError(fmt.Errorf("cannot parse %s: %v", options.configFilename, err))
 	}
-	if symmetryAnalysis.P11g {
-		println("  P11g")
+	return wallpaperCommand, nil
+}
+
+// analyzeWallpaper reports the symmetries and value statistics of the formula without making an image.
+//   If the color value space is automatic, the bounds it would choose are reported too.
+func analyzeWallpaper(ctx context.Context, wallpaperCommand *command.CreateWallpaperCommand, options *commandLineOptions) error {
+	renderSettings, err := renderSettingsForCommand(wallpaperCommand, options)
+	if err != nil {
+		return err
 	}
-	if symmetryAnalysis.P11m {
-		println("  P11m")
+	err = setUpFormula(wallpaperCommand, renderSettings)
+	if err != nil {
+		return err
 	}
-	if symmetryAnalysis.P2mm {
-		println("  P2mm")
+
+	if !choosesColorValueSpace(wallpaperCommand) {
+		statistics, err := render.Analyze(ctx, *renderSettings)
+		if err != nil {
+			return err
+		}
+		printStatistics(statistics)
+		return nil
 	}
-	if symmetryAnalysis.P2mg {
-		println("  P2mg")
+	valueField, statistics, err := render.CalculateField(ctx, *renderSettings, wallpaperCommand.FieldHash())
+	if err != nil {
+		return err
 	}
+	printStatistics(statistics)
+	return chooseColorValueSpace(wallpaperCommand, valueField.Values)
+}
 
-	transformedCoordinates := []complex128{}
-	resultsByTerm := [][]complex128{}
-	for range friezeFormula.Terms {
-		resultsByTerm = append(resultsByTerm, []complex128{})
+// renderWallpaper transforms the source image with the formula and writes the output image.
+//   If the command has a field file, the formula's values are saved there,
+//   and reused instead of calculated when the formula and sample space have not changed.
+//   Cancelling ctx stops the render without writing the output image.
+func renderWallpaper(ctx context.Context, wallpaperCommand *command.CreateWallpaperCommand, options *commandLineOptions) error {
+	if options.memoryBudget > 0 && wallpaperCommand.FieldFilename != "" {
+		return invalidConfigError(errors.New("a memory budget cannot be used with a field file, the field holds every pixel in memory"))
 	}
 
-	for _, complexCoordinate := range scaledCoordinates {
-		friezeResults := friezeFormula.Calculate(complexCoordinate)
-		for index, formulaResult := range friezeResults.ContributionByTerm {
-			resultsByTerm[index] = append(resultsByTerm[index], formulaResult)
-		}
-
-		transformedCoordinate := friezeResults.Total
-		transformedCoordinates = append(transformedCoordinates, transformedCoordinate)
+	outputFormat, outputOptions, err := outputEncoding(wallpaperCommand)
+	if err != nil {
+		return err
+	}
+	if options.memoryBudget > 0 && outputFormat.Name != "png" {
+		return invalidConfigError(fmt.Errorf("a memory budget can only stream png output, not %s", outputFormat.Name))
 	}
 
-	println("Min/Max ranges, by Term")
-	for index, results := range resultsByTerm {
-		minz, maxz := mathutility.GetBoundingBox(results)
-		fmt.Printf("%d: %e - %e\n", index, minz, maxz)
+	renderSettings, err := renderSettingsForCommand(wallpaperCommand, options)
+	if err != nil {
+		return err
+	}
+	commandColorizer, err := colorizerForCommand(wallpaperCommand)
+	if err != nil {
+		return err
 	}
-	return transformedCoordinates
-}
 
-func transformCoordinatesForRosetteFormula(rosetteFormula *rosette.Formula, scaledCoordinates []complex128) []complex128 {
-	transformedCoordinates := []complex128{}
-	resultsByTerm := [][]complex128{}
-	for range rosetteFormula.Terms {
-		resultsByTerm = append(resultsByTerm, []complex128{})
+	valueField, err := loadCachedField(wallpaperCommand, renderSettings)
+	if err != nil {
+		return err
 	}
+	if valueField == nil {
+		err = setUpFormula(wallpaperCommand, renderSettings)
+		if err != nil {
+			return err
+		}
 
-	for _, complexCoordinate := range scaledCoordinates {
-		rosetteResults := rosetteFormula.Calculate(complexCoordinate)
-		for index, formulaResult := range rosetteResults.ContributionByTerm {
-			resultsByTerm[index] = append(resultsByTerm[index], formulaResult)
+		if options.memoryBudget > 0 {
+			if choosesColorValueSpace(wallpaperCommand) {
+				stride := render.SampleStrideForBudget(renderSettings.OutputBounds, options.memoryBudget/2)
+				sampledValues, err := render.SampleValues(ctx, *renderSettings, stride)
+				if err != nil {
+					return err
+				}
+				err = chooseColorValueSpace(wallpaperCommand, sampledValues)
+				if err != nil {
+					return err
+				}
+			}
+			renderSettings.Colorizer = useColorValueSpace(wallpaperCommand, commandColorizer)
+			return streamToFile(ctx, wallpaperCommand, *renderSettings, outputOptions.BitDepth, options.memoryBudget)
 		}
 
-		transformedCoordinate := rosetteResults.Total
-		transformedCoordinates = append(transformedCoordinates, transformedCoordinate)
+		if wallpaperCommand.FieldFilename == "" && !choosesColorValueSpace(wallpaperCommand) {
+			renderSettings.Colorizer = useColorValueSpace(wallpaperCommand, commandColorizer)
+			outputImage := render.NewImage(renderSettings.OutputBounds, outputOptions.BitDepth)
+			statistics, err := render.Render(ctx, *renderSettings, outputImage)
+			if err != nil {
+				return err
+			}
+			printStatistics(statistics)
+			return outputToFile(wallpaperCommand, outputImage)
+		}
+
+		var statistics *formula.ResultStatistics
+		valueField, statistics, err = render.CalculateField(ctx, *renderSettings, wallpaperCommand.FieldHash())
+		if err != nil {
+			return err
+		}
+		printStatistics(statistics)
+		if wallpaperCommand.FieldFilename != "" {
+			err = valueField.Save(wallpaperCommand.FieldFilename)
+			if err != nil {
+				return fileError(err)
+			}
+		}
 	}
 
-	println("Min/Max ranges, by Term")
-	for index, results := range resultsByTerm {
-		minz, maxz := mathutility.GetBoundingBox(results)
-		fmt.Printf("%d: %e - %e\n", index, minz, maxz)
+	if choosesColorValueSpace(wallpaperCommand) {
+		err = chooseColorValueSpace(wallpaperCommand, valueField.Values)
+		if err != nil {
+			return err
+		}
 	}
-	return transformedCoordinates
+	renderSettings.Colorizer = useColorValueSpace(wallpaperCommand, commandColorizer)
+	return colorizeFieldToFile(ctx, wallpaperCommand, *renderSettings, valueField)
 }
 
-func transformCoordinatesForHexagonalWallpaperFormula(wallpaperFormula *wavepacket.HexagonalWallpaperFormula, scaledCoordinates []complex128) []complex128 {
-	wallpaperFormula.SetUp()
-
-	transformedCoordinates := []complex128{}
-	resultsByTerm := [][]complex128{}
-	for range wallpaperFormula.Formula.WavePackets {
-		resultsByTerm = append(resultsByTerm, []complex128{})
+// loadCachedField returns the command's field file if it matches the formula, sample space and output size.
+//   It returns nil if there is no field file or it is out of date.
+func loadCachedField(wallpaperCommand *command.CreateWallpaperCommand, renderSettings *render.Settings) (*field.Field, error) {
+	if wallpaperCommand.FieldFilename == "" {
+		return nil, nil
+	}
+	cachedField, err := field.LoadIfMatches(
+		wallpaperCommand.FieldFilename,
+		wallpaperCommand.FieldHash(),
+		renderSettings.OutputBounds.Dx(),
+		renderSettings.OutputBounds.Dy(),
+	)
+	if err != nil {
+		return nil, fileError(err)
 	}
-
-	for _, complexCoordinate := range scaledCoordinates {
-		hexagonalWallpaperResults := wallpaperFormula.Calculate(complexCoordinate)
-		for index, formulaResult := range hexagonalWallpaperResults.ContributionByTerm {
-			resultsByTerm[index] = append(resultsByTerm[index], formulaResult)
-		}
-
-		transformedCoordinate := hexagonalWallpaperResults.Total
-		transformedCoordinates = append(transformedCoordinates, transformedCoordinate)
+	if cachedField != nil {
+		fmt.Printf("Reusing the field in %s\n", wallpaperCommand.FieldFilename)
 	}
+	return cachedField, nil
+}
 
-	println("Min/Max ranges, by Term")
-	for index, results := range resultsByTerm {
-		minz, maxz := mathutility.GetBoundingBox(results)
-		fmt.Printf("%d: %e - %e\n", index, minz, maxz)
+// colorizeWallpaper colors a saved field without calculating the formula.
+//   The output is the same size as the field.
+func colorizeWallpaper(ctx context.Context, wallpaperCommand *command.CreateWallpaperCommand, options *commandLineOptions) error {
+	if wallpaperCommand.FieldFilename == "" {
+		return invalidConfigError(errors.New("colorize needs a field file, set field_filename or use -field"))
 	}
-
-	println("Symmetries found:")
-
-	if wallpaperFormula.HasSymmetry(wavepacket.P31m) {
-		println("  p31m")
+	savedField, err := field.Load(wallpaperCommand.FieldFilename)
+	if err != nil {
+		return fileError(err)
 	}
-	if wallpaperFormula.HasSymmetry(wavepacket.P3m1) {
-		println("  p3m1")
+	_, _, err = outputEncoding(wallpaperCommand)
+	if err != nil {
+		return err
 	}
-	if wallpaperFormula.HasSymmetry(wavepacket.P6) {
-		println("  p6")
+	wallpaperCommand.OutputImageSize.Width = savedField.Width
+	wallpaperCommand.OutputImageSize.Height = savedField.Height
+	if savedField.Hash != wallpaperCommand.FieldHash() {
+		fmt.Fprintf(os.Stderr, "warning: %s was made with a different formula or sample space than %s\n", wallpaperCommand.FieldFilename, options.configFilename)
 	}
-	if wallpaperFormula.HasSymmetry(wavepacket.P6m) {
-		println("  p6m")
+
+	renderSettings, err := renderSettingsForCommand(wallpaperCommand, options)
+	if err != nil {
+		return err
 	}
-	if wallpaperFormula.HasSymmetry(wavepacket.P3) {
-		println("  p3")
+	commandColorizer, err := colorizerForCommand(wallpaperCommand)
+	if err != nil {
+		return err
 	}
-
-	return transformedCoordinates
+	if choosesColorValueSpace(wallpaperCommand) {
+		err = chooseColorValueSpace(wallpaperCommand, savedField.Values)
+		if err != nil {
+			return err
+		}
+	}
+	renderSettings.Colorizer = useColorValueSpace(wallpaperCommand, commandColorizer)
+	return colorizeFieldToFile(ctx, wallpaperCommand, *renderSettings, savedField)
 }
 
-func transformCoordinatesForSquareWallpaperFormula(wallpaperFormula *wavepacket.SquareWallpaperFormula, scaledCoordinates []complex128) []complex128 {
-	wallpaperFormula.SetUp()
+// choosesColorValueSpace returns true if the command's color value space is chosen from the transformed values.
+func choosesColorValueSpace(wallpaperCommand *command.CreateWallpaperCommand) bool {
+	return wallpaperCommand.ColorValueSpace.Auto != nil && wallpaperCommand.UsesSourceImage()
+}
 
-	transformedCoordinates := []complex128{}
-	resultsByTerm := [][]complex128{}
-	for range wallpaperFormula.Formula.WavePackets {
-		resultsByTerm = append(resultsByTerm, []complex128{})
+// chooseColorValueSpace sets the command's color value space from the transformed values
+//   and prints the bounds so they can be copied into the config.
+func chooseColorValueSpace(wallpaperCommand *command.CreateWallpaperCommand, transformedValues []complex128) error {
+	err := wallpaperCommand.ColorValueSpace.Validate()
+	if err != nil {
+		return invalidConfigError(err)
 	}
-
-	for _, complexCoordinate := range scaledCoordinates {
-		hexagonalWallpaperResults := wallpaperFormula.Calculate(complexCoordinate)
-		for index, formulaResult := range hexagonalWallpaperResults.ContributionByTerm {
-			resultsByTerm[index] = append(resultsByTerm[index], formulaResult)
-		}
-
-		transformedCoordinate := hexagonalWallpaperResults.Total
-		transformedCoordinates = append(transformedCoordinates, transformedCoordinate)
+	auto := wallpaperCommand.ColorValueSpace.Auto
+	bounds, err := auto.Bounds(transformedValues)
+	if err != nil {
+		return invalidConfigError(err)
 	}
+	wallpaperCommand.ColorValueSpace.ComplexNumberCorners = bounds
 
-	println("Min/Max ranges, by Term")
-	for index, results := range resultsByTerm {
-		minz, maxz := mathutility.GetBoundingBox(results)
-		fmt.Printf("%d: %e - %e\n", index, minz, maxz)
-	}
+	lowerPercentile, upperPercentile := auto.Percentiles()
+	fmt.Printf("Chose color_value_space from the %v to %v percentiles:\n", lowerPercentile, upperPercentile)
+	fmt.Printf("color_value_space:\n  minx: %v\n  miny: %v\n  maxx: %v\n  maxy: %v\n", bounds.MinX, bounds.MinY, bounds.MaxX, bounds.MaxY)
+	return nil
+}
 
-	println("Symmetries found:")
-	if wallpaperFormula.HasSymmetry(wavepacket.P4) {
-		println("  p4")
+// colorizeFieldToFile colors the field and writes the output image.
+func colorizeFieldToFile(ctx context.Context, wallpaperCommand *command.CreateWallpaperCommand, renderSettings render.Settings, valueField *field.Field) error {
+	_, outputOptions, err := outputEncoding(wallpaperCommand)
+	if err != nil {
+		return err
 	}
-	if wallpaperFormula.HasSymmetry(wavepacket.P4m) {
-		println("  p4m")
+	outputImage := render.NewImage(renderSettings.OutputBounds, outputOptions.BitDepth)
+	err = render.ColorizeField(ctx, renderSettings, valueField, outputImage)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
 	}
-	if wallpaperFormula.HasSymmetry(wavepacket.P4g) {
-		println("  p4g")
+	if err != nil {
+		return invalidConfigError(err)
 	}
-
-	return transformedCoordinates
+	return outputToFile(wallpaperCommand, outputImage)
 }
 
-func transformCoordinatesForRhombicWallpaperFormula(wallpaperFormula *wavepacket.RhombicWallpaperFormula, scaledCoordinates []complex128) []complex128 {
-	err := wallpaperFormula.SetUp()
+// streamToFile renders strips of rows that fit in the memory budget and writes each one to a PNG as it finishes.
+//   If ctx is cancelled the unfinished PNG is removed.
+func streamToFile(ctx context.Context, wallpaperCommand *command.CreateWallpaperCommand, renderSettings render.Settings, bitDepth int, memoryBudget int64) error {
+	width := renderSettings.OutputBounds.Dx()
+	height := renderSettings.OutputBounds.Dy()
+	rowsPerStrip := render.RowsPerStripForBudget(width, bitDepth, memoryBudget-pngstream.WriterMemory(width, bitDepth))
+	recipe, err := recipeTextChunks(wallpaperCommand)
 	if err != nil {
-		println(err.Error())
+		return err
 	}
 
-	transformedCoordinates := []complex128{}
-	resultsByTerm := [][]complex128{}
-	for range wallpaperFormula.Formula.WavePackets {
-		resultsByTerm = append(resultsByTerm, []complex128{})
+	outputFilename := wallpaperCommand.OutputFilename
+	outputImageFile, err := os.Create(outputFilename)
+	if err != nil {
+		return fileError(err)
+	}
+	bufferedOutput := bufio.NewWriter(outputImageFile)
+	pngWriter, err := pngstream.NewWriterWithBitDepth(bufferedOutput, width, height, bitDepth)
+	if err == nil {
+		err = pngWriter.AddText(recipe...)
+	}
+	if err != nil {
+		outputImageFile.Close()
+		return fileError(err)
 	}
 
-	for _, complexCoordinate := range scaledCoordinates {
-		hexagonalWallpaperResults := wallpaperFormula.Calculate(complexCoordinate)
-		for index, formulaResult := range hexagonalWallpaperResults.ContributionByTerm {
-			resultsByTerm[index] = append(resultsByTerm[index], formulaResult)
-		}
-
-		transformedCoordinate := hexagonalWallpaperResults.Total
-		transformedCoordinates = append(transformedCoordinates, transformedCoordinate)
+	statistics, err := render.Stream(ctx, renderSettings, rowsPerStrip, bitDepth, func(strip draw.Image) error {
+		return pngWriter.WriteRows(strip)
+	})
+	if err == nil {
+		err = pngWriter.Close()
 	}
+	if err == nil {
+		err = bufferedOutput.Flush()
+	}
+	if err != nil && ctx.Err() != nil {
+		outputImageFile.Close()
+		os.Remove(outputFilename)
+		return ctx.Err()
+	}
+	if err != nil {
+		outputImageFile.Close()
+		return fileError(fmt.Errorf("cannot write %s: %v", outputFilename, err))
+	}
+	printStatistics(statistics)
+	return fileError(outputImageFile.Close())
+}
 
-	println("Min/Max ranges, by Term")
-	for index, results := range resultsByTerm {
-		minz, maxz := mathutility.GetBoundingBox(results)
-		fmt.Printf("%d: %e - %e\n", index, minz, maxz)
+// renderSettingsForCommand describes how to render the command's output.
+//   The caller sets up the formula and chooses the Colorizer.
+func renderSettingsForCommand(wallpaperCommand *command.CreateWallpaperCommand, options *commandLineOptions) (*render.Settings, error) {
+	outputWidth := wallpaperCommand.OutputImageSize.Width
+	outputHeight := wallpaperCommand.OutputImageSize.Height
+	if outputWidth < 1 || outputHeight < 1 {
+		return nil, invalidConfigError(fmt.Errorf("output size must be positive, got %dx%d", outputWidth, outputHeight))
+	}
+	if wallpaperCommand.Supersample.Size < 0 {
+		return nil, invalidConfigError(fmt.Errorf("supersample size cannot be negative, got %d", wallpaperCommand.Supersample.Size))
+	}
+
+	return &render.Settings{
+		OutputBounds:   image.Rect(0, 0, outputWidth, outputHeight),
+		SampleSpaceMin: complex(wallpaperCommand.SampleSpace.MinX, wallpaperCommand.SampleSpace.MinY),
+		SampleSpaceMax: complex(wallpaperCommand.SampleSpace.MaxX, wallpaperCommand.SampleSpace.MaxY),
+		Workers:        options.workers,
+		Supersample:    wallpaperCommand.Supersample.Size,
+		Jitter:         wallpaperCommand.Supersample.Jitter,
+		ValueTerm:      wallpaperCommand.ValueTerm(),
+		Progress:       options.progress,
+	}, nil
+}
+
+// setUpFormula validates and sets up the command's formula, reports its symmetries
+//   and adds it to the render settings.
+func setUpFormula(wallpaperCommand *command.CreateWallpaperCommand, renderSettings *render.Settings) error {
+	activeFormula, err := formulaForCommand(wallpaperCommand)
+	if err != nil {
+		return err
 	}
 
 	println("Symmetries found:")
-	if wallpaperFormula.HasSymmetry(wavepacket.Cm) {
-		println("  cm")
+	symmetries := activeFormula.Symmetries()
+	for _, symmetry := range symmetries {
+		println("  " + symmetry)
 	}
-	if wallpaperFormula.HasSymmetry(wavepacket.Cmm) {
-		println("  cmm")
+	if len(symmetries) == 0 {
+		println("  none found")
 	}
 
-	return transformedCoordinates
+	renderSettings.Formula = activeFormula
+	return nil
 }
 
-func transformCoordinatesForRectangularWallpaperFormula(wallpaperFormula *wavepacket.RectangularWallpaperFormula, scaledCoordinates []complex128) []complex128 {
-	err := wallpaperFormula.SetUp()
+// formulaForCommand validates and sets up the command's formula.
+func formulaForCommand(wallpaperCommand *command.CreateWallpaperCommand) (formula.Formula, error) {
+	activeFormula := wallpaperCommand.ActiveFormula()
+	if activeFormula == nil {
+		return nil, invalidConfigError(errors.New("no formula found"))
+	}
+	err := activeFormula.Validate()
 	if err != nil {
-		println(err.Error())
+		return nil, invalidConfigError(err)
 	}
-
-	transformedCoordinates := []complex128{}
-	resultsByTerm := [][]complex128{}
-	for range wallpaperFormula.Formula.WavePackets {
-		resultsByTerm = append(resultsByTerm, []complex128{})
+	err = activeFormula.SetUp()
+	if err != nil {
+		return nil, invalidConfigError(err)
 	}
+	return activeFormula, nil
+}
 
-	for _, complexCoordinate := range scaledCoordinates {
-		hexagonalWallpaperResults := wallpaperFormula.Calculate(complexCoordinate)
-		for index, formulaResult := range hexagonalWallpaperResults.ContributionByTerm {
-			resultsByTerm[index] = append(resultsByTerm[index], formulaResult)
+// colorizerForCommand returns the command's domain coloring or gradient palette, or loads its source image.
+//   A source image's value space is filled in by useColorValueSpace once it is known.
+//   Commands with a quantize palette snap the colors to the palette extracted from the source image.
+func colorizerForCommand(wallpaperCommand *command.CreateWallpaperCommand) (colorizer.Colorizer, error) {
+	var colorSourceImage image.Image
+	if wallpaperCommand.UsesSourceImage() || wallpaperCommand.ExtractsPalette() {
+		var err error
+		colorSourceImage, err = loadSourceImage(wallpaperCommand.SampleSourceFilename)
+		if err != nil {
+			return nil, err
 		}
-
-		transformedCoordinate := hexagonalWallpaperResults.Total
-		transformedCoordinates = append(transformedCoordinates, transformedCoordinate)
 	}
 
-	println("Min/Max ranges, by Term")
-	for index, results := range resultsByTerm {
-		minz, maxz := mathutility.GetBoundingBox(results)
-		fmt.Printf("%d: %e - %e\n", index, minz, maxz)
+	commandColorizer, err := unquantizedColorizerForCommand(wallpaperCommand, colorSourceImage)
+	if err != nil || wallpaperCommand.QuantizePalette == nil {
+		return commandColorizer, err
 	}
+	palette, err := extractPalette(wallpaperCommand.QuantizePalette, colorSourceImage)
+	if err != nil {
+		return nil, err
+	}
+	quantizer, err := colorizer.NewQuantizer(commandColorizer, palette)
+	if err != nil {
+		return nil, invalidConfigError(err)
+	}
+	return quantizer, nil
+}
 
-	println("Symmetries found:")
-	hasSymmetry := false
-	if wallpaperFormula.HasSymmetry(wavepacket.Pm) {
-		println("  pm")
-		hasSymmetry = true
+func unquantizedColorizerForCommand(wallpaperCommand *command.CreateWallpaperCommand, colorSourceImage image.Image) (colorizer.Colorizer, error) {
+	if wallpaperCommand.DomainColoring != nil {
+		return wallpaperCommand.DomainColoring, nil
 	}
-	if wallpaperFormula.HasSymmetry(wavepacket.Pg) {
-		println("  pg")
-		hasSymmetry = true
+	if wallpaperCommand.GradientPalette != nil {
+		return gradientForCommand(wallpaperCommand.GradientPalette, colorSourceImage)
 	}
-	if wallpaperFormula.HasSymmetry(wavepacket.Pmm) {
-		println("  pmm")
-		hasSymmetry = true
+
+	err := wallpaperCommand.ColorValueSpace.Validate()
+	if err != nil {
+		return nil, invalidConfigError(err)
 	}
-	if wallpaperFormula.HasSymmetry(wavepacket.Pmg) {
-		println("  pmg")
-		hasSymmetry = true
+	filter, err := colorizer.FilterByName(wallpaperCommand.SamplingFilter)
+	if err != nil {
+		return nil, invalidConfigError(err)
 	}
-	if wallpaperFormula.HasSymmetry(wavepacket.Pgg) {
-		println("  pgg")
-		hasSymmetry = true
+	outOfRangeMode, err := colorizer.ParseOutOfRangeMode(wallpaperCommand.OutOfRange.Mode)
+	if err != nil {
+		return nil, invalidConfigError(err)
 	}
-	if hasSymmetry == false {
-		println("  none found")
+	var background color.Color
+	if wallpaperCommand.OutOfRange.Background != "" {
+		background, err = colorizer.ParseHexColor(wallpaperCommand.OutOfRange.Background)
+		if err != nil {
+			return nil, invalidConfigError(err)
+		}
 	}
-	return transformedCoordinates
-}
 
-func transformCoordinatesForGenericWallpaperFormula(wallpaperFormula *wavepacket.GenericWallpaperFormula, scaledCoordinates []complex128) []complex128 {
-	wallpaperFormula.SetUp()
+	return &colorizer.SourceImage{
+		Source:       colorSourceImage,
+		Filter:       filter,
+		OutOfRange:   outOfRangeMode,
+		Background:   background,
+		FadeDistance: wallpaperCommand.OutOfRange.FadeDistance,
+	}, nil
+}
 
-	transformedCoordinates := []complex128{}
-	resultsByTerm := [][]complex128{}
-	for range wallpaperFormula.Formula.WavePackets {
-		resultsByTerm = append(resultsByTerm, []complex128{})
+func loadSourceImage(filename string) (image.Image, error) {
+	reader, err := os.Open(filename)
+	if err != nil {
+		return nil, fileError(err)
 	}
+	defer reader.Close()
 
-	for _, complexCoordinate := range scaledCoordinates {
-		genericWallpaperResults := wallpaperFormula.Calculate(complexCoordinate)
-		for index, formulaResult := range genericWallpaperResults.ContributionByTerm {
-			resultsByTerm[index] = append(resultsByTerm[index], formulaResult)
-		}
+	colorSourceImage, _, err := image.Decode(reader)
+	if err != nil {
+		return nil, fileError(fmt.Errorf("cannot decode %s: %v", filename, err))
+	}
+	return colorSourceImage, nil
+}
 
-		transformedCoordinate := genericWallpaperResults.Total
-		transformedCoordinates = append(transformedCoordinates, transformedCoordinate)
+// extractPalette picks the palette from the source image and prints it, so it can be copied into a command.
+func extractPalette(extraction *command.PaletteExtraction, colorSourceImage image.Image) ([]color.NRGBA, error) {
+	palette, err := extraction.Extract(colorSourceImage)
+	if err != nil {
+		return nil, invalidConfigError(err)
+	}
+	hexColors := []string{}
+	for _, paletteColor := range palette {
+		hexColors = append(hexColors, colorizer.FormatHexColor(paletteColor))
 	}
+	fmt.Printf("Extracted palette: %s\n", strings.Join(hexColors, " "))
+	return palette, nil
+}
 
-	println("Min/Max ranges, by Term")
-	for index, results := range resultsByTerm {
-		minz, maxz := mathutility.GetBoundingBox(results)
-		fmt.Printf("%d: %e - %e\n", index, minz, maxz)
+// gradientForCommand loads the palette library or extracts the source image's palette if needed, and builds the gradient.
+func gradientForCommand(palette *command.GradientPalette, colorSourceImage image.Image) (colorizer.Colorizer, error) {
+	var library command.PaletteLibrary
+	if palette.LibraryFilename != "" {
+		libraryYAML, err := ioutil.ReadFile(palette.LibraryFilename)
+		if err != nil {
+			return nil, fileError(err)
+		}
+		library, err = command.NewPaletteLibraryFromYAML(libraryYAML)
+		if err != nil {
+			return nil, invalidConfigError(fmt.Errorf("cannot parse %s: %v", palette.LibraryFilename, err))
+		}
+	}
+	err := palette.InlineLibraryStops(library)
+	if err != nil {
+		return nil, invalidConfigError(err)
+	}
+	var extracted []color.NRGBA
+	if palette.Extract != nil {
+		var err error
+		extracted, err = extractPalette(palette.Extract, colorSourceImage)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	//println("Symmetries found:")
-	//if wallpaperFormula.HasSymmetry(wavepacket.P2) {
-	//	println("  p2")
-	//}
+	gradient, err := palette.Gradient(library, extracted)
+	if err != nil {
+		return nil, invalidConfigError(err)
+	}
+	return gradient, nil
+}
 
-	return transformedCoordinates
+// useColorValueSpace maps the command's color value space onto the source image.
+//   Colorizers without a source image are returned unchanged.
+func useColorValueSpace(wallpaperCommand *command.CreateWallpaperCommand, commandColorizer colorizer.Colorizer) colorizer.Colorizer {
+	if quantizer, ok := commandColorizer.(*colorizer.Quantizer); ok {
+		useColorValueSpace(wallpaperCommand, quantizer.Colorizer)
+		return quantizer
+	}
+	sourceColorizer, ok := commandColorizer.(*colorizer.SourceImage)
+	if !ok {
+		return commandColorizer
+	}
+	sourceColorizer.Mapping = wallpaperCommand.ColorValueSpace.ValueMapping()
+	sourceColorizer.ValueSpaceMin, sourceColorizer.ValueSpaceMax = wallpaperCommand.ColorValueSpace.Corners()
+	return sourceColorizer
 }
 
-func flattenCoordinates(destinationBounds image.Rectangle) []complex128 {
-	flattenedCoordinates := []complex128{}
-	for destinationY := destinationBounds.Min.Y ; destinationY < destinationBounds.Max.Y; destinationY++ {
-		for destinationX := destinationBounds.Min.X; destinationX < destinationBounds.Max.X; destinationX++ {
-			flattenedCoordinates = append(flattenedCoordinates, complex(float64(destinationX), float64(destinationY)))
-		}
+// printStatistics reports the bounds and mean of the formula's results, for each term and in total.
+func printStatistics(statistics *formula.ResultStatistics) {
+	fmt.Println("Min/Max ranges, by Term")
+	for index := range statistics.ByTerm {
+		fmt.Printf("%d: %s\n", index, describeStatistics(&statistics.ByTerm[index]))
 	}
-	return flattenedCoordinates
+	fmt.Printf("Total: %s\n", describeStatistics(&statistics.Total))
 }
 
-func scaleDestinationPixels(destinationBounds image.Rectangle, destinationCoordinates []complex128, viewPortMin complex128, viewPortMax complex128) []complex128 {
-	scaledCoordinates := []complex128{}
-	for _, destinationCoordinate := range destinationCoordinates {
-		destinationScaledX := mathutility.ScaleValueBetweenTwoRanges(
-			float64(real(destinationCoordinate)),
-			float64(destinationBounds.Min.X),
-			float64(destinationBounds.Max.X),
-			real(viewPortMin),
-			real(viewPortMax),
-		)
-		destinationScaledY := mathutility.ScaleValueBetweenTwoRanges(
-			float64(imag(destinationCoordinate)),
-			float64(destinationBounds.Min.Y),
-			float64(destinationBounds.Max.Y),
-			imag(viewPortMin),
-			imag(viewPortMax),
-		)
-		scaledCoordinates = append(scaledCoordinates, complex(destinationScaledX, destinationScaledY))
-	}
-	return scaledCoordinates
+func describeStatistics(statistics *mathutility.Statistics) string {
+	description := fmt.Sprintf("%e - %e, mean %e", statistics.Min, statistics.Max, statistics.Mean())
+	if statistics.InfCount > 0 || statistics.NaNCount > 0 {
+		description += fmt.Sprintf(" (ignored %d infinite and %d NaN values)", statistics.InfCount, statistics.NaNCount)
+	}
+	return description
 }
 
-func colorDestinationImage(
-	destinationImage *image.NRGBA,
-	sourceImage image.Image,
-	destinationCoordinates []complex128,
-	transformedCoordinates []complex128,
-	colorValueBoundMin complex128,
-	colorValueBoundMax complex128,
-	) {
-	sourceImageBounds := sourceImage.Bounds()
-	for index, transformedCoordinate := range transformedCoordinates {
-		var sourceColorR, sourceColorG, sourceColorB, sourceColorA uint32
-
-		if real(transformedCoordinate) < real(colorValueBoundMin) ||
-			imag(transformedCoordinate) < imag(colorValueBoundMin) ||
-		real(transformedCoordinate) > real(colorValueBoundMax) ||
-			imag(transformedCoordinate) > imag(colorValueBoundMax) {
-			sourceColorR,sourceColorG,sourceColorB,sourceColorA = 0,0,0,0
-		} else {
-			sourceImagePixelX := int(mathutility.ScaleValueBetweenTwoRanges(
-				float64(real(transformedCoordinate)),
-				real(colorValueBoundMin),
-				real(colorValueBoundMax),
-				float64(sourceImageBounds.Min.X),
-				float64(sourceImageBounds.Max.X),
-			))
-			sourceImagePixelY := int(mathutility.ScaleValueBetweenTwoRanges(
-				float64(imag(transformedCoordinate)),
-				imag(colorValueBoundMin),
-				imag(colorValueBoundMax),
-				float64(sourceImageBounds.Min.Y),
-				float64(sourceImageBounds.Max.Y),
-			))
-			sourceColorR, sourceColorG, sourceColorB, sourceColorA = sourceImage.At(sourceImagePixelX, sourceImagePixelY).RGBA()
-		}
+// outputEncoding picks the command's output format, from output_format or the output filename's extension,
+//   and fills in the format's default options.
+func outputEncoding(wallpaperCommand *command.CreateWallpaperCommand) (*encoder.Format, encoder.Options, error) {
+	var outputFormat *encoder.Format
+	var err error
+	if wallpaperCommand.OutputFormat != "" {
+		outputFormat, err = encoder.ByName(wallpaperCommand.OutputFormat)
+	} else {
+		outputFormat, err = encoder.ForFilename(wallpaperCommand.OutputFilename)
+	}
+	if err != nil {
+		return nil, encoder.Options{}, invalidConfigError(err)
+	}
+	outputOptions, err := outputFormat.ResolveOptions(encoder.Options{
+		BitDepth: wallpaperCommand.OutputOptions.BitDepth,
+		Quality:  wallpaperCommand.OutputOptions.Quality,
+	})
+	if err != nil {
+		return nil, encoder.Options{}, invalidConfigError(err)
+	}
+	return outputFormat, outputOptions, nil
+}
 
-		destinationPixelX := int(real(destinationCoordinates[index]))
-		destinationPixelY := int(imag(destinationCoordinates[index]))
+// outputToFile writes the image to the command's output file in the command's output format.
+//   Formats that can hold text get the command's recipe in their metadata.
+func outputToFile(wallpaperCommand *command.CreateWallpaperCommand, outputImage image.Image) error {
+	outputFormat, outputOptions, err := outputEncoding(wallpaperCommand)
+	if err != nil {
+		return err
+	}
+	recipe, err := recipeTextChunks(wallpaperCommand)
+	if err != nil {
+		return err
+	}
 
-		destinationImage.Set(
-			destinationPixelX,
-			destinationPixelY,
-			color.NRGBA{
-				R: uint8(sourceColorR>>8),
-				G: uint8(sourceColorG>>8),
-				B: uint8(sourceColorB>>8),
-				A: uint8(sourceColorA>>8),
-			},
-		)
+	outputFilename := wallpaperCommand.OutputFilename
+	outputImageFile, err := os.Create(outputFilename)
+	if err != nil {
+		return fileError(err)
+	}
+	err = outputFormat.Encode(outputImageFile, outputImage, outputOptions, recipe)
+	if err != nil {
+		outputImageFile.Close()
+		return fileError(fmt.Errorf("cannot encode %s: %v", outputFilename, err))
 	}
+	return fileError(outputImageFile.Close())
 }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	. "gopkg.in/check.v1"
+	"math/cmplx"
+	"wallpaper/entities/command"
+	"wallpaper/entities/formula"
+	"wallpaper/entities/formula/catalog"
+	"wallpaper/entities/formula/wavepacket"
+	"wallpaper/entities/utility"
+)
+
+type FormulaForCommandSuite struct{}
+
+var _ = Suite(&FormulaForCommandSuite{})
+
+// samplePoints are spread around the unit cell and beyond it.
+var samplePoints = []complex128{
+	complex(0.1, 0.2),
+	complex(0.37, -0.61),
+	complex(-1.3, 0.45),
+	complex(2.2, 1.7),
+}
+
+// wallpaperShapes gives each lattice the shape parameters that make the catalog's conventional vectors.
+var wallpaperShapes = map[formula.LatticeType]string{
+	formula.ObliqueLattice:     "  vector_width: 0.3\n  vector_height: 1.2\n",
+	formula.RectangularLattice: "  lattice_height: 0.7\n",
+	formula.RhombicLattice:     "  lattice_height: 0.7\n",
+	formula.SquareLattice:      "",
+	formula.HexagonalLattice:   "",
+}
+
+// wallpaperFormulaLattice returns the lattice the set up wallpaper formula calculates with.
+func wallpaperFormulaLattice(activeFormula formula.Formula) *formula.LatticeVectorPair {
+	switch wallpaperFormula := activeFormula.(type) {
+	case *wavepacket.SquareWallpaperFormula:
+		return wallpaperFormula.Formula.Lattice
+	case *wavepacket.HexagonalWallpaperFormula:
+		return wallpaperFormula.Formula.Lattice
+	case *wavepacket.RectangularWallpaperFormula:
+		return wallpaperFormula.Formula.Lattice
+	case *wavepacket.RhombicWallpaperFormula:
+		return wallpaperFormula.Formula.Lattice
+	case *wavepacket.GenericWallpaperFormula:
+		return wallpaperFormula.Formula.Lattice
+	}
+	return nil
+}
+
+func (suite *FormulaForCommandSuite) TestWallpaperFormulasKeepTheirGroupsSymmetry(checker *C) {
+	for _, group := range catalog.Wallpapers() {
+		config := fmt.Sprintf(`
+wallpaper_formula:
+  group: %s
+%s  multiplier:
+    real: 1
+    imaginary: 0
+  terms:
+    -
+      power_n: 2
+      power_m: -1
+    -
+      power_n: 1
+      power_m: 3
+`, group.Name, wallpaperShapes[group.Lattice()])
+		wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML([]byte(config))
+		checker.Assert(err, IsNil, Commentf("%s", group.Name))
+
+		activeFormula, err := formulaForCommand(wallpaperCommand)
+		checker.Assert(err, IsNil, Commentf("%s", group.Name))
+		lattice := wallpaperFormulaLattice(activeFormula)
+		checker.Assert(lattice, NotNil, Commentf("%s", group.Name))
+
+		for generatorIndex, generator := range group.Generators(*lattice) {
+			for _, z := range samplePoints {
+				difference := cmplx.Abs(activeFormula.Calculate(generator.Apply(z)).Total - activeFormula.Calculate(z).Total)
+				checker.Assert(difference, utility.NumericallyCloseEnough{}, 0.0, 1e-6, Commentf("%s generator %d at %v", group.Name, generatorIndex, z))
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"wallpaper/entities/command"
+	"wallpaper/entities/preview"
+)
+
+// previewMaximumSide is the longest side of a preview stamp, in pixels.
+const previewMaximumSide = 200
+
+// previewWallpaper renders a small preview stamp and adds it to the output's preview history,
+//   so the last few previews can be compared. The index page is rewritten if it was asked for.
+func previewWallpaper(ctx context.Context, wallpaperCommand *command.CreateWallpaperCommand, options *commandLineOptions) error {
+	history, err := preview.NewHistory(wallpaperCommand.OutputFilename, options.previewHistory)
+	if err != nil {
+		return invalidConfigError(err)
+	}
+
+	shrinkToPreviewSize(wallpaperCommand, options.overrides)
+	// Previews are 8 bit PNGs so every browser can show them on the index page.
+	wallpaperCommand.OutputFilename = history.PendingFilename()
+	wallpaperCommand.OutputFormat = "png"
+	wallpaperCommand.OutputOptions = command.OutputOptions{}
+	// A preview sized field would replace the full sized one.
+	wallpaperCommand.FieldFilename = ""
+
+	err = renderWallpaper(ctx, wallpaperCommand, options)
+	if err != nil {
+		os.Remove(history.PendingFilename())
+		return err
+	}
+	err = history.Add(history.PendingFilename())
+	if err != nil {
+		return fileError(err)
+	}
+	fmt.Printf("Preview: %s\n", history.Filename(1))
+
+	if !options.previewIndex {
+		return nil
+	}
+	err = history.WriteIndex()
+	if err != nil {
+		return fileError(err)
+	}
+	fmt.Printf("Index: %s\n", history.IndexFilename())
+	return nil
+}
+
+// shrinkToPreviewSize scales the output size down so the longest side fits in a preview stamp.
+//   The aspect ratio and the sample space are kept. Sizes given on the command line are respected.
+func shrinkToPreviewSize(wallpaperCommand *command.CreateWallpaperCommand, overrides command.Overrides) {
+	if overrides.OutputWidth != nil || overrides.OutputHeight != nil {
+		return
+	}
+
+	width := wallpaperCommand.OutputImageSize.Width
+	height := wallpaperCommand.OutputImageSize.Height
+	longestSide := width
+	if height > longestSide {
+		longestSide = height
+	}
+	if longestSide <= previewMaximumSide {
+		return
+	}
+
+	wallpaperCommand.OutputImageSize.Width = maxInt(1, width*previewMaximumSide/longestSide)
+	wallpaperCommand.OutputImageSize.Height = maxInt(1, height*previewMaximumSide/longestSide)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"wallpaper/entities/render"
+)
+
+// progressInterval is how often the progress line is redrawn.
+const progressInterval = 200 * time.Millisecond
+
+// progressLine redraws one line on a terminal with how far the render has got and how long is left.
+type progressLine struct {
+	output    io.Writer
+	lock      sync.Mutex
+	lastDrawn time.Time
+	// drawn is true while the line is on screen and needs ending before anything else is written.
+	drawn bool
+}
+
+// newProgressLine returns a progress line on stderr, or nil if stderr is not a terminal,
+//   so redirected output is not filled with carriage returns.
+func newProgressLine() *progressLine {
+	info, err := os.Stderr.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &progressLine{output: os.Stderr}
+}
+
+// reporter returns a function that shows a render's progress, labelled so renders can be told apart.
+//   A nil progress line returns nil, which render.Settings takes to mean nobody is watching.
+func (line *progressLine) reporter(label string) func(render.Progress) {
+	if line == nil {
+		return nil
+	}
+	return func(progress render.Progress) {
+		line.show(label, progress)
+	}
+}
+
+// show redraws the line, at most every progressInterval. The last row always ends the line.
+func (line *progressLine) show(label string, progress render.Progress) {
+	line.lock.Lock()
+	defer line.lock.Unlock()
+
+	finished := progress.RowsDone >= progress.TotalRows
+	if !finished && time.Since(line.lastDrawn) < progressInterval {
+		return
+	}
+	line.lastDrawn = time.Now()
+	text := fmt.Sprintf("%s: %3.0f%% (%d/%d rows)", label, 100*progress.Fraction(), progress.RowsDone, progress.TotalRows)
+	if !finished && progress.Remaining > 0 {
+		text += fmt.Sprintf(", %v left", progress.Remaining.Round(time.Second))
+	}
+	fmt.Fprintf(line.output, "\r%-60s", text)
+	line.drawn = !finished
+	if finished {
+		fmt.Fprintln(line.output)
+	}
+}
+
+// end finishes a line left unfinished by a cancelled or failed render.
+func (line *progressLine) end() {
+	if line == nil {
+		return
+	}
+	line.lock.Lock()
+	defer line.lock.Unlock()
+	if line.drawn {
+		fmt.Fprintln(line.output)
+		line.drawn = false
+	}
+}
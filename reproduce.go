@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"wallpaper/entities/command"
+	"wallpaper/entities/encoder"
+	"wallpaper/entities/pngstream"
+)
+
+// version is stored in every image's recipe. Release builds set it with -ldflags "-X main.version=...".
+var version = "dev"
+
+// recipeTextChunks describes how to render the command's image again, as PNG text chunks.
+//   Call it after the color value space is chosen, so the recipe holds the bounds that were used.
+func recipeTextChunks(wallpaperCommand *command.CreateWallpaperCommand) ([]pngstream.TextChunk, error) {
+	normalizedYAML, err := wallpaperCommand.NormalizedYAML()
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe the command: %v", err)
+	}
+	recipe := &command.Recipe{
+		Command: normalizedYAML,
+		Version: version,
+	}
+	if wallpaperCommand.UsesSourceImage() || wallpaperCommand.ExtractsPalette() {
+		recipe.SourceHash, err = hashFile(wallpaperCommand.SampleSourceFilename)
+		if err != nil {
+			return nil, fileError(err)
+		}
+	}
+	if wallpaperCommand.UsesSourceImage() {
+		bounds := wallpaperCommand.ColorValueSpace.ComplexNumberCorners
+		recipe.ColorValueSpace = &bounds
+	}
+	return recipe.TextChunks()
+}
+
+// hashFile returns the hex SHA-256 of the named file.
+func hashFile(filename string) (string, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(contents)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// loadRecipeCommand reads the recipe stored in a rendered image and recreates its command.
+//   It warns if the source image has changed since the image was rendered.
+func loadRecipeCommand(imageFilename string) (*command.CreateWallpaperCommand, error) {
+	imageFile, err := os.Open(imageFilename)
+	if err != nil {
+		return nil, fileError(err)
+	}
+	defer imageFile.Close()
+
+	chunks, err := pngstream.ReadText(imageFile)
+	if err != nil {
+		return nil, fileError(fmt.Errorf("cannot read %s: %v", imageFilename, err))
+	}
+	recipe, err := command.NewRecipeFromTextChunks(chunks)
+	if err != nil {
+		return nil, invalidConfigError(fmt.Errorf("cannot reproduce %s: %v", imageFilename, err))
+	}
+	wallpaperCommand, err := recipe.WallpaperCommand()
+	if err != nil {
+		return nil, invalidConfigError(fmt.Errorf("cannot parse the command stored in %s: %v", imageFilename, err))
+	}
+
+	fmt.Printf("Reproducing %s, rendered by version %s\n", imageFilename, recipe.Version)
+	if recipe.SourceHash != "" {
+		sourceHash, err := hashFile(wallpaperCommand.SampleSourceFilename)
+		if err != nil {
+			return nil, fileError(err)
+		}
+		if sourceHash != recipe.SourceHash {
+			fmt.Fprintf(os.Stderr, "warning: %s has changed since %s was rendered\n", wallpaperCommand.SampleSourceFilename, imageFilename)
+		}
+	}
+	return wallpaperCommand, nil
+}
+
+// reproducedFilename names the reproduced image after the original, so the original is not overwritten.
+//   The extension matches the format the recipe writes, so a recipe for a TIFF or JPEG is not saved as .png.
+func reproducedFilename(imageFilename string, wallpaperCommand *command.CreateWallpaperCommand) string {
+	extension := filepath.Ext(wallpaperCommand.OutputFilename)
+	if wallpaperCommand.OutputFormat != "" {
+		outputFormat, err := encoder.ByName(wallpaperCommand.OutputFormat)
+		if err == nil {
+			extension = outputFormat.Extensions[0]
+		}
+	}
+	if extension == "" {
+		extension = ".png"
+	}
+	return strings.TrimSuffix(imageFilename, filepath.Ext(imageFilename)) + ".reproduced" + extension
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	. "gopkg.in/check.v1"
+	"testing"
+	"wallpaper/entities/command"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type ReproduceSuite struct{}
+
+var _ = Suite(&ReproduceSuite{})
+
+func (suite *ReproduceSuite) TestReproducedFilenameKeepsTheRecipesFormat(checker *C) {
+	for _, example := range []struct {
+		outputFilename string
+		outputFormat   string
+		expected       string
+	}{
+		{"wallpaper.png", "", "images/original.reproduced.png"},
+		{"wallpaper", "", "images/original.reproduced.png"},
+		{"wallpaper.jpg", "", "images/original.reproduced.jpg"},
+		{"wallpaper.png", "tiff", "images/original.reproduced.tif"},
+		{"wallpaper.png", "JPEG", "images/original.reproduced.jpg"},
+	} {
+		wallpaperCommand := &command.CreateWallpaperCommand{
+			OutputFilename: example.outputFilename,
+			OutputFormat:   example.outputFormat,
+		}
+		checker.Assert(reproducedFilename("images/original.png", wallpaperCommand), Equals, example.expected, Commentf("%+v", example))
+	}
+}
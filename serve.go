@@ -0,0 +1,653 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"image"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"wallpaper/entities/command"
+	"wallpaper/entities/formula"
+	"wallpaper/entities/jobs"
+	"wallpaper/entities/mathutility"
+	"wallpaper/entities/pngstream"
+	"wallpaper/entities/render"
+	"wallpaper/entities/utility"
+)
+
+// defaultListenAddress is where serve listens unless -listen says otherwise.
+const defaultListenAddress = "localhost:8080"
+
+// serveCacheSize is how many configs the server remembers, with their images and analyses.
+const serveCacheSize = 32
+
+// serveMaximumSamples is the most samples the server calculates for one image, so one request cannot use up the memory.
+const serveMaximumSamples = 4096 * 4096
+
+// serveMaximumUpload is the largest source image that can be uploaded, in bytes.
+const serveMaximumUpload = 64 << 20
+
+// serveMaximumUploadBody is the largest upload request, with the image in base64 and some room for the JSON around it.
+const serveMaximumUploadBody = serveMaximumUpload/3*4 + 1024
+
+// serveMaximumConfig is the largest config that can be posted, in bytes.
+const serveMaximumConfig = 1 << 20
+
+// wallpaperServer renders posted configs and remembers the results by config hash.
+type wallpaperServer struct {
+	options *commandLineOptions
+	// defaultConfig is the config file as JSON. The editor page starts with it.
+	defaultConfig []byte
+	// defaultSourceFilename is the config file's sample_source_filename.
+	//   Posted configs may sample it or an uploaded image, but no other file.
+	defaultSourceFilename string
+	// defaultLibraryFilename is the config file's gradient palette library, the only library posted configs may read.
+	defaultLibraryFilename string
+	uploadDirectory        string
+
+	// renders runs one render at a time, since each render already uses every CPU.
+	renders *jobs.Queue
+
+	// cacheLock guards the cache and the results in it.
+	cacheLock sync.Mutex
+	cache     map[string]*serverResult
+	// cacheOrder holds the hashes from oldest to newest, so the oldest is forgotten first.
+	cacheOrder []string
+}
+
+// serverResult is a posted config and, once it is rendered, its image and analysis.
+type serverResult struct {
+	config   []byte
+	image    []byte
+	analysis *serverAnalysis
+}
+
+// serverAnalysis reports the formula's symmetries and the range of its values.
+type serverAnalysis struct {
+	Hash       string             `json:"hash"`
+	Symmetries []string           `json:"symmetries"`
+	Total      serverStatistics   `json:"total"`
+	ByTerm     []serverStatistics `json:"by_term"`
+	// ColorValueSpace is the color value space that was used, which may have been chosen automatically.
+	ColorValueSpace *command.ComplexNumberCorners `json:"color_value_space,omitempty"`
+}
+
+// serverStatistics is a mathutility.Statistics that can be marshaled to JSON.
+type serverStatistics struct {
+	Min      utility.ComplexNumberForMarshal `json:"min"`
+	Max      utility.ComplexNumberForMarshal `json:"max"`
+	Mean     utility.ComplexNumberForMarshal `json:"mean"`
+	Count    int                             `json:"count"`
+	NaNCount int                             `json:"nan_count"`
+	InfCount int                             `json:"inf_count"`
+}
+
+// sourceUpload is the body of POST /api/sources. The image file arrives base64 encoded.
+type sourceUpload struct {
+	Image []byte `json:"image"`
+}
+
+// serveWallpapers runs the HTTP server until it fails or ctx is cancelled. It only listens on localhost.
+func serveWallpapers(ctx context.Context, options *commandLineOptions) error {
+	err := requireLoopbackAddress(options.listenAddress)
+	if err != nil {
+		return invalidConfigError(err)
+	}
+
+	defaultConfig, defaultMarshal, err := defaultConfigJSON(options.configFilename)
+	if err != nil {
+		return err
+	}
+	uploadDirectory := options.uploadDirectory
+	if uploadDirectory == "" {
+		uploadDirectory, err = ioutil.TempDir("", "wallpaper-uploads")
+		if err != nil {
+			return fileError(err)
+		}
+	}
+	err = os.MkdirAll(uploadDirectory, 0755)
+	if err != nil {
+		return fileError(err)
+	}
+
+	server := &wallpaperServer{
+		options:                options,
+		defaultConfig:          defaultConfig,
+		defaultSourceFilename:  defaultMarshal.SampleSourceFilename,
+		defaultLibraryFilename: defaultLibraryFilename(defaultMarshal),
+		uploadDirectory:        uploadDirectory,
+		renders:                jobs.NewQueue(ctx, 1, nil),
+		cache:                  map[string]*serverResult{},
+	}
+
+	httpServer := &http.Server{Addr: options.listenAddress, Handler: server.handler()}
+	go func() {
+		<-ctx.Done()
+		httpServer.Close()
+	}()
+	fmt.Printf("Serving on http://%s/ with uploads in %s\n", options.listenAddress, uploadDirectory)
+	err = httpServer.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return ctx.Err()
+	}
+	return err
+}
+
+// handler routes the API and the editor page. It only answers requests addressed to localhost,
+//   so a web page on another site cannot reach the server by pointing its own name at 127.0.0.1.
+func (server *wallpaperServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", server.handlePage)
+	mux.HandleFunc("/api/config", server.handleDefaultConfig)
+	mux.HandleFunc("/api/configs", server.handleConfigs)
+	mux.HandleFunc("/api/render", server.handleRender)
+	mux.HandleFunc("/api/analysis", server.handleAnalysis)
+	mux.HandleFunc("/api/sources", server.handleSources)
+	mux.HandleFunc("/api/jobs", server.handleJobs)
+	mux.HandleFunc("/api/jobs/cancel", server.handleCancelJob)
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		host, _, err := net.SplitHostPort(request.Host)
+		if err != nil {
+			host = request.Host
+		}
+		if !isLoopbackHost(host) {
+			http.Error(response, fmt.Sprintf("serve only answers requests for localhost, not %q", request.Host), http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(response, request)
+	})
+}
+
+// defaultLibraryFilename returns the config's gradient palette library, if it has one.
+func defaultLibraryFilename(config *command.CreateWallpaperCommandMarshal) string {
+	if config.GradientPalette == nil {
+		return ""
+	}
+	return config.GradientPalette.LibraryFilename
+}
+
+// requireLoopbackAddress makes sure the server cannot be reached from other machines.
+func requireLoopbackAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %q: %v", address, err)
+	}
+	if !isLoopbackHost(host) {
+		return fmt.Errorf("serve only listens on localhost, got %q", address)
+	}
+	return nil
+}
+
+// isLoopbackHost is true for localhost and loopback IP addresses. IPv6 addresses may be in brackets.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// defaultConfigJSON reads the config file and converts it to JSON for the editor page.
+//   It also returns the parsed config. A missing config file starts the editor empty.
+func defaultConfigJSON(configFilename string) ([]byte, *command.CreateWallpaperCommandMarshal, error) {
+	var config command.CreateWallpaperCommandMarshal
+	configYAML, err := ioutil.ReadFile(configFilename)
+	if os.IsNotExist(err) {
+		return []byte("{}"), &config, nil
+	}
+	if err != nil {
+		return nil, nil, fileError(err)
+	}
+	err = yaml.Unmarshal(configYAML, &config)
+	if err != nil {
+		return nil, nil, invalidConfigError(fmt.Errorf("cannot parse %s: %v", configFilename, err))
+	}
+	configJSON, err := json.Marshal(config)
+	return configJSON, &config, err
+}
+
+func (server *wallpaperServer) handlePage(response http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != "/" {
+		http.NotFound(response, request)
+		return
+	}
+	response.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(response, servePage)
+}
+
+// handleDefaultConfig returns the config file the server was started with.
+func (server *wallpaperServer) handleDefaultConfig(response http.ResponseWriter, request *http.Request) {
+	if !allowMethod(response, request, http.MethodGet) {
+		return
+	}
+	response.Header().Set("Content-Type", "application/json")
+	response.Write(server.defaultConfig)
+}
+
+// handleConfigs remembers a posted config and returns its hash, to render or analyze later.
+func (server *wallpaperServer) handleConfigs(response http.ResponseWriter, request *http.Request) {
+	if !allowMethod(response, request, http.MethodPost) || !requireJSON(response, request) {
+		return
+	}
+	hash, err := server.storeConfig(request)
+	if err != nil {
+		writeServerError(response, err)
+		return
+	}
+	writeJSON(response, map[string]string{"hash": hash})
+}
+
+// handleRender renders a posted config, or a remembered one with GET ?hash=, and returns it as a PNG.
+//   The PNG holds the config's recipe, so it can be reproduced.
+func (server *wallpaperServer) handleRender(response http.ResponseWriter, request *http.Request) {
+	if !allowMethod(response, request, http.MethodGet, http.MethodPost) || !requireJSON(response, request) {
+		return
+	}
+	hash, err := server.requestedHash(request)
+	if err != nil {
+		writeServerError(response, err)
+		return
+	}
+	result, err := server.renderedResult(request.Context(), hash)
+	if err != nil {
+		writeServerError(response, err)
+		return
+	}
+	response.Header().Set("Content-Type", "image/png")
+	response.Header().Set("X-Config-Hash", hash)
+	response.Write(result.image)
+}
+
+// handleAnalysis returns the symmetries and value ranges of a remembered config, GET ?hash=, or a posted one.
+func (server *wallpaperServer) handleAnalysis(response http.ResponseWriter, request *http.Request) {
+	if !allowMethod(response, request, http.MethodGet, http.MethodPost) || !requireJSON(response, request) {
+		return
+	}
+	hash, err := server.requestedHash(request)
+	if err != nil {
+		writeServerError(response, err)
+		return
+	}
+	result, err := server.renderedResult(request.Context(), hash)
+	if err != nil {
+		writeServerError(response, err)
+		return
+	}
+	writeJSON(response, result.analysis)
+}
+
+// handleJobs lists the renders that are queued, running or recently finished, with their progress.
+func (server *wallpaperServer) handleJobs(response http.ResponseWriter, request *http.Request) {
+	if !allowMethod(response, request, http.MethodGet) {
+		return
+	}
+	writeJSON(response, server.renders.List())
+}
+
+// handleCancelJob cancels the render with POST ?id=. The request waiting for it gets an error.
+//   The returned status says running until the render has stopped.
+func (server *wallpaperServer) handleCancelJob(response http.ResponseWriter, request *http.Request) {
+	if !allowMethod(response, request, http.MethodPost) || !requireJSON(response, request) {
+		return
+	}
+	id, err := strconv.Atoi(request.URL.Query().Get("id"))
+	if err != nil {
+		writeServerError(response, invalidConfigError(errors.New("cancel needs a job ?id=")))
+		return
+	}
+	err = server.renders.Cancel(id)
+	if err != nil {
+		writeServerError(response, invalidConfigError(err))
+		return
+	}
+	status, _ := server.renders.Status(id)
+	writeJSON(response, status)
+}
+
+// handleSources saves an uploaded source image and returns the filename to use as sample_source_filename.
+//   The request body is a sourceUpload. Files are named after their contents, so uploading twice is harmless.
+func (server *wallpaperServer) handleSources(response http.ResponseWriter, request *http.Request) {
+	if !allowMethod(response, request, http.MethodPost) || !requireJSON(response, request) {
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(response, request.Body, serveMaximumUploadBody))
+	if err != nil {
+		writeServerError(response, invalidConfigError(fmt.Errorf("cannot read the upload: %v", err)))
+		return
+	}
+	var upload sourceUpload
+	err = json.Unmarshal(body, &upload)
+	if err != nil {
+		writeServerError(response, invalidConfigError(fmt.Errorf("cannot parse the upload: %v", err)))
+		return
+	}
+	contents := upload.Image
+	if len(contents) > serveMaximumUpload {
+		writeServerError(response, invalidConfigError(fmt.Errorf("uploads can be at most %d bytes", serveMaximumUpload)))
+		return
+	}
+	_, imageFormat, err := image.DecodeConfig(bytes.NewReader(contents))
+	if err != nil {
+		writeServerError(response, invalidConfigError(fmt.Errorf("upload is not an image: %v", err)))
+		return
+	}
+
+	contentHash := sha256.Sum256(contents)
+	filename := filepath.Join(server.uploadDirectory, hex.EncodeToString(contentHash[:8])+"."+imageFormat)
+	err = ioutil.WriteFile(filename, contents, 0644)
+	if err != nil {
+		writeServerError(response, err)
+		return
+	}
+	writeJSON(response, map[string]string{"filename": filename})
+}
+
+// requestedHash returns the hash in the ?hash= query, or remembers the posted config and returns its hash.
+func (server *wallpaperServer) requestedHash(request *http.Request) (string, error) {
+	if request.Method == http.MethodPost {
+		return server.storeConfig(request)
+	}
+	hash := request.URL.Query().Get("hash")
+	if hash == "" {
+		return "", invalidConfigError(errors.New("GET needs a ?hash= from a posted config"))
+	}
+	return hash, nil
+}
+
+// storeConfig parses the posted CreateWallpaperCommandMarshal and remembers it by its hash.
+//   The hash covers the config and the source image's contents, so editing the source renders again.
+func (server *wallpaperServer) storeConfig(request *http.Request) (string, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(request.Body, serveMaximumConfig))
+	if err != nil {
+		return "", err
+	}
+	var config command.CreateWallpaperCommandMarshal
+	err = json.Unmarshal(body, &config)
+	if err != nil {
+		return "", invalidConfigError(fmt.Errorf("cannot parse the config: %v", err))
+	}
+	// Marshaling the parsed config again ignores formatting and unknown fields.
+	canonical, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromJSON(canonical)
+	if err != nil {
+		return "", invalidConfigError(fmt.Errorf("cannot parse the config: %v", err))
+	}
+
+	if wallpaperCommand.GradientPalette != nil && wallpaperCommand.GradientPalette.LibraryFilename != "" {
+		err = server.requireAllowedLibrary(wallpaperCommand.GradientPalette.LibraryFilename)
+		if err != nil {
+			return "", invalidConfigError(err)
+		}
+	}
+
+	hasher := sha256.New()
+	hasher.Write(canonical)
+	if wallpaperCommand.UsesSourceImage() || wallpaperCommand.ExtractsPalette() {
+		err = server.requireAllowedSource(wallpaperCommand.SampleSourceFilename)
+		if err != nil {
+			return "", invalidConfigError(err)
+		}
+		sourceHash, err := hashFile(wallpaperCommand.SampleSourceFilename)
+		if err != nil {
+			return "", fileError(err)
+		}
+		io.WriteString(hasher, sourceHash)
+	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	server.cacheLock.Lock()
+	defer server.cacheLock.Unlock()
+	if server.cache[hash] == nil {
+		server.cache[hash] = &serverResult{config: canonical}
+		server.cacheOrder = append(server.cacheOrder, hash)
+		if len(server.cacheOrder) > serveCacheSize {
+			delete(server.cache, server.cacheOrder[0])
+			server.cacheOrder = server.cacheOrder[1:]
+		}
+	}
+	return hash, nil
+}
+
+// requireAllowedSource makes sure a posted config only samples an uploaded image or the config file's own source image,
+//   so the server cannot be used to read other files.
+func (server *wallpaperServer) requireAllowedSource(filename string) error {
+	absoluteFilename, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+	if sameFilename(absoluteFilename, server.defaultSourceFilename) {
+		return nil
+	}
+	uploadDirectory, err := filepath.Abs(server.uploadDirectory)
+	if err != nil {
+		return err
+	}
+	relativeFilename, err := filepath.Rel(uploadDirectory, absoluteFilename)
+	if err != nil || relativeFilename == ".." || strings.HasPrefix(relativeFilename, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("sample_source_filename must be an uploaded image or the config file's own, not %q", filename)
+	}
+	return nil
+}
+
+// requireAllowedLibrary makes sure a posted config only reads the config file's own palette library.
+//   Reading any other file could show parts of it in the parse error.
+func (server *wallpaperServer) requireAllowedLibrary(filename string) error {
+	absoluteFilename, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+	if !sameFilename(absoluteFilename, server.defaultLibraryFilename) {
+		return fmt.Errorf("library_filename must be the config file's own palette library, not %q", filename)
+	}
+	return nil
+}
+
+// sameFilename is true if the relative filename names the absolute one. An empty filename names nothing.
+func sameFilename(absoluteFilename, filename string) bool {
+	if filename == "" {
+		return false
+	}
+	otherFilename, err := filepath.Abs(filename)
+	return err == nil && otherFilename == absoluteFilename
+}
+
+// renderedResult returns the remembered config's image and analysis, rendering them the first time they are asked for.
+//   The render waits its turn in the job queue. If ctx is cancelled first, the render is cancelled too.
+func (server *wallpaperServer) renderedResult(ctx context.Context, hash string) (*serverResult, error) {
+	server.cacheLock.Lock()
+	result := server.cache[hash]
+	rendered := result != nil && result.image != nil
+	server.cacheLock.Unlock()
+	if result == nil {
+		return nil, invalidConfigError(fmt.Errorf("no config with hash %q, post it first", hash))
+	}
+	if rendered {
+		return result, nil
+	}
+
+	finished := make(chan struct{})
+	var renderErr error
+	id := server.renders.Submit(hash[:12], func(jobCtx context.Context, reportProgress func(render.Progress)) error {
+		defer close(finished)
+		renderErr = server.renderResult(jobCtx, hash, result, reportProgress)
+		return renderErr
+	})
+	select {
+	case <-finished:
+		return result, renderErr
+	case <-ctx.Done():
+		server.renders.Cancel(id)
+		return nil, ctx.Err()
+	}
+}
+
+// renderResult renders the result's config and remembers the image and analysis, unless an earlier job already did.
+func (server *wallpaperServer) renderResult(ctx context.Context, hash string, result *serverResult, reportProgress func(render.Progress)) error {
+	server.cacheLock.Lock()
+	rendered := result.image != nil
+	server.cacheLock.Unlock()
+	if rendered {
+		return nil
+	}
+
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromJSON(result.config)
+	if err != nil {
+		return invalidConfigError(err)
+	}
+	renderedImage, analysis, err := server.renderToMemory(ctx, wallpaperCommand, reportProgress)
+	if err != nil {
+		return err
+	}
+	analysis.Hash = hash
+
+	server.cacheLock.Lock()
+	defer server.cacheLock.Unlock()
+	result.image, result.analysis = renderedImage, analysis
+	return nil
+}
+
+// renderToMemory renders the command to a PNG without touching its output or field files.
+func (server *wallpaperServer) renderToMemory(ctx context.Context, wallpaperCommand *command.CreateWallpaperCommand, reportProgress func(render.Progress)) ([]byte, *serverAnalysis, error) {
+	renderSettings, err := renderSettingsForCommand(wallpaperCommand, server.options)
+	if err != nil {
+		return nil, nil, err
+	}
+	renderSettings.Progress = reportProgress
+	if renderSettings.OutputBounds.Dx()*renderSettings.OutputBounds.Dy()*renderSettings.SamplesPerPixel() > serveMaximumSamples {
+		return nil, nil, invalidConfigError(fmt.Errorf("the server renders at most %d samples, ask for a smaller output_size or supersample", serveMaximumSamples))
+	}
+	commandColorizer, err := colorizerForCommand(wallpaperCommand)
+	if err != nil {
+		return nil, nil, err
+	}
+	activeFormula, err := formulaForCommand(wallpaperCommand)
+	if err != nil {
+		return nil, nil, err
+	}
+	renderSettings.Formula = activeFormula
+
+	outputImage := image.NewNRGBA(renderSettings.OutputBounds)
+	var statistics *formula.ResultStatistics
+	if choosesColorValueSpace(wallpaperCommand) {
+		valueField, fieldStatistics, err := render.CalculateField(ctx, *renderSettings, wallpaperCommand.FieldHash())
+		if err != nil {
+			return nil, nil, err
+		}
+		statistics = fieldStatistics
+		err = chooseColorValueSpace(wallpaperCommand, valueField.Values)
+		if err != nil {
+			return nil, nil, err
+		}
+		renderSettings.Colorizer = useColorValueSpace(wallpaperCommand, commandColorizer)
+		err = render.ColorizeField(ctx, *renderSettings, valueField, outputImage)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		renderSettings.Colorizer = useColorValueSpace(wallpaperCommand, commandColorizer)
+		statistics, err = render.Render(ctx, *renderSettings, outputImage)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	recipe, err := recipeTextChunks(wallpaperCommand)
+	if err != nil {
+		return nil, nil, err
+	}
+	var encoded bytes.Buffer
+	err = pngstream.EncodeWithText(&encoded, outputImage, recipe)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	analysis := &serverAnalysis{
+		Symmetries: activeFormula.Symmetries(),
+		Total:      newServerStatistics(&statistics.Total),
+		ByTerm:     []serverStatistics{},
+	}
+	for index := range statistics.ByTerm {
+		analysis.ByTerm = append(analysis.ByTerm, newServerStatistics(&statistics.ByTerm[index]))
+	}
+	if wallpaperCommand.UsesSourceImage() {
+		bounds := wallpaperCommand.ColorValueSpace.ComplexNumberCorners
+		analysis.ColorValueSpace = &bounds
+	}
+	return encoded.Bytes(), analysis, nil
+}
+
+func newServerStatistics(statistics *mathutility.Statistics) serverStatistics {
+	return serverStatistics{
+		Min:      complexForMarshal(statistics.Min),
+		Max:      complexForMarshal(statistics.Max),
+		Mean:     complexForMarshal(statistics.Mean()),
+		Count:    statistics.Count,
+		NaNCount: statistics.NaNCount,
+		InfCount: statistics.InfCount,
+	}
+}
+
+func complexForMarshal(number complex128) utility.ComplexNumberForMarshal {
+	return utility.ComplexNumberForMarshal{Real: real(number), Imaginary: imag(number)}
+}
+
+// allowMethod answers 405 Method Not Allowed and returns false unless the request uses one of the methods.
+func allowMethod(response http.ResponseWriter, request *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if request.Method == method {
+			return true
+		}
+	}
+	http.Error(response, fmt.Sprintf("%s is not allowed here", request.Method), http.StatusMethodNotAllowed)
+	return false
+}
+
+// requireJSON answers 415 Unsupported Media Type and returns false unless a POST says its body is JSON.
+//   Browsers will not send that from another site's page without asking the server first, and the server never agrees.
+func requireJSON(response http.ResponseWriter, request *http.Request) bool {
+	if request.Method != http.MethodPost {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		http.Error(response, "POST needs Content-Type: application/json", http.StatusUnsupportedMediaType)
+		return false
+	}
+	return true
+}
+
+// writeServerError answers 400 Bad Request for problems with the config or its files, and 500 for anything else.
+func writeServerError(response http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if exitErr, ok := err.(*exitError); ok && (exitErr.exitCode == exitCodeInvalidConfig || exitErr.exitCode == exitCodeFileError) {
+		status = http.StatusBadRequest
+	}
+	http.Error(response, err.Error(), status)
+}
+
+func writeJSON(response http.ResponseWriter, value interface{}) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		writeServerError(response, err)
+		return
+	}
+	response.Header().Set("Content-Type", "application/json")
+	response.Write(encoded)
+}
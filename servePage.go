@@ -0,0 +1,231 @@
+package main
+
+// servePage is the editor served by serve. It loads nothing from other sites, so it works offline.
+//   Sliders are made for every multiplier and power in the config. Moving one renders a small preview.
+const servePage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Wallpaper editor</title>
+<style>
+body { font-family: sans-serif; margin: 0; display: flex; height: 100vh; background: #202020; color: #e0e0e0; }
+#controls { width: 420px; overflow: auto; padding: 12px; background: #2a2a2a; }
+#result { flex: 1; overflow: auto; padding: 12px; }
+textarea { width: 100%; height: 240px; font-family: monospace; font-size: 11px; }
+.slider { display: grid; grid-template-columns: 1fr 140px 60px; gap: 4px; align-items: center; font-size: 12px; }
+.slider span { overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+#error { color: #ff8080; white-space: pre-wrap; }
+pre { font-size: 11px; }
+</style>
+</head>
+<body>
+<div id="controls">
+<h3>Wallpaper editor</h3>
+<p>
+<label>Preview size <input id="previewSide" type="number" value="300" min="16" max="4096" style="width: 70px"></label>
+<label><input id="fullSize" type="checkbox"> full size</label>
+<button id="renderButton">Render</button>
+</p>
+<p><label>Source image <input id="upload" type="file" accept="image/*"></label></p>
+<div id="sliders"></div>
+<h4>Config</h4>
+<textarea id="config" spellcheck="false"></textarea>
+<button id="applyButton">Apply config</button>
+</div>
+<div id="result">
+<div id="error"></div>
+<img id="image" alt="">
+<pre id="analysis"></pre>
+</div>
+<script>
+"use strict";
+var config = {};
+var rendering = false;
+var renderAgain = false;
+var renderTimer = null;
+
+function element(id) { return document.getElementById(id); }
+
+function showError(message) { element("error").textContent = message || ""; }
+
+function showConfig() { element("config").value = JSON.stringify(config, null, 2); }
+
+function valueAt(path) {
+  var value = config;
+  path.forEach(function (key) { value = value[key]; });
+  return value;
+}
+
+function setValueAt(path, newValue) {
+  var parent = valueAt(path.slice(0, -1));
+  parent[path[path.length - 1]] = newValue;
+}
+
+function addSlider(path, minimum, maximum, step) {
+  var row = document.createElement("div");
+  row.className = "slider";
+  var label = document.createElement("span");
+  label.textContent = path.join(".");
+  label.title = label.textContent;
+  var range = document.createElement("input");
+  range.type = "range";
+  range.min = minimum;
+  range.max = maximum;
+  range.step = step;
+  range.value = valueAt(path);
+  var number = document.createElement("input");
+  number.type = "number";
+  number.step = step;
+  number.value = valueAt(path);
+  function update(source) {
+    var newValue = parseFloat(source.value);
+    if (isNaN(newValue)) { return; }
+    range.value = newValue;
+    number.value = newValue;
+    setValueAt(path, newValue);
+    showConfig();
+    scheduleRender();
+  }
+  range.addEventListener("input", function () { update(range); });
+  number.addEventListener("change", function () { update(number); });
+  row.appendChild(label);
+  row.appendChild(range);
+  row.appendChild(number);
+  element("sliders").appendChild(row);
+}
+
+function addSliders(value, path) {
+  if (value === null || typeof value !== "object") { return; }
+  Object.keys(value).forEach(function (key) {
+    var child = value[key];
+    var childPath = path.concat([Array.isArray(value) ? Number(key) : key]);
+    if (key === "multiplier" && child && typeof child.real === "number") {
+      addSlider(childPath.concat(["real"]), -3, 3, 0.01);
+      addSlider(childPath.concat(["imaginary"]), -3, 3, 0.01);
+    } else if ((key === "power_n" || key === "power_m") && typeof child === "number") {
+      addSlider(childPath, -12, 12, 1);
+    } else {
+      addSliders(child, childPath);
+    }
+  });
+}
+
+function buildSliders() {
+  element("sliders").innerHTML = "";
+  addSliders(config, []);
+}
+
+// requestConfig shrinks the output to the preview size, keeping the aspect ratio, unless full size is checked.
+function requestConfig() {
+  var requested = JSON.parse(JSON.stringify(config));
+  var size = requested.output_size;
+  var previewSide = parseInt(element("previewSide").value, 10) || 300;
+  if (!element("fullSize").checked && size && size.width > 0 && size.height > 0) {
+    var scale = previewSide / Math.max(size.width, size.height);
+    if (scale < 1) {
+      size.width = Math.max(1, Math.round(size.width * scale));
+      size.height = Math.max(1, Math.round(size.height * scale));
+    }
+  }
+  return requested;
+}
+
+function scheduleRender() {
+  if (renderTimer !== null) { clearTimeout(renderTimer); }
+  renderTimer = setTimeout(function () { renderTimer = null; render(); }, 250);
+}
+
+function render() {
+  if (rendering) { renderAgain = true; return; }
+  rendering = true;
+  fetch("/api/render", {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(requestConfig())
+  })
+    .then(function (response) {
+      if (!response.ok) {
+        return response.text().then(function (message) { throw new Error(message); });
+      }
+      var hash = response.headers.get("X-Config-Hash");
+      return response.blob().then(function (blob) {
+        var image = element("image");
+        if (image.src) { URL.revokeObjectURL(image.src); }
+        image.src = URL.createObjectURL(blob);
+        showError("");
+        return fetch("/api/analysis?hash=" + encodeURIComponent(hash));
+      });
+    })
+    .then(function (response) { return response.json(); })
+    .then(function (analysis) { element("analysis").textContent = JSON.stringify(analysis, null, 2); })
+    .catch(function (error) { showError(error.message); })
+    .then(function () {
+      rendering = false;
+      if (renderAgain) { renderAgain = false; render(); }
+    });
+}
+
+function applyConfig() {
+  try {
+    config = JSON.parse(element("config").value);
+  } catch (error) {
+    showError("Config is not valid JSON: " + error.message);
+    return;
+  }
+  buildSliders();
+  render();
+}
+
+function readBase64(file) {
+  return new Promise(function (resolve, reject) {
+    var reader = new FileReader();
+    reader.onload = function () { resolve(reader.result.slice(reader.result.indexOf(",") + 1)); };
+    reader.onerror = function () { reject(reader.error); };
+    reader.readAsDataURL(file);
+  });
+}
+
+function upload() {
+  var file = element("upload").files[0];
+  if (!file) { return; }
+  readBase64(file)
+    .then(function (image) {
+      return fetch("/api/sources", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ image: image })
+      });
+    })
+    .then(function (response) {
+      if (!response.ok) {
+        return response.text().then(function (message) { throw new Error(message); });
+      }
+      return response.json();
+    })
+    .then(function (uploaded) {
+      config.sample_source_filename = uploaded.filename;
+      showConfig();
+      render();
+    })
+    .catch(function (error) { showError(error.message); });
+}
+
+element("renderButton").addEventListener("click", render);
+element("applyButton").addEventListener("click", applyConfig);
+element("upload").addEventListener("change", upload);
+element("previewSide").addEventListener("change", scheduleRender);
+element("fullSize").addEventListener("change", scheduleRender);
+
+fetch("/api/config")
+  .then(function (response) { return response.json(); })
+  .then(function (loaded) {
+    config = loaded;
+    showConfig();
+    buildSliders();
+    render();
+  })
+  .catch(function (error) { showError(error.message); });
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	. "gopkg.in/check.v1"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"wallpaper/entities/jobs"
+)
+
+type ServeSuite struct {
+	server  *wallpaperServer
+	handler http.Handler
+}
+
+var _ = Suite(&ServeSuite{})
+
+func (suite *ServeSuite) SetUpTest(checker *C) {
+	suite.server = &wallpaperServer{
+		options:                &commandLineOptions{},
+		defaultConfig:          []byte("{}"),
+		defaultSourceFilename:  "data/source.png",
+		defaultLibraryFilename: "data/palettes.yml",
+		uploadDirectory:        checker.MkDir(),
+		renders:                jobs.NewQueue(context.Background(), 1, nil),
+		cache:                  map[string]*serverResult{},
+	}
+	suite.handler = suite.server.handler()
+}
+
+func (suite *ServeSuite) serve(method, host, path, contentType string, body []byte) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, bytes.NewReader(body))
+	request.Host = host
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	response := httptest.NewRecorder()
+	suite.handler.ServeHTTP(response, request)
+	return response
+}
+
+func (suite *ServeSuite) TestAnswersRequestsForLocalhost(checker *C) {
+	for _, host := range []string{"localhost:8080", "127.0.0.1:8080", "[::1]:8080", "localhost"} {
+		response := suite.serve(http.MethodGet, host, "/api/config", "", nil)
+		checker.Assert(response.Code, Equals, http.StatusOK, Commentf("host %s", host))
+	}
+}
+
+func (suite *ServeSuite) TestRefusesRequestsForOtherHosts(checker *C) {
+	for _, host := range []string{"example.com:8080", "example.com", "192.168.1.2:8080", "localhost.example.com"} {
+		response := suite.serve(http.MethodGet, host, "/api/config", "", nil)
+		checker.Assert(response.Code, Equals, http.StatusForbidden, Commentf("host %s", host))
+	}
+}
+
+func (suite *ServeSuite) TestPostsMustBeJSON(checker *C) {
+	for _, path := range []string{"/api/render", "/api/configs", "/api/analysis", "/api/sources", "/api/jobs/cancel?id=1"} {
+		for _, contentType := range []string{"", "text/plain", "application/x-www-form-urlencoded", "multipart/form-data; boundary=x"} {
+			response := suite.serve(http.MethodPost, "localhost:8080", path, contentType, []byte("{}"))
+			checker.Assert(response.Code, Equals, http.StatusUnsupportedMediaType, Commentf("%s as %q", path, contentType))
+		}
+	}
+}
+
+func (suite *ServeSuite) TestUploadSavesTheImageInTheUploadDirectory(checker *C) {
+	var encoded bytes.Buffer
+	png.Encode(&encoded, image.NewNRGBA(image.Rect(0, 0, 2, 2)))
+	body, _ := json.Marshal(sourceUpload{Image: encoded.Bytes()})
+
+	response := suite.serve(http.MethodPost, "localhost:8080", "/api/sources", "application/json; charset=utf-8", body)
+	checker.Assert(response.Code, Equals, http.StatusOK)
+	var uploaded map[string]string
+	checker.Assert(json.Unmarshal(response.Body.Bytes(), &uploaded), IsNil)
+	checker.Assert(filepath.Dir(uploaded["filename"]), Equals, suite.server.uploadDirectory)
+	checker.Assert(strings.HasSuffix(uploaded["filename"], ".png"), Equals, true)
+	checker.Assert(suite.server.requireAllowedSource(uploaded["filename"]), IsNil)
+}
+
+func (suite *ServeSuite) TestSourcesMustBeUploadsOrTheConfigFilesOwn(checker *C) {
+	checker.Assert(suite.server.requireAllowedSource("data/source.png"), IsNil)
+	checker.Assert(suite.server.requireAllowedSource("./data/../data/source.png"), IsNil)
+	checker.Assert(suite.server.requireAllowedSource(filepath.Join(suite.server.uploadDirectory, "abc.png")), IsNil)
+
+	for _, filename := range []string{
+		"/etc/passwd",
+		"data/other.png",
+		filepath.Join(suite.server.uploadDirectory, "..", "abc.png"),
+		suite.server.uploadDirectory + "-elsewhere/abc.png",
+	} {
+		err := suite.server.requireAllowedSource(filename)
+		checker.Assert(err, ErrorMatches, "sample_source_filename must be an uploaded image .*", Commentf("filename %s", filename))
+	}
+}
+
+func (suite *ServeSuite) TestPostedConfigsCannotReadOtherPaletteLibraries(checker *C) {
+	secretDirectory := checker.MkDir()
+	secretFilename := filepath.Join(secretDirectory, "secret.yml")
+	checker.Assert(ioutil.WriteFile(secretFilename, []byte("password: hunter2\n"), 0644), IsNil)
+
+	config := fmt.Sprintf(`{
+		"rosette_formula": {"terms": [{"multiplier": {"real": 1, "imaginary": 0}, "power_n": 3, "power_m": 0}]},
+		"gradient_palette": {"name": "secret", "library_filename": %q}
+	}`, secretFilename)
+	for _, path := range []string{"/api/configs", "/api/render", "/api/analysis"} {
+		response := suite.serve(http.MethodPost, "localhost:8080", path, "application/json", []byte(config))
+		checker.Assert(response.Code, Equals, http.StatusBadRequest, Commentf("%s", path))
+		checker.Assert(response.Body.String(), Matches, "library_filename must be the config file's own palette library.*\n")
+		checker.Assert(strings.Contains(response.Body.String(), "hunter2"), Equals, false)
+	}
+	checker.Assert(suite.server.cache, HasLen, 0)
+
+	checker.Assert(suite.server.requireAllowedLibrary("./data/palettes.yml"), IsNil)
+	checker.Assert(suite.server.requireAllowedLibrary(secretFilename), NotNil)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+	"wallpaper/entities/command"
+)
+
+// watchPollInterval is how often watch looks at the files for changes.
+const watchPollInterval = 500 * time.Millisecond
+
+// watchedFile is what watch remembers about a file to notice when it changes.
+type watchedFile struct {
+	filename string
+	exists   bool
+	modified time.Time
+	size     int64
+}
+
+// watchWallpaper renders a preview and then the full image, and starts again every time the config file,
+//   the source image or the palette library changes. A change cancels the render in progress.
+//   Errors are printed and watching goes on, so a half edited config does not stop it.
+//   It only returns when ctx is cancelled, after the render in progress has stopped.
+func watchWallpaper(ctx context.Context, options *commandLineOptions) error {
+	var watched []watchedFile
+	var cancelRender context.CancelFunc
+	var renderFinished chan struct{}
+	stopRender := func() {
+		if cancelRender != nil {
+			cancelRender()
+			<-renderFinished
+			cancelRender = nil
+		}
+	}
+	defer stopRender()
+
+	for {
+		if watched == nil || watchedFilesChanged(watched) {
+			stopRender()
+
+			var configYAML []byte
+			configYAML, watched = loadWatchedConfig(options)
+			if configYAML != nil {
+				renderCtx, cancel := context.WithCancel(ctx)
+				cancelRender = cancel
+				renderFinished = make(chan struct{})
+				go func(finished chan struct{}) {
+					defer close(finished)
+					renderPreviewThenFull(renderCtx, configYAML, options)
+				}(renderFinished)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(watchPollInterval):
+		}
+	}
+}
+
+// loadWatchedConfig reads and parses the config file and returns it with the files to watch.
+//   If the config cannot be read or parsed, the error is printed, nil is returned and only the config file is watched.
+func loadWatchedConfig(options *commandLineOptions) ([]byte, []watchedFile) {
+	filenames := []string{options.configFilename}
+	configYAML, err := ioutil.ReadFile(options.configFilename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot read %s: %v\n", options.configFilename, err)
+		return nil, watchFiles(filenames)
+	}
+	wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML(configYAML)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot parse %s: %v\n", options.configFilename, err)
+		return nil, watchFiles(filenames)
+	}
+
+	if wallpaperCommand.UsesSourceImage() || wallpaperCommand.ExtractsPalette() {
+		filenames = append(filenames, wallpaperCommand.SampleSourceFilename)
+	}
+	if wallpaperCommand.GradientPalette != nil && wallpaperCommand.GradientPalette.LibraryFilename != "" {
+		filenames = append(filenames, wallpaperCommand.GradientPalette.LibraryFilename)
+	}
+	fmt.Printf("Rendering %s, watching for changes\n", options.configFilename)
+	return configYAML, watchFiles(filenames)
+}
+
+// renderPreviewThenFull renders a preview, so the change can be seen quickly, and then the full image.
+//   It stops quietly when ctx is cancelled.
+func renderPreviewThenFull(ctx context.Context, configYAML []byte, options *commandLineOptions) {
+	for _, subcommand := range []string{subcommandPreview, subcommandRender} {
+		wallpaperCommand, err := command.NewCreateWallpaperCommandFromYAML(configYAML)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot parse %s: %v\n", options.configFilename, err)
+			return
+		}
+		wallpaperCommand.ApplyOverrides(options.overrides)
+
+		if subcommand == subcommandPreview {
+			err = previewWallpaper(ctx, wallpaperCommand, options)
+		} else {
+			err = renderWallpaper(ctx, wallpaperCommand, options)
+		}
+		if err != nil && ctx.Err() != nil {
+			fmt.Println("Render cancelled")
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s failed: %v\n", subcommand, err)
+			return
+		}
+		if subcommand == subcommandRender {
+			fmt.Printf("Wrote %s\n", wallpaperCommand.OutputFilename)
+		}
+	}
+}
+
+// watchFiles records the current state of each file. Missing files are recorded too, so watch notices when they appear.
+func watchFiles(filenames []string) []watchedFile {
+	watched := []watchedFile{}
+	for _, filename := range filenames {
+		file := watchedFile{filename: filename}
+		info, err := os.Stat(filename)
+		if err == nil {
+			file.exists = true
+			file.modified = info.ModTime()
+			file.size = info.Size()
+		}
+		watched = append(watched, file)
+	}
+	return watched
+}
+
+// watchedFilesChanged returns true if any of the files was created, deleted, modified or resized since it was recorded.
+func watchedFilesChanged(watched []watchedFile) bool {
+	for _, file := range watched {
+		current := watchFiles([]string{file.filename})[0]
+		if current.exists != file.exists || !current.modified.Equal(file.modified) || current.size != file.size {
+			return true
+		}
+	}
+	return false
+}